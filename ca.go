@@ -0,0 +1,568 @@
+package main
+
+// Автономный удостоверяющий центр (УЦ) для тестовой инфраструктуры на алгоритмах ГОСТ
+// Каталог УЦ (--ca-dir):
+//   ca.pem - сертификат УЦ
+//   ca_private.sigkey - приватный ключ УЦ в самоописывающем формате
+//   index.txt - перечень выпущенных сертификатов: статус (V - действителен, R - отозван), серийный номер,
+//     окончание срока действия, субъект, для отозванных - время и причина отзыва
+//   certs/<серийный номер>.pem - выпущенные сертификаты
+//   crlnumber - номер следующего списка отзыва
+//   crlbase - номер и время выпуска последнего полного списка отзыва, к которому выпускаются разностные
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"gost34102012/utils"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	caCertFile  = "ca.pem"
+	caKeyFile   = "ca_private.sigkey"
+	caIndexFile = "index.txt"
+	caCertsDir  = "certs"
+	caCRLNumber = "crlnumber"
+	caCRLBase   = "crlbase"
+
+	// Срок действия корневого сертификата по умолчанию в днях
+	caRootDays = 3650
+	// Период выпуска полного и разностного списков отзыва по умолчанию в днях
+	caCRLDays   = 7
+	caDeltaDays = 1
+)
+
+// Профиль выпускаемого сертификата: шаблон с расширениями и срок действия по умолчанию в днях
+func certificateProfile(profile string) (*utils.CertificateTemplate, int, error) {
+	switch profile {
+	case "intermediate":
+		// Промежуточный УЦ выпускает только конечные сертификаты
+		return &utils.CertificateTemplate{
+			IsCA:       true,
+			MaxPathLen: 0,
+			KeyUsage:   x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		}, 1825, nil
+	case "end-entity":
+		return &utils.CertificateTemplate{
+			KeyUsage: x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		}, 365, nil
+	case "ocsp-responder":
+		// Ответчик OCSP, уполномоченный УЦ подписывать ответы о выпущенных им сертификатах
+		return &utils.CertificateTemplate{
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		}, 365, nil
+	}
+	return nil, 0, fmt.Errorf("Неизвестный профиль сертификата: %s, должен быть intermediate, end-entity или ocsp-responder", profile)
+}
+
+// Создание каталога УЦ
+// Если certFile не задан, выпускается самоподписанный корневой сертификат с субъектом subject
+// ключом из keyFile или новым ключом. Если certFile задан, каталог создается для выпущенного
+// ранее сертификата УЦ (например, промежуточного) и ключа keyFile
+func caInit(s *utils.Signer, dir, subject, keyFile, certFile string, days int, encrypt bool, pc utils.PBECipher) (*utils.Certificate, error) {
+	if _, err := os.Stat(filepath.Join(dir, caCertFile)); err == nil {
+		return nil, fmt.Errorf("Каталог %s уже содержит УЦ", dir)
+	}
+	if !keyFileSupported(s.Curve()) {
+		return nil, fmt.Errorf("УЦ поддерживается только для стандартных наборов параметров")
+	}
+	if certFile != "" && keyFile == "" {
+		return nil, fmt.Errorf("Для сертификата УЦ %s укажите его приватный ключ в --key", certFile)
+	}
+
+	// Пароль запрашивается до создания файлов
+	var pass []byte
+	var err error
+	if encrypt {
+		if pass, err = readNewPassphrase(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ключ УЦ: существующий или новый
+	var privKey *utils.PrivateKey
+	if keyFile != "" {
+		if privKey, err = readPrivkey(s, keyFile); err != nil {
+			return nil, err
+		}
+	} else if _, privKey, err = s.GenerateKeyPair(); err != nil {
+		return nil, err
+	}
+	pubKey := privKey.Public().(*utils.PublicKey)
+
+	var cert *utils.Certificate
+	if certFile != "" {
+		data, err := os.ReadFile(certFile)
+		if err != nil {
+			return nil, err
+		}
+		if cert, err = utils.ParseCertificatePEM(data); err != nil {
+			return nil, err
+		}
+		if !cert.BasicConstraintsValid || !cert.IsCA {
+			return nil, fmt.Errorf("Сертификат %s не является сертификатом УЦ", certFile)
+		}
+		if !cert.PublicKey.Equal(pubKey) {
+			return nil, fmt.Errorf("Ключ %s не соответствует сертификату %s", keyFile, certFile)
+		}
+	} else {
+		if subject == "" {
+			return nil, fmt.Errorf("Не указан субъект сертификата УЦ. Укажите параметр --subject <имя>")
+		}
+		name, err := utils.ParseDistinguishedName(subject)
+		if err != nil {
+			return nil, err
+		}
+		if days <= 0 {
+			days = caRootDays
+		}
+		tmpl := &utils.CertificateTemplate{
+			Subject:    name,
+			IsCA:       true,
+			MaxPathLen: -1,
+			KeyUsage:   x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		}
+		tmpl.NotBefore = time.Now()
+		tmpl.NotAfter = tmpl.NotBefore.AddDate(0, 0, days)
+
+		// Подробнее в utils/x509_create.go
+		der, err := s.CreateCertificate(tmpl, pubKey, nil, privKey)
+		if err != nil {
+			return nil, err
+		}
+		if cert, err = utils.ParseCertificate(der); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, caCertsDir), 0700); err != nil {
+		return nil, err
+	}
+	if err := writePrivkey(filepath.Join(dir, caKeyFile), privKey, pass, pc); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, caIndexFile), nil, 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, caCertFile), cert.MarshalPEM(), 0644); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// Загрузка сертификата УЦ из каталога
+func loadCACert(dir string) (*utils.Certificate, error) {
+	data, err := os.ReadFile(filepath.Join(dir, caCertFile))
+	if err != nil {
+		return nil, fmt.Errorf("Каталог %s не содержит УЦ: %w", dir, err)
+	}
+	return utils.ParseCertificatePEM(data)
+}
+
+// Разбиение списка значений через запятую без пустых значений
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Формирование запроса на сертификат для ключа из keyFile
+// emails - адреса электронной почты через запятую для subjectAltName
+func genCSR(s *utils.Signer, keyFile, subject, emails, signTool string) (*utils.CertificateRequest, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("Не указан субъект запроса. Укажите параметр --subject <имя>")
+	}
+	name, err := utils.ParseDistinguishedName(subject)
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := readPrivkey(s, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &utils.CertificateRequestTemplate{
+		Subject:         name,
+		SubjectSignTool: signTool,
+	}
+	tmpl.EmailAddresses = splitList(emails)
+
+	// Подробнее в utils/csr.go
+	der, err := s.CreateCertificateRequest(tmpl, privKey)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ParseCertificateRequest(der)
+}
+
+// Проверка подписи запроса на сертификат и вывод его содержимого
+func verifyCSR(csrFile string) error {
+	data, err := os.ReadFile(csrFile)
+	if err != nil {
+		return err
+	}
+	csr, err := utils.ParseCertificateRequestPEM(data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Субъект: %s\n", csr.Subject)
+	fmt.Printf("Набор параметров ключа: %s (%s)\n", csr.ParamSet.Name, csr.ParamSet.OID)
+	for _, email := range csr.EmailAddresses {
+		fmt.Printf("Адрес электронной почты: %s\n", email)
+	}
+	for _, ext := range csr.Extensions {
+		fmt.Printf("Запрошенное расширение: %s\n", ext.Id)
+	}
+	return csr.CheckSignature()
+}
+
+// Выпуск сертификата по запросу на сертификат из csrFile
+// Подпись запроса проверяется, субъект и ключ переносятся из запроса без изменений,
+// запрошенные расширения переносятся, если не задаются профилем
+// Срок действия ограничивается сроком действия сертификата УЦ
+// crlURLs и ocspURLs - URL списков отзыва и ответчиков OCSP через запятую для расширений
+// cRLDistributionPoints и authorityInfoAccess
+func caIssue(s *utils.Signer, dir, csrFile, profile string, days int, crlURLs, ocspURLs string) (*utils.Certificate, error) {
+	caCert, err := loadCACert(dir)
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := readPrivkey(s, filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(csrFile)
+	if err != nil {
+		return nil, err
+	}
+	// Подробнее в utils/csr.go
+	csr, err := utils.ParseCertificateRequestPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	tmpl, defaultDays, err := certificateProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	if days <= 0 {
+		days = defaultDays
+	}
+	tmpl.RawSubject = csr.RawSubject
+	tmpl.CRLDistributionPoints = splitList(crlURLs)
+	tmpl.OCSPServer = splitList(ocspURLs)
+	tmpl.AddRequestedExtensions(csr)
+	tmpl.NotBefore = time.Now()
+	tmpl.NotAfter = tmpl.NotBefore.AddDate(0, 0, days)
+	if tmpl.NotAfter.After(caCert.NotAfter) {
+		fmt.Printf("Срок действия сокращен до окончания срока действия сертификата УЦ: %s\n", caCert.NotAfter.Format(time.RFC3339))
+		tmpl.NotAfter = caCert.NotAfter
+	}
+
+	// Подробнее в utils/x509_create.go
+	der, err := s.CreateCertificate(tmpl, csr.PublicKey, caCert, privKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := utils.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	certPath := filepath.Join(dir, caCertsDir, fmt.Sprintf("%x.pem", cert.SerialNumber))
+	if err := os.WriteFile(certPath, cert.MarshalPEM(), 0644); err != nil {
+		return nil, err
+	}
+	return cert, appendCAIndex(dir, cert)
+}
+
+// Добавление выпущенного сертификата в перечень index.txt
+func appendCAIndex(dir string, cert *utils.Certificate) error {
+	f, err := os.OpenFile(filepath.Join(dir, caIndexFile), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	subject := strings.ReplaceAll(cert.Subject.String(), "\t", " ")
+	_, err = fmt.Fprintf(f, "V\t%x\t%s\t%s\n", cert.SerialNumber, cert.NotAfter.UTC().Format(time.RFC3339), subject)
+	return err
+}
+
+// Запись перечня выпущенных сертификатов index.txt
+type caIndexEntry struct {
+	status   string
+	serial   string
+	notAfter string
+	subject  string
+	// Время и причина отзыва для статуса R
+	revoked time.Time
+	reason  int
+}
+
+// Чтение перечня выпущенных сертификатов
+func readCAIndex(dir string) ([]caIndexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, caIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	var entries []caIndexEntry
+	for n, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("%s, строка %d: неверный формат", caIndexFile, n+1)
+		}
+		entry := caIndexEntry{status: fields[0], serial: fields[1], notAfter: fields[2], subject: fields[3]}
+		if entry.status == "R" {
+			if len(fields) != 6 {
+				return nil, fmt.Errorf("%s, строка %d: не указаны время и причина отзыва", caIndexFile, n+1)
+			}
+			if entry.revoked, err = time.Parse(time.RFC3339, fields[4]); err != nil {
+				return nil, fmt.Errorf("%s, строка %d: %w", caIndexFile, n+1, err)
+			}
+			if entry.reason, err = utils.LookupReason(fields[5]); err != nil {
+				return nil, fmt.Errorf("%s, строка %d: %w", caIndexFile, n+1, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Запись перечня выпущенных сертификатов через временный файл
+func writeCAIndex(dir string, entries []caIndexEntry) error {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s", e.status, e.serial, e.notAfter, e.subject)
+		if e.status == "R" {
+			fmt.Fprintf(&b, "\t%s\t%s", e.revoked.UTC().Format(time.RFC3339), utils.ReasonName(e.reason))
+		}
+		b.WriteString("\n")
+	}
+	path := filepath.Join(dir, caIndexFile)
+	if err := os.WriteFile(path+".tmp", []byte(b.String()), 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Отзыв сертификата УЦ: сертификат задается файлом certFile или серийным номером serial в hex
+// reason - имя причины отзыва по RFC 5280, например keyCompromise
+func caRevoke(dir, certFile, serial, reason string) (*caIndexEntry, error) {
+	caCert, err := loadCACert(dir)
+	if err != nil {
+		return nil, err
+	}
+	code, err := utils.LookupReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	if code == utils.ReasonRemoveFromCRL {
+		return nil, fmt.Errorf("Причина removeFromCRL не используется при отзыве")
+	}
+	if certFile != "" {
+		cert, err := readCertificate(certFile)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(cert.RawIssuer, caCert.RawSubject) || cert.CheckSignatureFrom(caCert) != nil {
+			return nil, fmt.Errorf("Сертификат %s выпущен не УЦ %s", certFile, dir)
+		}
+		serial = fmt.Sprintf("%x", cert.SerialNumber)
+	}
+	if serial == "" {
+		return nil, fmt.Errorf("Не указан отзываемый сертификат. Укажите параметр --cert <имя файла> или --serial <серийный номер>")
+	}
+	serial = strings.ToLower(strings.TrimPrefix(serial, "0x"))
+
+	entries, err := readCAIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].serial != serial {
+			continue
+		}
+		if entries[i].status == "R" {
+			return nil, fmt.Errorf("Сертификат %s уже отозван %s", serial, entries[i].revoked.Format(time.RFC3339))
+		}
+		entries[i].status = "R"
+		entries[i].revoked = time.Now().UTC().Truncate(time.Second)
+		entries[i].reason = code
+		return &entries[i], writeCAIndex(dir, entries)
+	}
+	return nil, fmt.Errorf("Сертификат с серийным номером %s не выпускался УЦ %s", serial, dir)
+}
+
+// Чтение числа из служебного файла УЦ, если файла нет - возвращается def
+func readCANumber(path string, def int64) (*big.Int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return big.NewInt(def), nil
+	} else if err != nil {
+		return nil, err
+	}
+	n, ok := new(big.Int).SetString(strings.TrimSpace(string(data)), 10)
+	if !ok {
+		return nil, fmt.Errorf("Неверное содержимое файла %s", path)
+	}
+	return n, nil
+}
+
+// Выпуск списка отзыва УЦ
+// Полный список содержит все отозванные сертификаты, разностный (delta) - отозванные после
+// выпуска последнего полного списка. days - период выпуска следующего списка
+func caGenCRL(s *utils.Signer, dir string, days int, delta bool) (*utils.RevocationList, error) {
+	caCert, err := loadCACert(dir)
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := readPrivkey(s, filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readCAIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	number, err := readCANumber(filepath.Join(dir, caCRLNumber), 1)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &utils.RevocationListTemplate{Number: number, ThisUpdate: time.Now()}
+	var since time.Time
+	if delta {
+		data, err := os.ReadFile(filepath.Join(dir, caCRLBase))
+		if err != nil {
+			return nil, fmt.Errorf("Разностный список выпускается к полному списку отзыва, выпустите его без --delta: %w", err)
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Неверное содержимое файла %s", caCRLBase)
+		}
+		base, ok := new(big.Int).SetString(fields[0], 10)
+		if !ok {
+			return nil, fmt.Errorf("Неверное содержимое файла %s", caCRLBase)
+		}
+		if since, err = time.Parse(time.RFC3339, fields[1]); err != nil {
+			return nil, fmt.Errorf("Неверное содержимое файла %s: %w", caCRLBase, err)
+		}
+		tmpl.BaseNumber = base
+	}
+	if days <= 0 {
+		days = caCRLDays
+		if delta {
+			days = caDeltaDays
+		}
+	}
+	tmpl.NextUpdate = tmpl.ThisUpdate.AddDate(0, 0, days)
+
+	for _, e := range entries {
+		// Отзыв в ту же секунду, что и выпуск полного списка, включается в разностный список повторно
+		if e.status != "R" || e.revoked.Before(since) {
+			continue
+		}
+		serial, ok := new(big.Int).SetString(e.serial, 16)
+		if !ok {
+			return nil, fmt.Errorf("Неверный серийный номер в %s: %s", caIndexFile, e.serial)
+		}
+		tmpl.RevokedCertificates = append(tmpl.RevokedCertificates, utils.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: e.revoked,
+			ReasonCode:     e.reason,
+		})
+	}
+
+	// Подробнее в utils/crl.go
+	der, err := s.CreateRevocationList(tmpl, caCert, privKey)
+	if err != nil {
+		return nil, err
+	}
+	crl, err := utils.ParseRevocationList(der)
+	if err != nil {
+		return nil, err
+	}
+
+	next := new(big.Int).Add(number, big.NewInt(1))
+	if err := os.WriteFile(filepath.Join(dir, caCRLNumber), []byte(next.String()+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	if !delta {
+		base := number.String() + "\t" + crl.ThisUpdate.UTC().Format(time.RFC3339) + "\n"
+		if err := os.WriteFile(filepath.Join(dir, caCRLBase), []byte(base), 0600); err != nil {
+			return nil, err
+		}
+	}
+	return crl, nil
+}
+
+// Определение статуса сертификата для ответчика OCSP по перечню index.txt УЦ
+// Перечень читается при каждом запросе, поэтому отзыв учитывается сразу, без выпуска списка отзыва
+func caOCSPLookup(dir string) func(serial *big.Int) (int, *utils.RevokedCertificate, error) {
+	return func(serial *big.Int) (int, *utils.RevokedCertificate, error) {
+		entries, err := readCAIndex(dir)
+		if err != nil {
+			return 0, nil, err
+		}
+		hex := fmt.Sprintf("%x", serial)
+		for _, e := range entries {
+			if e.serial != hex {
+				continue
+			}
+			if e.status == "R" {
+				return utils.OCSPRevoked, &utils.RevokedCertificate{SerialNumber: serial, RevocationTime: e.revoked, ReasonCode: e.reason}, nil
+			}
+			return utils.OCSPGood, nil, nil
+		}
+		return utils.OCSPUnknown, nil, nil
+	}
+}
+
+// Ответчик OCSP по базе данных УЦ из каталога dir
+// Ответы подписываются ключом УЦ или, если задан certFile, сертификатом ответчика certFile
+// (профиль ocsp-responder) и его ключом keyFile. validity - срок актуальности ответа
+func caOCSPResponder(s *utils.Signer, dir, certFile, keyFile string, validity time.Duration) (*utils.OCSPResponder, error) {
+	caCert, err := loadCACert(dir)
+	if err != nil {
+		return nil, err
+	}
+	responder := &utils.OCSPResponder{
+		Issuer:   caCert,
+		Signer:   s,
+		Lookup:   caOCSPLookup(dir),
+		Validity: validity,
+	}
+	if certFile == "" {
+		responder.Key, err = readPrivkey(s, filepath.Join(dir, caKeyFile))
+		return responder, err
+	}
+	if keyFile == "" {
+		return nil, fmt.Errorf("Для сертификата ответчика %s укажите его приватный ключ в --key", certFile)
+	}
+	if responder.Certificate, err = readCertificate(certFile); err != nil {
+		return nil, err
+	}
+	if responder.Key, err = readPrivkey(s, keyFile); err != nil {
+		return nil, err
+	}
+	if !responder.Certificate.PublicKey.Equal(responder.Key.Public().(*utils.PublicKey)) {
+		return nil, fmt.Errorf("Ключ %s не соответствует сертификату %s", keyFile, certFile)
+	}
+	return responder, nil
+}
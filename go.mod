@@ -1,3 +1,7 @@
 module gost34102012
 
 go 1.18
+
+require golang.org/x/term v0.29.0
+
+require golang.org/x/sys v0.30.0 // indirect
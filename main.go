@@ -4,29 +4,121 @@ package main
 // точка входа, старт работы в зависимости от переданных аргументов
 
 import (
+	"bytes"
+	"crypto/x509"
 	"flag"
 	"fmt"
 	"gost34102012/utils"
 	"math/big"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
-var (
-	mode256 = 256
-	mode512 = 512
-)
+// Проверка того, что ключ из файла относится к кривой Signer
+func checkKeyCurve(s *utils.Signer, c *utils.Curve, fKey string) error {
+	if !c.Equal(s.Curve()) {
+		return fmt.Errorf("Ключ %s относится к набору параметров %s, укажите его в --params", fKey, c.Name)
+	}
+	return nil
+}
+
+// Определение набора параметров по файлу ключа в самоописывающем формате или сертификату
+// Для файлов других форматов возвращает nil
+// Подробнее в utils/keyfile.go и utils/x509.go
+func keyFileParamSet(fKey string) (*utils.ParamSet, error) {
+	bytes, err := os.ReadFile(fKey)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsCertificatePEM(bytes) {
+		cert, err := utils.ParseCertificatePEM(bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.ParamSet, nil
+	}
+	if !utils.IsKeyFile(bytes) {
+		return nil, nil
+	}
+	kf, err := utils.ParseKeyFile(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return kf.ParamSet, nil
+}
+
+// Определение набора параметров по файлу подписи в самоописывающем формате
+// Для подписей в десятичном формате возвращает nil
+// Подробнее в utils/signature_file.go
+func signatureFileParamSet(fSignature string) (*utils.ParamSet, error) {
+	bytes, err := os.ReadFile(fSignature)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.IsSignatureFile(bytes) {
+		return nil, nil
+	}
+	sf, err := utils.ParseSignatureFile(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return sf.ParamSet, nil
+}
 
 // Чтение публичного ключа из файла в параметре --key
-func readPubkey(fKey string) (*utils.PublicKey, error) {
+// Поддерживаются самоописывающий формат, сертификат X.509 PEM, SubjectPublicKeyInfo PEM и десятичный формат прежних версий
+// Ключ проверяется на принадлежность кривой Signer
+func readPubkey(s *utils.Signer, fKey string) (*utils.PublicKey, error) {
 	// Читаем байтовое содержимое файла
 	bytes, err := os.ReadFile(fKey)
 	if err != nil {
 		return nil, err
 	}
-	// Переводим в строку
-	keyString := string(bytes)
+
+	// Ключ в самоописывающем формате, подробнее в utils/keyfile.go
+	if utils.IsKeyFile(bytes) {
+		kf, err := utils.ParseKeyFile(bytes)
+		if err != nil {
+			return nil, err
+		}
+		if kf.PublicKey == nil {
+			return nil, fmt.Errorf("Файл %s не содержит публичный ключ", fKey)
+		}
+		if err := checkKeyCurve(s, kf.PublicKey.Curve, fKey); err != nil {
+			return nil, err
+		}
+		return utils.NewPublicKeyOnCurve(s.Curve(), kf.PublicKey.X, kf.PublicKey.Y), nil
+	}
+
+	// Ключ субъекта сертификата, подробнее в utils/x509.go
+	if utils.IsCertificatePEM(bytes) {
+		cert, err := utils.ParseCertificatePEM(bytes)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkKeyCurve(s, cert.PublicKey.Curve, fKey); err != nil {
+			return nil, err
+		}
+		return utils.NewPublicKeyOnCurve(s.Curve(), cert.PublicKey.X, cert.PublicKey.Y), nil
+	}
+
+	// Ключ в формате SubjectPublicKeyInfo PEM, подробнее в utils/pkcs8.go
+	if strings.HasPrefix(strings.TrimSpace(string(bytes)), "-----BEGIN") {
+		pKey, err := utils.ParsePublicKeyPEM(bytes)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkKeyCurve(s, pKey.Curve, fKey); err != nil {
+			return nil, err
+		}
+		return utils.NewPublicKeyOnCurve(s.Curve(), pKey.X, pKey.Y), nil
+	}
+
+	// Переводим в строку, отбрасывая перевод строки в конце файла
+	keyString := strings.TrimSpace(string(bytes))
 
 	// Разбиваем на подстроки по переносу строки
 	keyItems := strings.Split(keyString, "\n")
@@ -46,19 +138,76 @@ func readPubkey(fKey string) (*utils.PublicKey, error) {
 	if !ok {
 		return nil, fmt.Errorf("Невозможно получить координату Y из файла. Она должен быть числом в десятичном представлении на второй строке.")
 	}
-	// инициализируем и возвращаем публичный ключ
-	return utils.NewPublicKey(x, y), nil
+	// инициализируем публичный ключ и проверяем его
+	// Подробнее в utils/pubkey_validate.go
+	pKey := utils.NewPublicKeyOnCurve(s.Curve(), x, y)
+	if err := s.ValidatePublicKey(pKey); err != nil {
+		return nil, err
+	}
+	return pKey, nil
 }
 
 // Чтение приватного ключа из файла в параметре --key
-func readPrivkey(fKey string) (*utils.PrivateKey, error) {
+// Поддерживаются самоописывающий формат, PKCS#8 PEM и десятичный формат прежних версий
+// Для зашифрованных ключей запрашивается пароль
+// Ключ привязывается к кривой Signer
+func readPrivkey(s *utils.Signer, fKey string) (*utils.PrivateKey, error) {
 	// Читаем байтовое содержимое файла
 	bytes, err := os.ReadFile(fKey)
 	if err != nil {
 		return nil, err
 	}
-	// Переводим в строку
-	keyString := string(bytes)
+
+	// Ключ в самоописывающем формате, подробнее в utils/keyfile.go
+	if utils.IsKeyFile(bytes) {
+		kf, err := utils.ParseKeyFile(bytes)
+		if err != nil {
+			return nil, err
+		}
+		var pass []byte
+		if kf.IsEncrypted() {
+			pass, err = readPassphrase(fmt.Sprintf("Введите пароль приватного ключа %s: ", fKey))
+			if err != nil {
+				return nil, err
+			}
+		}
+		pKey, err := kf.Decrypt(pass)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkKeyCurve(s, pKey.Curve, fKey); err != nil {
+			return nil, err
+		}
+		return utils.NewPrivateKeyOnCurve(s.Curve(), pKey.D), nil
+	}
+
+	// Ключ в формате PKCS#8 PEM, зашифрованный или открытый
+	// Подробнее в utils/pkcs8.go и utils/pkcs8_encrypted.go
+	if strings.HasPrefix(strings.TrimSpace(string(bytes)), "-----BEGIN") {
+		var pKey *utils.PrivateKey
+		if utils.IsEncryptedPrivateKeyPEM(bytes) {
+			pass, err := readPassphrase(fmt.Sprintf("Введите пароль приватного ключа %s: ", fKey))
+			if err != nil {
+				return nil, err
+			}
+			pKey, err = utils.ParseEncryptedPrivateKeyPEM(bytes, pass)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			pKey, err = utils.ParsePrivateKeyPEM(bytes)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := checkKeyCurve(s, pKey.Curve, fKey); err != nil {
+			return nil, err
+		}
+		return utils.NewPrivateKeyOnCurve(s.Curve(), pKey.D), nil
+	}
+
+	// Переводим в строку, отбрасывая перевод строки в конце файла
+	keyString := strings.TrimSpace(string(bytes))
 	// Разбиваем на подстроки по переносу строки
 	keyItems := strings.Split(keyString, "\n")
 	// Проверяем что в файле была 1 строка, если нет - вернуть ошибку
@@ -72,22 +221,24 @@ func readPrivkey(fKey string) (*utils.PrivateKey, error) {
 		return nil, fmt.Errorf("Невозможно ключ из файла. Ключ должен быть числом в десятичном представлении.")
 	}
 
-	// Проверяем что d != 0
-	if d.Cmp(big.NewInt(0)) == 0 {
-		return nil, fmt.Errorf("Невозможно получить приватный ключ, неверное содержимое файла")
+	// Проверяем что 0 < d < q
+	if d.Sign() <= 0 || d.Cmp(s.Curve().Q) >= 0 {
+		return nil, fmt.Errorf("Невозможно получить приватный ключ, неверное содержимое файла: ключ должен удовлетворять 0 < d < q")
 	}
-	return utils.NewPrivateKey(d), nil
+	return utils.NewPrivateKeyOnCurve(s.Curve(), d), nil
 }
 
 // Чтение сигнатуры (подписи) из файла
-func readSignature(fSignature string) ([]byte, error) {
+// Подпись дополняется ведущими нулями до длины, определяемой режимом Signer
+// В строгом режиме допускается только каноническая десятичная запись числа
+func readSignature(s *utils.Signer, fSignature string, strict bool) ([]byte, error) {
 	// Читаем байтовое содержимое файла
 	bytes, err := os.ReadFile(fSignature)
 	if err != nil {
 		return nil, err
 	}
-	// Переводим в строку
-	signatureString := string(bytes)
+	// Переводим в строку, отбрасывая перевод строки в конце файла
+	signatureString := strings.TrimRight(string(bytes), "\r\n")
 	// Разбиваем на подстроки по переносу строки
 	signatureItems := strings.Split(signatureString, "\n")
 	// Проверяем что в файле была 1 строка, если нет - вернуть ошибку
@@ -102,11 +253,144 @@ func readSignature(fSignature string) ([]byte, error) {
 		return nil, fmt.Errorf("Невозможно получить подпись из файла. Подпись должна быть числом в десятичном представлении.")
 	}
 
-	return signature.Bytes(), nil
+	// Проверяем что запись числа каноническая: без знака и ведущих нулей
+	if strict && signature.String() != signatureItems[0] {
+		return nil, utils.ErrSignatureEncoding
+	}
+
+	// Проверяем что число помещается в подпись фиксированной длины
+	if signature.Sign() < 0 || (signature.BitLen()+7)/8 > s.SignatureSize() {
+		return nil, fmt.Errorf("Невозможно получить подпись из файла. Подпись длиннее %d байт.", s.SignatureSize())
+	}
+
+	return signature.FillBytes(make([]byte, s.SignatureSize())), nil
+}
+
+// Проверка того, что ключи кривой можно записать в самоописывающем формате
+// Для собственных кривых из --curve-file используется десятичный формат
+func keyFileSupported(c *utils.Curve) bool {
+	_, err := utils.ParamSetByCurve(c)
+	return err == nil
+}
+
+// Запись публичного ключа в файл
+func writePubkey(fKey string, pubKey *utils.PublicKey) error {
+	// Самоописывающий формат, подробнее в utils/keyfile.go
+	if keyFileSupported(pubKey.Curve) {
+		pubData, err := utils.MarshalPublicKeyFile(pubKey, time.Now())
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(fKey, pubData, 0600)
+	}
+
+	// Переводим X, Y точки проверки подписи (публичный ключ) в строковое предсталение с разбиение по переносу строки
+	pubData := []byte(fmt.Sprintf("%s\n%s", pubKey.X, pubKey.Y))
+
+	// Записываем строковое представление в файл
+	return os.WriteFile(fKey, pubData, 0600)
+}
+
+// Запись приватного ключа в файл
+// Если pass не nil, ключ шифруется паролем с шифром pc
+func writePrivkey(fKey string, privKey *utils.PrivateKey, pass []byte, pc utils.PBECipher) error {
+	var privData []byte
+	var err error
+	switch {
+	case keyFileSupported(privKey.Curve) && pass != nil:
+		// Зашифрованный ключ, подробнее в utils/pkcs8_encrypted.go
+		privData, err = utils.MarshalEncryptedPrivateKeyFile(nil, privKey, pass, pc, time.Now())
+	case keyFileSupported(privKey.Curve):
+		privData, err = utils.MarshalPrivateKeyFile(privKey, time.Now())
+	case pass != nil:
+		return fmt.Errorf("Шифрование ключа поддерживается только для стандартных наборов параметров")
+	default:
+		// Переводим D параметр подписи (приватный ключ) в строковое предсталение
+		privData = []byte(privKey.D.String())
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fKey, privData, 0600)
+}
+
+// Импорт ключа из десятичного формата прежних версий в самоописывающий формат
+// Тип ключа определяется по числу строк: одна - приватный, две - публичный
+func importKey(s *utils.Signer, fKey, outFile string, encrypt bool, pc utils.PBECipher) error {
+	if !keyFileSupported(s.Curve()) {
+		return fmt.Errorf("Импорт поддерживается только для стандартных наборов параметров")
+	}
+	bytes, err := os.ReadFile(fKey)
+	if err != nil {
+		return err
+	}
+	if utils.IsKeyFile(bytes) {
+		return fmt.Errorf("Ключ %s уже в самоописывающем формате", fKey)
+	}
+
+	// Публичный ключ - две строки, приватный - одна, перевод строки в конце файла не учитывается
+	if len(strings.Split(strings.TrimSpace(string(bytes)), "\n")) == 2 {
+		pubKey, err := readPubkey(s, fKey)
+		if err != nil {
+			return err
+		}
+		return writePubkey(outFile, pubKey)
+	}
+
+	privKey, err := readPrivkey(s, fKey)
+	if err != nil {
+		return err
+	}
+	var pass []byte
+	if encrypt {
+		if pass, err = readNewPassphrase(); err != nil {
+			return err
+		}
+	}
+	return writePrivkey(outFile, privKey, pass, pc)
+}
+
+// Проверка соответствия файлов приватного и публичного ключей
+func checkKeyPair(s *utils.Signer, privKeyFile, pubKeyFile string) error {
+	privKey, err := readPrivkey(s, privKeyFile)
+	if err != nil {
+		return err
+	}
+	pubKey, err := readPubkey(s, pubKeyFile)
+	if err != nil {
+		return err
+	}
+	// Подробнее в utils/keypair.go
+	return s.CheckKeyPair(pubKey, privKey)
+}
+
+// Восстановление файла публичного ключа по приватному ключу
+func regenPubkey(s *utils.Signer, privKeyFile, pubKeyFile string) error {
+	privKey, err := readPrivkey(s, privKeyFile)
+	if err != nil {
+		return err
+	}
+	// Q = dP, подробнее в utils/crypto_signer.go
+	pubKey := privKey.Public().(*utils.PublicKey)
+	return writePubkey(pubKeyFile, pubKey)
 }
 
 // Генерация ключевой пары
-func genKeyPair(s *utils.Signer) (string, string, error) {
+// Если encrypt, приватный ключ шифруется паролем с шифром pc
+func genKeyPair(s *utils.Signer, encrypt bool, pc utils.PBECipher) (string, string, error) {
+	// Пароль запрашивается до создания файлов, чтобы при ошибке ввода не оставлять ключи
+	var pass []byte
+	if encrypt {
+		if !keyFileSupported(s.Curve()) {
+			return "", "", fmt.Errorf("Шифрование ключа поддерживается только для стандартных наборов параметров")
+		}
+		var err error
+		pass, err = readNewPassphrase()
+		if err != nil {
+			return "", "", err
+		}
+	}
+
 	// Генерируем публичный и приватный ключ, если произошла ошибка - возвращаем ее
 	// Подробнее в utils/signature.go
 	pubKey, privKey, err := s.GenerateKeyPair()
@@ -117,25 +401,13 @@ func genKeyPair(s *utils.Signer) (string, string, error) {
 	// Получаем текущий штамп времени для формирования имени файла для записи ключей
 	ts := time.Now().Format("20060102T150405")
 
-	// Переводим X, Y точки проверки подписи (публичный ключ) в строковое предсталение с разбиение по переносу строки
-	pubData := []byte(fmt.Sprintf("%s\n%s", pubKey.X, pubKey.Y))
-	// формируем имя файла
+	// формируем имена файлов и записываем ключи
 	pubKeyFile := fmt.Sprintf("%s_public.sigkey", ts)
-
-	// Записываем строковое представление в файл
-	err = os.WriteFile(pubKeyFile, pubData, 0600)
-	if err != nil {
+	privKeyFile := fmt.Sprintf("%s_private.sigkey", ts)
+	if err := writePubkey(pubKeyFile, pubKey); err != nil {
 		return "", "", err
 	}
-	// Переводим D параметр подписи (приватный ключ) в строковое предсталение
-	privData := []byte(privKey.D.String())
-
-	// формируем имя файла
-	privKeyFile := fmt.Sprintf("%s_private.sigkey", ts)
-
-	// Записываем строковое представление в файл
-	err = os.WriteFile(privKeyFile, privData, 0600)
-	if err != nil {
+	if err := writePrivkey(privKeyFile, privKey, pass, pc); err != nil {
 		return "", "", err
 	}
 
@@ -144,9 +416,10 @@ func genKeyPair(s *utils.Signer) (string, string, error) {
 }
 
 // Формирование цифровой подписи файла
-func signFile(signer *utils.Signer, filename, signatureFilePath, privKeyFile string) error {
+// Подпись записывается в самоописывающем формате, а при legacy или для собственных кривых - десятичным числом
+func signFile(signer *utils.Signer, filename, signatureFilePath, privKeyFile string, legacy bool) error {
 	// Получаем приватный ключ из файла в параметре --key
-	pKey, err := readPrivkey(privKeyFile)
+	pKey, err := readPrivkey(signer, privKeyFile)
 	if err != nil {
 		return err
 	}
@@ -157,13 +430,27 @@ func signFile(signer *utils.Signer, filename, signatureFilePath, privKeyFile str
 		return err
 	}
 
-	// Формируем цифровую подпись
+	// Формируем цифровую подпись хеша файла
 	// Подробнее в utils/signature.go
-	signature, err := signer.SignBytes(bytes, pKey)
+	digest := signer.Digest(bytes)
+	signature, err := signer.SignDigest(digest, pKey)
 	if err != nil {
 		return err
 	}
 
+	// Самоописывающий формат, подробнее в utils/signature_file.go
+	if !legacy && keyFileSupported(pKey.Curve) {
+		sf, err := utils.NewSignatureFile(pKey.Public().(*utils.PublicKey), digest, signature, filepath.Base(filename), time.Now())
+		if err != nil {
+			return err
+		}
+		data, err := utils.MarshalSignatureFile(sf)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(signatureFilePath, data, 0600)
+	}
+
 	// Переводим подпись в целое число
 	signDigit := new(big.Int).SetBytes(signature)
 
@@ -173,48 +460,240 @@ func signFile(signer *utils.Signer, filename, signatureFilePath, privKeyFile str
 }
 
 // Проверка цифровой подписи файла
-func verifySign(signer *utils.Signer, filename, signatureFilePath, pubKeyFile string) (bool, error) {
+// Подпись в самоописывающем формате дополнительно сверяется с ключом и хешем файла
+func verifySign(signer *utils.Signer, filename, signatureFilePath, pubKeyFile string, strict bool) (bool, error) {
 	// Получаем ключ проверки подписи (публичный ключ) из файла в параметре --key
-	pKey, err := readPubkey(pubKeyFile)
+	pKey, err := readPubkey(signer, pubKeyFile)
 	if err != nil {
 		return false, err
 	}
 
-	// Получаем цифровую подпись из файла в параметре --signature
-	signature, err := readSignature(signatureFilePath)
+	// Читаем байтовое содержимое файла
+	data, err := os.ReadFile(filename)
 	if err != nil {
 		return false, err
 	}
 
-	// Читаем байтовое содержимое файла
-	bytes, err := os.ReadFile(filename)
+	sigData, err := os.ReadFile(signatureFilePath)
+	if err != nil {
+		return false, err
+	}
+
+	// Подпись в самоописывающем формате, подробнее в utils/signature_file.go
+	if utils.IsSignatureFile(sigData) {
+		sf, err := utils.ParseSignatureFile(sigData)
+		if err != nil {
+			return false, err
+		}
+		if !sf.ParamSet.Curve().Equal(signer.Curve()) {
+			return false, fmt.Errorf("Подпись сформирована на наборе параметров %s, а проверка выполняется на %s", sf.ParamSet.Name, signer.Curve().Name)
+		}
+		keyID, err := pKey.KeyID()
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(keyID, sf.KeyID) {
+			return false, fmt.Errorf("Подпись сформирована другим ключом: Key-Id подписи %x, ключа %x", sf.KeyID, keyID)
+		}
+		fmt.Printf("Время подписи: %s\n", sf.Signed.Local().Format(time.RFC3339))
+		if sf.FileName != filepath.Base(filename) {
+			fmt.Printf("Внимание: подписан файл с именем %s\n", sf.FileName)
+		}
+
+		// Изменение файла обнаруживается по хешу до проверки подписи
+		if !bytes.Equal(signer.Digest(data), sf.Digest) {
+			fmt.Println("Хеш файла не совпадает с подписанным, файл изменен.")
+			return false, nil
+		}
+		return signer.VerifyDigest(sf.Digest, sf.Signature, pKey)
+	}
+
+	// Получаем цифровую подпись из файла в параметре --signature
+	signature, err := readSignature(signer, signatureFilePath, strict)
 	if err != nil {
 		return false, err
 	}
 
 	// Проверяем цифровую подпись и возвращаем результат проверки пройдена (true) / не пройдена (false)
 	// Подробнее в utils/signature.go
-	ok, err := signer.VerifySign(bytes, signature, pKey)
+	ok, err := signer.VerifySign(data, signature, pKey)
 
 	return ok, err
 }
 
-// Определение параметров эллиптической кривой
-// Подробнее в utils/param_set.go
+// Проверка цепочки сертификата до доверенных сертификатов из trustPath (файл PEM или каталог)
+// Промежуточные сертификаты берутся из файлов PEM chainFiles, ku - требуемое использование ключа
+// Если задано crls, проверяется статус отзыва сертификатов цепочки
+// Подробнее в utils/verify_chain.go
+func verifyCertificatePath(cert *utils.Certificate, trustPath string, chainFiles []string, ku x509.KeyUsage, crls *utils.CRLStore) error {
+	roots := utils.NewTrustStore()
+	if err := roots.AddFile(trustPath); err != nil {
+		return err
+	}
+	opts := utils.VerifyOptions{Roots: roots, KeyUsage: ku, CRLs: crls}
+	for _, file := range chainFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		certs, err := utils.ParseCertificatesPEM(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		opts.Intermediates = append(opts.Intermediates, certs...)
+	}
+	chain, err := cert.Verify(opts)
+	if err != nil {
+		return err
+	}
+	for i, c := range chain {
+		fmt.Printf("Цепочка [%d]: %s\n", i, c.Subject)
+	}
+	return nil
+}
+
+// Хранилище списков отзыва из файла или каталога crlPath с загрузкой по URL из сертификатов, если fetch
+// Если списки не заданы и загрузка не включена, возвращает nil
+// Подробнее в utils/crl_store.go
+func loadCRLStore(crlPath string, fetch bool) (*utils.CRLStore, error) {
+	if crlPath == "" && !fetch {
+		return nil, nil
+	}
+	crls := utils.NewCRLStore()
+	if crlPath != "" {
+		if err := crls.AddFile(crlPath); err != nil {
+			return nil, err
+		}
+	}
+	if fetch {
+		crls.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return crls, nil
+}
+
+// Проверка подписи списка отзыва ключом сертификата издателя и вывод его содержимого
+// Подробнее в utils/crl.go
+func verifyCRL(crlFile, issuerFile string) error {
+	data, err := os.ReadFile(crlFile)
+	if err != nil {
+		return err
+	}
+	crl, err := utils.ParseRevocationListPEM(data)
+	if err != nil {
+		return err
+	}
+	issuer, err := readCertificate(issuerFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Издатель: %s\n", crl.Issuer)
+	if crl.IsDelta() {
+		fmt.Printf("Разностный список отзыва номер %s к полному списку номер %s\n", crl.Number, crl.BaseNumber)
+	} else if crl.Number != nil {
+		fmt.Printf("Полный список отзыва номер %s\n", crl.Number)
+	}
+	fmt.Printf("Выпущен: %s\n", crl.ThisUpdate.Format(time.RFC3339))
+	if !crl.NextUpdate.IsZero() {
+		fmt.Printf("Следующий выпуск: %s\n", crl.NextUpdate.Format(time.RFC3339))
+	}
+	for _, rc := range crl.RevokedCertificates {
+		fmt.Printf("Отозван: %x, %s, причина %s\n", rc.SerialNumber, rc.RevocationTime.Format(time.RFC3339), utils.ReasonName(rc.ReasonCode))
+	}
+	return crl.CheckSignatureFrom(issuer)
+}
+
+// Запрос статуса сертификата у ответчика OCSP и вывод ответа
+// Возвращает статус сертификата utils.OCSPGood, utils.OCSPRevoked или utils.OCSPUnknown
+// Подробнее в utils/ocsp_client.go
+func queryOCSP(certFile, issuerFile, responderURL string) (int, error) {
+	cert, err := readCertificate(certFile)
+	if err != nil {
+		return 0, err
+	}
+	issuer, err := readCertificate(issuerFile)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := utils.QueryOCSP(&http.Client{Timeout: 30 * time.Second}, responderURL, cert, issuer)
+	if err != nil {
+		return 0, err
+	}
+	fmt.Printf("Субъект: %s\n", cert.Subject)
+	fmt.Printf("Серийный номер: %x\n", cert.SerialNumber)
+	fmt.Printf("Ответ подписан: %s\n", resp.Certificate.Subject)
+	fmt.Printf("Ответ сформирован: %s\n", resp.ProducedAt.Format(time.RFC3339))
+	switch resp.Status {
+	case utils.OCSPGood:
+		fmt.Println("Статус: действителен")
+	case utils.OCSPRevoked:
+		fmt.Printf("Статус: отозван %s, причина %s\n", resp.RevokedAt.Format(time.RFC3339), utils.ReasonName(resp.RevocationReason))
+	default:
+		fmt.Println("Статус: неизвестен ответчику")
+	}
+	return resp.Status, nil
+}
+
+// Загрузка сертификата из файла PEM
+func readCertificate(certFile string) (*utils.Certificate, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ParseCertificatePEM(data)
+}
+
+// Проверка подписи сертификата ключом сертификата издателя
+// Если задано хранилище trustPath, проверяется цепочка до доверенного сертификата,
+// а сертификат издателя и chainFile используются как промежуточные
+// Подробнее в utils/x509.go
+func verifyCertificate(certFile, issuerFile, trustPath, chainFile string, crls *utils.CRLStore) error {
+	cert, err := readCertificate(certFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Субъект: %s\n", cert.Subject)
+	fmt.Printf("Издатель: %s\n", cert.Issuer)
+	fmt.Printf("Серийный номер: %x\n", cert.SerialNumber)
+	fmt.Printf("Действителен: с %s по %s\n", cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	fmt.Printf("Набор параметров ключа: %s (%s)\n", cert.ParamSet.Name, cert.ParamSet.OID)
+
+	if trustPath != "" {
+		var chainFiles []string
+		for _, file := range []string{issuerFile, chainFile} {
+			if file != "" {
+				chainFiles = append(chainFiles, file)
+			}
+		}
+		return verifyCertificatePath(cert, trustPath, chainFiles, 0, crls)
+	}
+
+	// Без сертификата издателя сертификат считается самоподписанным
+	issuer := cert
+	if issuerFile != "" {
+		if issuer, err = readCertificate(issuerFile); err != nil {
+			return err
+		}
+	}
+	return cert.CheckSignatureFrom(issuer)
+}
+
+// Определение параметров эллиптической кривой по имени или OID набора
+// Подробнее в utils/registry.go
 func getCurvesByParams(param string) (*utils.Curve, int, error) {
-	if param == "id-GostR3410-2001-CryptoPro-A-ParamSet" {
-		return utils.NewCurve256CryptoProParamSetA(), mode256, nil
-	} else if param == "id-GostR3410-2001-CryptoPro-B-ParamSet" {
-		return utils.NewCurve256CryptoProParamSetB(), mode256, nil
-	} else if param == "id-GostR3410-2001-CryptoPro-C-ParamSet" {
-		return utils.NewCurve256CryptoProParamSetC(), mode256, nil
-	} else if param == "id-tc26-gost-3410-12-512-paramSetA" {
-		return utils.NewCurve512ParamSetA(), mode512, nil
-	} else if param == "id-tc26-gost-3410-12-512-paramSetB" {
-		return utils.NewCurve512ParamSetB(), mode512, nil
-	} else {
-		return nil, 0, fmt.Errorf("неизвестный параметр эллиптической кривой")
+	ps, err := utils.LookupParamSet(param)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ps.Curve(), ps.HashMode, nil
+}
+
+// Формирование перечня наборов параметров для справки
+func paramSetsHelp() string {
+	items := []string{}
+	for _, ps := range utils.ParamSets() {
+		items = append(items, fmt.Sprintf("%s (%s)", ps.Name, ps.OID))
 	}
+	return strings.Join(items, ", ")
 }
 
 // Точка входа в программу
@@ -223,25 +702,230 @@ func main() {
 	fPath := flag.String("f", "", "Путь к файлу для подписания или проверки подписи")
 	fSignature := flag.String("signature", "", "Пусть к файлу с подписью. Для режима проверки будет считан, для режима подписания будет создан")
 	fKey := flag.String("key", "", "Файл с ключом подписи (приватный ключ) для режима подписи или с ключом проверки подписи (публичный ключ) для режима проверки подписи")
-	genMode := flag.Bool("gen", false, "Запуск в режиме генерации ключей пользователя.  Ключи сохраняются в текущий дериктории <timestamp>_public.sigkey и <timestamp>_private.sigkey")
+	genMode := flag.Bool("gen", false, "Запуск в режиме генерации ключей пользователя.  Ключи сохраняются в текущий дериктории <timestamp>_public.sigkey и <timestamp>_private.sigkey в самоописывающем формате с набором параметров")
 	sMode := flag.Bool("sign-file", false, "Запуск в режиме подписи файла")
 	vMode := flag.Bool("verify-sign", false, "Запуск в режиме проверки подписи файла")
-	param := flag.String("params", "id-tc26-gost-3410-12-512-paramSetA", "Выбор параметров элептической кривой. По умолчанию: id-tc26-gost-3410-12-512-paramSetB. Может быть один из [id-GostR3410-2001-CryptoPro-A-ParamSet, id-GostR3410-2001-CryptoPro-B-ParamSet, id-GostR3410-2001-CryptoPro-C-ParamSet, id-tc26-gost-3410-12-512-paramSetA, id-tc26-gost-3410-12-512-paramSetB]")
+	param := flag.String("params", "id-tc26-gost-3410-2012-512-paramSetA", "Выбор параметров элептической кривой по имени или OID. Может быть один из ["+paramSetsHelp()+"]")
+	strict := flag.Bool("strict", false, "Строгий режим проверки подписи: отвергаются неканонические записи подписи, r и s вне интервала (0, q) и промежуточные точки на бесконечности с указанием причины")
+	nonce := flag.String("nonce", "random", "Способ выработки k при подписи: random (случайное), deterministic (по RFC 6979 из ключа и хеша), hedged (детерминированное с подмешиванием случайности)")
+	verifyAfterSign := flag.Bool("verify-after-sign", true, "Проверка каждой подписи перед записью для защиты от сбоев при вычислениях. При ошибке проверки файл подписи не создается")
+	fPubKey := flag.String("pubkey", "", "Файл с публичным ключом для режимов --check-keys и --regen-pubkey")
+	checkKeys := flag.Bool("check-keys", false, "Запуск в режиме проверки соответствия приватного ключа (--key) и публичного ключа (--pubkey)")
+	regenKey := flag.Bool("regen-pubkey", false, "Запуск в режиме восстановления публичного ключа по приватному ключу (--key). Публичный ключ записывается в файл --pubkey")
+	encryptKey := flag.Bool("encrypt-key", false, "В режимах генерации и импорта ключей сохранить приватный ключ зашифрованным паролем (PKCS#8, PBES2 с алгоритмами ГОСТ). Пароль запрашивается при генерации и при каждом использовании ключа")
+	keyCipherName := flag.String("key-cipher", "kuznyechik", "Шифр для защиты приватного ключа паролем: kuznyechik или magma (режим CTR-ACPKM-OMAC)")
+	importMode := flag.Bool("import-key", false, "Запуск в режиме импорта ключа (--key) из десятичного формата прежних версий в самоописывающий формат. Набор параметров задается --params, результат записывается в файл --out")
+	fOut := flag.String("out", "", "Файл для записи результата в режимах --import-key, --gen-csr, --ca-issue и --ca-gen-crl")
+	legacySignature := flag.Bool("legacy-signature", false, "Записывать подпись десятичным числом в формате прежних версий вместо самоописывающего формата")
+	caInitMode := flag.Bool("ca-init", false, "Запуск в режиме создания каталога УЦ (--ca-dir): самоподписанный корневой сертификат с субъектом --subject на новом ключе или ключе --key, либо каталог для выпущенного ранее сертификата УЦ --cert и его ключа --key")
+	caIssueMode := flag.Bool("ca-issue", false, "Запуск в режиме выпуска сертификата УЦ из каталога --ca-dir по запросу на сертификат --csr. Сертификат записывается в файл --out и в каталог УЦ")
+	caDir := flag.String("ca-dir", "", "Каталог УЦ для режимов --ca-init, --ca-issue, --ca-revoke, --ca-gen-crl и --ca-ocsp")
+	subject := flag.String("subject", "", "Субъект сертификата, например \"CN=Тестовый УЦ,O=Организация,C=RU\"")
+	days := flag.Int("days", 0, "Срок действия сертификата в днях. По умолчанию: 3650 для корневого, 1825 для промежуточного, 365 для конечного сертификата")
+	fCSR := flag.String("csr", "", "Файл с запросом на сертификат PKCS#10 в PEM")
+	profile := flag.String("profile", "end-entity", "Профиль выпускаемого сертификата: intermediate (промежуточный УЦ), end-entity (конечный сертификат) или ocsp-responder (ответчик OCSP)")
+	genCSRMode := flag.Bool("gen-csr", false, "Запуск в режиме формирования запроса на сертификат PKCS#10 для приватного ключа --key с субъектом --subject. Запрос записывается в файл --out")
+	verifyCSRMode := flag.Bool("verify-csr", false, "Запуск в режиме проверки подписи запроса на сертификат --csr")
+	emails := flag.String("email", "", "Адреса электронной почты через запятую для расширения subjectAltName запроса на сертификат")
+	signTool := flag.String("sign-tool", "", "Наименование средства электронной подписи владельца для расширения subjectSignTool запроса на сертификат")
+	verifyCert := flag.Bool("verify-cert", false, "Запуск в режиме проверки подписи сертификата X.509 (--cert) ключом сертификата издателя (--issuer)")
+	fCert := flag.String("cert", "", "Файл с сертификатом X.509 в PEM")
+	fIssuer := flag.String("issuer", "", "Файл с сертификатом издателя X.509 в PEM. Если не указан, сертификат проверяется как самоподписанный")
+	fTrust := flag.String("trust", "", "Файл PEM или каталог с доверенными сертификатами. В режиме --verify-cert проверяется цепочка до доверенного сертификата, в режиме --verify-sign ключ --key должен быть сертификатом с цепочкой до доверенного")
+	fChain := flag.String("chain", "", "Файл PEM с промежуточными сертификатами для построения цепочки до доверенного сертификата (--trust)")
+	fCRL := flag.String("crl", "", "Файл или каталог со списками отзыва (PEM или DER). В режимах --verify-cert и --verify-sign с --trust сертификаты цепочки проверяются по спискам отзыва, в режиме --verify-crl - проверяемый список")
+	crlFetch := flag.Bool("crl-fetch", false, "Загружать списки отзыва по URL из расширений cRLDistributionPoints и freshestCRL сертификатов при проверке цепочки (--trust)")
+	crlURLs := flag.String("crl-url", "", "URL списков отзыва через запятую для расширения cRLDistributionPoints выпускаемого сертификата в режиме --ca-issue")
+	ocspURLs := flag.String("ocsp-url", "", "URL ответчика OCSP: в режиме --ca-issue через запятую для расширения authorityInfoAccess выпускаемого сертификата, в режиме --ocsp - адрес запроса (по умолчанию из сертификата)")
+	ocspMode := flag.Bool("ocsp", false, "Запуск в режиме запроса статуса сертификата --cert, выпущенного --issuer, у ответчика OCSP")
+	caOCSPMode := flag.Bool("ca-ocsp", false, "Запуск ответчика OCSP по базе данных УЦ из каталога --ca-dir на адресе --listen. Ответы подписываются ключом УЦ или сертификатом ответчика --cert (профиль ocsp-responder) с ключом --key")
+	listen := flag.String("listen", "127.0.0.1:8080", "Адрес HTTP сервера ответчика OCSP в режиме --ca-ocsp")
+	caRevokeMode := flag.Bool("ca-revoke", false, "Запуск в режиме отзыва сертификата (--cert или --serial) УЦ из каталога --ca-dir с причиной --reason")
+	caGenCRLMode := flag.Bool("ca-gen-crl", false, "Запуск в режиме выпуска списка отзыва УЦ из каталога --ca-dir. Список записывается в файл --out, срок до следующего выпуска задается --days (по умолчанию 7 для полного и 1 для разностного списка)")
+	deltaCRL := flag.Bool("delta", false, "В режиме --ca-gen-crl выпустить разностный список отзыва к последнему полному списку")
+	serial := flag.String("serial", "", "Серийный номер отзываемого сертификата в шестнадцатеричном виде для режима --ca-revoke")
+	reason := flag.String("reason", "unspecified", "Причина отзыва сертификата: unspecified, keyCompromise, cACompromise, affiliationChanged, superseded, cessationOfOperation, certificateHold, privilegeWithdrawn, aACompromise")
+	verifyCRLMode := flag.Bool("verify-crl", false, "Запуск в режиме проверки подписи списка отзыва --crl ключом сертификата издателя --issuer")
+	curveFile := flag.String("curve-file", "", "Путь к файлу с собственными параметрами эллиптической кривой в формате JSON или ASN.1 ECParameters (DER/PEM). Параметры проверяются на соответствие ГОСТ Р 34.10-2012, флаг --params при этом не учитывается")
 
 	// Парсим флаги
 	flag.Parse()
 
+	// Режим проверки сертификата, набор параметров берется из сертификата
+	if *verifyCert {
+		fmt.Println("Выбран режим проверки подписи сертификата.")
+		if *fCert == "" {
+			fmt.Println("Не указан путь к файлу сертификата. Укажите параметр --cert <имя файла>")
+			os.Exit(1)
+		}
+		crls, err := loadCRLStore(*fCRL, *crlFetch)
+		if err == nil {
+			err = verifyCertificate(*fCert, *fIssuer, *fTrust, *fChain, crls)
+		}
+		if err != nil {
+			if *fTrust != "" {
+				fmt.Printf("Сертификат недействителен: %s\n", err.Error())
+			} else {
+				fmt.Printf("Подпись сертификата не верна: %s\n", err.Error())
+			}
+			os.Exit(1)
+		}
+		if *fTrust != "" {
+			fmt.Println("Цепочка сертификата до доверенного сертификата проверена.")
+		} else {
+			fmt.Println("Подпись сертификата верна.")
+		}
+		os.Exit(0)
+	}
+
+	// Режим проверки списка отзыва, набор параметров берется из сертификата издателя
+	if *verifyCRLMode {
+		fmt.Println("Выбран режим проверки списка отзыва.")
+		if *fCRL == "" || *fIssuer == "" {
+			fmt.Println("Не указаны список отзыва или сертификат издателя. Укажите параметры --crl <имя файла> --issuer <имя файла>")
+			os.Exit(1)
+		}
+		if err := verifyCRL(*fCRL, *fIssuer); err != nil {
+			fmt.Printf("Подпись списка отзыва не верна: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("Подпись списка отзыва верна.")
+		os.Exit(0)
+	}
+
+	// Режим запроса статуса сертификата OCSP, набор параметров берется из сертификатов
+	if *ocspMode {
+		fmt.Println("Выбран режим запроса статуса сертификата OCSP.")
+		if *fCert == "" || *fIssuer == "" {
+			fmt.Println("Не указаны сертификат или сертификат издателя. Укажите параметры --cert <имя файла> --issuer <имя файла>")
+			os.Exit(1)
+		}
+		status, err := queryOCSP(*fCert, *fIssuer, *ocspURLs)
+		if err != nil {
+			fmt.Printf("Во время запроса статуса произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		if status != utils.OCSPGood {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Режим отзыва сертификата, ключ УЦ не требуется
+	if *caRevokeMode {
+		fmt.Println("Выбран режим отзыва сертификата.")
+		if *caDir == "" {
+			fmt.Println("Не указан каталог УЦ. Укажите параметр --ca-dir <каталог>")
+			os.Exit(1)
+		}
+		entry, err := caRevoke(*caDir, *fCert, *serial, *reason)
+		if err != nil {
+			fmt.Printf("Во время отзыва сертификата произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Сертификат %s (%s) отозван, причина: %s. Выпустите список отзыва: --ca-gen-crl\n", entry.serial, entry.subject, utils.ReasonName(entry.reason))
+		os.Exit(0)
+	}
+
+	// Режим проверки запроса на сертификат, набор параметров берется из запроса
+	if *verifyCSRMode {
+		fmt.Println("Выбран режим проверки запроса на сертификат.")
+		if *fCSR == "" {
+			fmt.Println("Не указан путь к файлу запроса. Укажите параметр --csr <имя файла>")
+			os.Exit(1)
+		}
+		if err := verifyCSR(*fCSR); err != nil {
+			fmt.Printf("Подпись запроса на сертификат не верна: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("Подпись запроса на сертификат верна.")
+		os.Exit(0)
+	}
+
 	// Получаем эллиптическую кривую с заданным наборов параметров
 	// Если в --params задано не известное значение - возвращаем ошибку
-	c, mode, err := getCurvesByParams(*param)
+	// Если задан --curve-file, параметры загружаются и проверяются из файла
+	var c *utils.Curve
+	var mode int
+	var err error
+	if *curveFile != "" {
+		c, err = utils.LoadCurve(*curveFile)
+		if err == nil {
+			mode = c.Size()
+		}
+	} else {
+		c, mode, err = getCurvesByParams(*param)
+	}
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
+	// Если ключ или подпись (в режиме проверки) в самоописывающем формате, набор параметров берется из файла
+	// Явно указанный --params и наборы из файлов ключа и подписи должны совпадать
+	if *curveFile == "" && !*genMode && !*importMode {
+		var keyPS, sigPS *utils.ParamSet
+		// В режиме выпуска сертификата ключ - ключ УЦ, набор параметров берется из сертификата УЦ
+		keyFile := *fKey
+		if (*caIssueMode || *caGenCRLMode || (*caOCSPMode && *fCert == "")) && *caDir != "" {
+			keyFile = filepath.Join(*caDir, caCertFile)
+		}
+		if keyFile != "" {
+			keyPS, err = keyFileParamSet(keyFile)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		if *vMode && *fSignature != "" {
+			sigPS, err = signatureFileParamSet(*fSignature)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		if keyPS != nil && sigPS != nil && !keyPS.Curve().Equal(sigPS.Curve()) {
+			fmt.Printf("Подпись %s сформирована на наборе параметров %s, а ключ %s относится к %s\n", *fSignature, sigPS.Name, *fKey, keyPS.Name)
+			os.Exit(1)
+		}
+		ps, source := keyPS, "ключа"
+		if ps == nil {
+			ps, source = sigPS, "подписи"
+		}
+		if ps != nil {
+			paramsSet := false
+			flag.Visit(func(f *flag.Flag) {
+				if f.Name == "params" {
+					paramsSet = true
+				}
+			})
+			if paramsSet && !ps.Curve().Equal(c) {
+				fmt.Printf("Файл %s относится к набору параметров %s, а в --params указан %s\n", source, ps.Name, *param)
+				os.Exit(1)
+			}
+			if !paramsSet {
+				c, mode = ps.Curve(), ps.HashMode
+			}
+			fmt.Printf("Набор параметров определен по файлу %s: %s\n", source, c.Name)
+		}
+	}
+
 	// Инициируем тип Signer для проведения дальнейших операций
 	// генерация ключей / проверка подписи / формирование подписи
 	s := utils.NewSigner(c, mode)
+	s.SetStrictVerification(*strict)
+	nonceMode, err := utils.ParseNonceMode(*nonce)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	s.SetNonceMode(nonceMode)
+	s.SetVerifyAfterSign(*verifyAfterSign)
+	keyCipher, err := utils.ParsePBECipher(*keyCipherName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	// проверяем что одновременно не заданы режим проверки и формирования подписи
 	if *sMode && *vMode {
@@ -273,7 +957,7 @@ func main() {
 		fmt.Printf("Путь к файлу приватного ключа: %s\n", *fKey)
 
 		// Подписываем файл и проверяем что нет ошибок
-		err := signFile(s, *fPath, *fSignature, *fKey)
+		err := signFile(s, *fPath, *fSignature, *fKey, *legacySignature)
 		if err != nil {
 			fmt.Printf("Во время подписи произошла ошибка: %s\n", err.Error())
 			os.Exit(1)
@@ -306,8 +990,30 @@ func main() {
 		fmt.Printf("Путь к файлу подписи: %s\n", *fSignature)
 		fmt.Printf("Путь к файлу публичного ключа: %s\n", *fKey)
 
+		// Проверка цепочки сертификата ключа проверки подписи
+		if *fTrust != "" {
+			cert, err := readCertificate(*fKey)
+			if err != nil {
+				fmt.Printf("Для проверки цепочки ключ проверки подписи должен быть сертификатом: %s\n", err.Error())
+				os.Exit(1)
+			}
+			var chainFiles []string
+			if *fChain != "" {
+				chainFiles = append(chainFiles, *fChain)
+			}
+			crls, err := loadCRLStore(*fCRL, *crlFetch)
+			if err == nil {
+				err = verifyCertificatePath(cert, *fTrust, chainFiles, x509.KeyUsageDigitalSignature|x509.KeyUsageContentCommitment, crls)
+			}
+			if err != nil {
+				fmt.Printf("Сертификат ключа проверки подписи недействителен: %s\n", err.Error())
+				os.Exit(1)
+			}
+			fmt.Println("Цепочка сертификата ключа проверки подписи проверена.")
+		}
+
 		// проверяем подпись
-		ok, err := verifySign(s, *fPath, *fSignature, *fKey)
+		ok, err := verifySign(s, *fPath, *fSignature, *fKey, *strict)
 		if err != nil {
 			fmt.Printf("Во время проверки подписи произошла ошибка: %s\n", err.Error())
 			os.Exit(1)
@@ -323,17 +1029,173 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Режим проверки ключевой пары
+	if *checkKeys {
+		fmt.Println("Выбран режим проверки ключевой пары.")
+		if *fKey == "" || *fPubKey == "" {
+			fmt.Println("Не указаны файлы ключей. Укажите параметры --key <приватный ключ> и --pubkey <публичный ключ>")
+			os.Exit(1)
+		}
+		fmt.Printf("Путь к файлу приватного ключа: %s\n", *fKey)
+		fmt.Printf("Путь к файлу публичного ключа: %s\n", *fPubKey)
+
+		if err := checkKeyPair(s, *fKey, *fPubKey); err != nil {
+			fmt.Printf("Ключи не соответствуют друг другу: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("Ключи соответствуют друг другу.")
+		os.Exit(0)
+	}
+
+	// Режим восстановления публичного ключа
+	if *regenKey {
+		fmt.Println("Выбран режим восстановления публичного ключа.")
+		if *fKey == "" || *fPubKey == "" {
+			fmt.Println("Не указаны файлы ключей. Укажите параметры --key <приватный ключ> и --pubkey <файл для записи публичного ключа>")
+			os.Exit(1)
+		}
+		fmt.Printf("Путь к файлу приватного ключа: %s\n", *fKey)
+
+		if err := regenPubkey(s, *fKey, *fPubKey); err != nil {
+			fmt.Printf("Во время восстановления публичного ключа произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Публичный ключ записан в файл: %s\n", *fPubKey)
+		os.Exit(0)
+	}
+
+	// Режим импорта ключа прежнего формата
+	if *importMode {
+		fmt.Println("Выбран режим импорта ключа.")
+		if *fKey == "" || *fOut == "" {
+			fmt.Println("Не указаны файлы ключа. Укажите параметры --key <ключ прежнего формата> и --out <файл для записи>")
+			os.Exit(1)
+		}
+		fmt.Printf("Набор параметров эллиптической кривой: %s (%s)\n", c.Name, c.OID)
+
+		if err := importKey(s, *fKey, *fOut, *encryptKey, keyCipher); err != nil {
+			fmt.Printf("Во время импорта ключа произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Ключ записан в файл: %s\n", *fOut)
+		os.Exit(0)
+	}
+
+	// Режим формирования запроса на сертификат
+	if *genCSRMode {
+		fmt.Println("Выбран режим формирования запроса на сертификат.")
+		if *fKey == "" || *fOut == "" {
+			fmt.Println("Не указаны приватный ключ или файл для записи. Укажите параметры --key <имя файла> --out <имя файла>")
+			os.Exit(1)
+		}
+		csr, err := genCSR(s, *fKey, *subject, *emails, *signTool)
+		if err == nil {
+			err = os.WriteFile(*fOut, csr.MarshalPEM(), 0644)
+		}
+		if err != nil {
+			fmt.Printf("Во время формирования запроса произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Запрос на сертификат записан в файл: %s\n", *fOut)
+		os.Exit(0)
+	}
+
+	// Режим создания каталога УЦ
+	if *caInitMode {
+		fmt.Println("Выбран режим создания УЦ.")
+		if *caDir == "" {
+			fmt.Println("Не указан каталог УЦ. Укажите параметр --ca-dir <каталог>")
+			os.Exit(1)
+		}
+		cert, err := caInit(s, *caDir, *subject, *fKey, *fCert, *days, *encryptKey, keyCipher)
+		if err != nil {
+			fmt.Printf("Во время создания УЦ произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("УЦ создан в каталоге %s. Субъект: %s, действителен до %s\n", *caDir, cert.Subject, cert.NotAfter.Format(time.RFC3339))
+		os.Exit(0)
+	}
+
+	// Режим выпуска сертификата
+	if *caIssueMode {
+		fmt.Println("Выбран режим выпуска сертификата.")
+		if *caDir == "" || *fCSR == "" || *fOut == "" {
+			fmt.Println("Не указаны каталог УЦ, запрос на сертификат или файл для записи. Укажите параметры --ca-dir <каталог> --csr <имя файла> --out <имя файла>")
+			os.Exit(1)
+		}
+		cert, err := caIssue(s, *caDir, *fCSR, *profile, *days, *crlURLs, *ocspURLs)
+		if err == nil {
+			err = os.WriteFile(*fOut, cert.MarshalPEM(), 0644)
+		}
+		if err != nil {
+			fmt.Printf("Во время выпуска сертификата произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Сертификат выпущен. Субъект: %s, серийный номер: %x, действителен до %s\n", cert.Subject, cert.SerialNumber, cert.NotAfter.Format(time.RFC3339))
+		fmt.Printf("Сертификат записан в файл: %s\n", *fOut)
+		os.Exit(0)
+	}
+
+	// Режим ответчика OCSP
+	if *caOCSPMode {
+		fmt.Println("Выбран режим ответчика OCSP.")
+		if *caDir == "" {
+			fmt.Println("Не указан каталог УЦ. Укажите параметр --ca-dir <каталог>")
+			os.Exit(1)
+		}
+		responder, err := caOCSPResponder(s, *caDir, *fCert, *fKey, time.Hour)
+		if err != nil {
+			fmt.Printf("Во время запуска ответчика OCSP произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Ответчик OCSP УЦ %s запущен на http://%s/\n", responder.Issuer.Subject, *listen)
+		// Подробнее в utils/ocsp_client.go
+		if err := http.ListenAndServe(*listen, responder); err != nil {
+			fmt.Printf("Ошибка ответчика OCSP: %s\n", err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Режим выпуска списка отзыва
+	if *caGenCRLMode {
+		fmt.Println("Выбран режим выпуска списка отзыва.")
+		if *caDir == "" || *fOut == "" {
+			fmt.Println("Не указаны каталог УЦ или файл для записи. Укажите параметры --ca-dir <каталог> --out <имя файла>")
+			os.Exit(1)
+		}
+		crl, err := caGenCRL(s, *caDir, *days, *deltaCRL)
+		if err == nil {
+			err = os.WriteFile(*fOut, crl.MarshalPEM(), 0644)
+		}
+		if err != nil {
+			fmt.Printf("Во время выпуска списка отзыва произошла ошибка: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Список отзыва номер %s выпущен, отозванных сертификатов: %d, следующий выпуск: %s\n", crl.Number, len(crl.RevokedCertificates), crl.NextUpdate.Format(time.RFC3339))
+		fmt.Printf("Список отзыва записан в файл: %s\n", *fOut)
+		os.Exit(0)
+	}
+
 	// Режим генерации ключей пользователя
 	if *genMode {
 		fmt.Println("Выбран режим генерации ключевой пары")
-		fmt.Printf("Набор параметров эллиптической кривой: %s\n", *param)
+		if *curveFile != "" {
+			fmt.Printf("Параметры эллиптической кривой загружены из файла: %s\n", *curveFile)
+		} else {
+			fmt.Printf("Набор параметров эллиптической кривой: %s (%s)\n", c.Name, c.OID)
+		}
 		fmt.Printf(
 			"p: %s\na: %s\nb: %s\nq: %s\nGx: %s\nGy: %s\n",
 			c.P, c.A, c.B, c.Q, c.X, c.Y,
 		)
+		// Для скрученных кривых Эдвардса выводим также их параметры
+		if c.IsEdwards() {
+			fmt.Printf("m/q: %s\ne: %s\nd: %s\n", c.Cofactor, c.E, c.D)
+		}
 
 		// Формируем и записываем ключи пользователя
-		pubFile, privFile, err := genKeyPair(s)
+		pubFile, privFile, err := genKeyPair(s, *encryptKey, keyCipher)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
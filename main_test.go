@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"gost34102012/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Импорт ключей из десятичного формата прежних версий с переводом строки в конце файла и без него
+func TestImportLegacyKey(t *testing.T) {
+	ps, err := utils.ParamSetByName("id-tc26-gost-3410-2012-256-paramSetB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := utils.NewSigner(ps.Curve(), ps.HashMode)
+	pubKey, privKey, err := s.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	for _, ending := range []string{"", "\n", "\r\n"} {
+		legacy := map[string]string{
+			"private": privKey.D.String() + ending,
+			"public":  fmt.Sprintf("%s\n%s%s", pubKey.X, pubKey.Y, ending),
+		}
+		for kind, content := range legacy {
+			in := filepath.Join(dir, kind+".sigkey")
+			out := filepath.Join(dir, kind+".out")
+			if err := os.WriteFile(in, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := importKey(s, in, out, false, utils.PBEKuznyechik); err != nil {
+				t.Fatalf("%s ключ, окончание %q: %v", kind, ending, err)
+			}
+
+			// Набор параметров определяется по заголовку файла
+			got, err := keyFileParamSet(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != ps {
+				t.Fatalf("%s ключ, окончание %q: набор параметров %v, ожидался %s", kind, ending, got, ps.Name)
+			}
+
+			if kind == "private" {
+				imported, err := readPrivkey(s, out)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !imported.Equal(privKey) {
+					t.Fatalf("окончание %q: импортированный приватный ключ не совпадает с исходным", ending)
+				}
+				continue
+			}
+			imported, err := readPubkey(s, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !imported.Equal(pubKey) {
+				t.Fatalf("окончание %q: импортированный публичный ключ не совпадает с исходным", ending)
+			}
+		}
+	}
+
+	// Файл в десятичном формате не определяет набор параметров
+	legacy := filepath.Join(dir, "legacy.sigkey")
+	if err := os.WriteFile(legacy, []byte(privKey.D.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := keyFileParamSet(legacy); got != nil || err != nil {
+		t.Fatalf("набор параметров %v, %v для файла прежнего формата", got, err)
+	}
+
+	// Повторный импорт и ключ вне (0, q) отвергаются
+	if err := importKey(s, filepath.Join(dir, "private.out"), filepath.Join(dir, "again"), false, utils.PBEKuznyechik); err == nil {
+		t.Fatal("повторно импортирован ключ в самоописывающем формате")
+	}
+	if err := os.WriteFile(legacy, []byte(ps.Curve().Q.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := importKey(s, legacy, filepath.Join(dir, "q"), false, utils.PBEKuznyechik); err == nil {
+		t.Fatal("импортирован приватный ключ d = q")
+	}
+}
+
+// Ключ другого набора параметров не читается с --params текущего набора
+func TestReadKeyFileCurveMismatch(t *testing.T) {
+	ps, err := utils.ParamSetByName("id-tc26-gost-3410-2012-512-paramSetC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := utils.NewSigner(ps.Curve(), ps.HashMode)
+	pubKey, _, err := s.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "public.sigkey")
+	if err := writePubkey(path, pubKey); err != nil {
+		t.Fatal(err)
+	}
+
+	other := utils.NewSigner(utils.NewCurve512ParamSetA(), 512)
+	if _, err := readPubkey(other, path); err == nil {
+		t.Fatal("принят ключ другого набора параметров")
+	}
+	if got, err := keyFileParamSet(path); err != nil || got != ps {
+		t.Fatalf("набор параметров %v, %v, ожидался %s", got, err, ps.Name)
+	}
+}
+
+// Подпись файла в самоописывающем формате и десятичном формате прежних версий
+func TestSignVerifyFile(t *testing.T) {
+	ps, err := utils.ParamSetByName("id-GostR3410-2001-CryptoPro-B-ParamSet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := utils.NewSigner(ps.Curve(), ps.HashMode)
+	pubKey, privKey, err := s.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	fPub := filepath.Join(dir, "public.sigkey")
+	fPriv := filepath.Join(dir, "private.sigkey")
+	fData := filepath.Join(dir, "data.txt")
+	if err := writePubkey(fPub, pubKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePrivkey(fPriv, privKey, nil, utils.PBEKuznyechik); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fData, []byte("подписываемые данные"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, legacy := range []bool{false, true} {
+		fSig := filepath.Join(dir, fmt.Sprintf("data-%t.sig", legacy))
+		if err := signFile(s, fData, fSig, fPriv, legacy); err != nil {
+			t.Fatal(err)
+		}
+
+		// Набор параметров определяется только по самоописывающему формату
+		got, err := signatureFileParamSet(fSig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if legacy && got != nil || !legacy && got != ps {
+			t.Fatalf("legacy %t: набор параметров по файлу подписи %v", legacy, got)
+		}
+
+		if ok, err := verifySign(s, fData, fSig, fPub, true); !ok || err != nil {
+			t.Fatalf("legacy %t: подпись не прошла проверку: %v", legacy, err)
+		}
+		if legacy {
+			// Перевод строки в конце файла подписи прежнего формата не учитывается
+			sig, err := os.ReadFile(fSig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ending := range []string{"\n", "\r\n"} {
+				if err := os.WriteFile(fSig, append(sig, ending...), 0600); err != nil {
+					t.Fatal(err)
+				}
+				if ok, err := verifySign(s, fData, fSig, fPub, true); !ok || err != nil {
+					t.Fatalf("окончание %q: подпись не прошла проверку: %v", ending, err)
+				}
+			}
+		}
+	}
+
+	// Измененный файл не проходит проверку
+	fSig := filepath.Join(dir, "data-false.sig")
+	if err := os.WriteFile(fData, []byte("измененные данные"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := verifySign(s, fData, fSig, fPub, false); ok {
+		t.Fatal("принята подпись измененного файла")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+// Ввод пароля для зашифрованных приватных ключей
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Общий буфер стандартного ввода, чтобы не терять данные между запросами
+var stdin = bufio.NewReader(os.Stdin)
+
+// Запрос пароля с приглашением prompt
+// С терминала пароль читается без отображения вводимых символов,
+// если отключить отображение не удалось, пароль не читается
+// Если ввод идет не с терминала, читается одна строка
+func readPassphrase(prompt string) ([]byte, error) {
+	fmt.Print(prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		pass, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return nil, fmt.Errorf("Не удалось прочитать пароль без отображения ввода: %s", err)
+		}
+		return pass, nil
+	}
+	line, err := stdin.ReadString('\n')
+	fmt.Println()
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("Не удалось прочитать пароль: %s", err)
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+// Запрос нового пароля с подтверждением
+func readNewPassphrase() ([]byte, error) {
+	pass, err := readPassphrase("Введите пароль для защиты приватного ключа: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 {
+		return nil, fmt.Errorf("Пароль не может быть пустым")
+	}
+	confirm, err := readPassphrase("Повторите пароль: ")
+	if err != nil {
+		return nil, err
+	}
+	if string(pass) != string(confirm) {
+		return nil, fmt.Errorf("Пароли не совпадают")
+	}
+	return pass, nil
+}
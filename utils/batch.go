@@ -0,0 +1,151 @@
+package utils
+
+// Пакетная проверка подписей
+// Подписи проверяются параллельно ограниченным числом горутин
+// Общие предвычисления:
+// - таблица кратных базовой точки P вычисляется один раз для кривой
+// - каждый публичный ключ проверяется и получает таблицу кратных один раз на пакет
+// В режиме FailFast проверка прекращается после первой неверной подписи или ошибки
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Проверка элемента пакета не выполнялась из-за остановки в режиме FailFast
+var ErrBatchAborted = errors.New("проверка пакета остановлена после неверной подписи")
+
+// Элемент пакета для проверки
+type BatchItem struct {
+	// Подписанное сообщение
+	Message []byte
+	// Подпись во внутреннем формате r || s
+	Signature []byte
+	// Публичный ключ для проверки
+	PublicKey *PublicKey
+}
+
+// Результат проверки элемента пакета
+type BatchResult struct {
+	// Подпись верна
+	Valid bool
+	// Ошибка проверки, как у VerifySign
+	Err error
+}
+
+// Тип для пакетной проверки подписей
+type BatchVerifier struct {
+	// Signer, задающий кривую, режим и строгость проверки
+	sign *Signer
+	// Число горутин
+	workers int
+	// Остановка после первой неверной подписи
+	failFast bool
+}
+
+// Подготовленный публичный ключ
+type batchKey struct {
+	once sync.Once
+	// Таблица кратных Q
+	table *pointTable
+	// Результат проверки ключа
+	err error
+}
+
+// "Конструктор" для типа BatchVerifier
+// Если workers <= 0, число горутин равно GOMAXPROCS
+func NewBatchVerifier(sign *Signer, workers int) *BatchVerifier {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &BatchVerifier{
+		sign:    sign,
+		workers: workers,
+	}
+}
+
+// Включение или выключение остановки после первой неверной подписи
+// Не проверенные элементы получают ошибку ErrBatchAborted
+func (b *BatchVerifier) SetFailFast(failFast bool) {
+	b.failFast = failFast
+}
+
+// Проверка пакета подписей
+// Результаты возвращаются в порядке элементов пакета
+func (b *BatchVerifier) Verify(items []BatchItem) []BatchResult {
+	results := make([]BatchResult, len(items))
+
+	var (
+		mu   sync.Mutex
+		keys = make(map[string]*batchKey)
+		// 1 после первой неверной подписи в режиме FailFast
+		stop int32
+		wg   sync.WaitGroup
+	)
+
+	// Получение подготовленного ключа, ключи с одинаковыми координатами подготавливаются один раз
+	prepare := func(pubKey *PublicKey) (*pointTable, error) {
+		if pubKey == nil || pubKey.X == nil || pubKey.Y == nil {
+			return nil, b.sign.ValidatePublicKey(pubKey)
+		}
+		// Кривая проверяется для каждого элемента, в кеше только координаты
+		if pubKey.Curve != nil && !sameCurve(b.sign.c, pubKey.Curve) {
+			return nil, b.sign.ValidatePublicKey(pubKey)
+		}
+		id := pubKey.X.Text(16) + ":" + pubKey.Y.Text(16)
+
+		mu.Lock()
+		k, ok := keys[id]
+		if !ok {
+			k = &batchKey{}
+			keys[id] = k
+		}
+		mu.Unlock()
+
+		k.once.Do(func() {
+			if k.err = b.sign.ValidatePublicKey(pubKey); k.err == nil {
+				k.table = b.sign.c.newTable(pubKey.X, pubKey.Y)
+			}
+		})
+		return k.table, k.err
+	}
+
+	jobs := make(chan int)
+	for w := 0; w < b.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if atomic.LoadInt32(&stop) == 1 {
+					results[i] = BatchResult{Err: ErrBatchAborted}
+					continue
+				}
+				item := items[i]
+				ok, err := b.verifyItem(item, prepare)
+				results[i] = BatchResult{Valid: ok, Err: err}
+				if b.failFast && !ok {
+					atomic.StoreInt32(&stop, 1)
+				}
+			}
+		}()
+	}
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Проверка одного элемента пакета, повторяет VerifySign с подготовленным ключом
+func (b *BatchVerifier) verifyItem(item BatchItem, prepare func(*PublicKey) (*pointTable, error)) (bool, error) {
+	table, err := prepare(item.PublicKey)
+	if err != nil {
+		return false, err
+	}
+	hash := NewHasher(b.sign.mode).GetHashBytes(item.Message)
+	return b.sign.verifyDigestTable(hash, item.Signature, table)
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// Пакет из верных и неверных подписей двумя ключами
+func batchItems(t *testing.T, sign *Signer) []BatchItem {
+	t.Helper()
+	pub1, priv1, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, priv2, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	offCurve := NewPublicKeyOnCurve(sign.c, pub1.X, new(big.Int).Add(pub1.Y, i1))
+
+	var items []BatchItem
+	for i := 0; i < 8; i++ {
+		pub, priv := pub1, priv1
+		if i%2 == 1 {
+			pub, priv = pub2, priv2
+		}
+		message := []byte(fmt.Sprintf("сообщение %d", i))
+		signature, err := sign.SignBytes(message, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corrupted := append([]byte(nil), signature...)
+		corrupted[len(corrupted)-1] ^= 1
+
+		items = append(items,
+			BatchItem{Message: message, Signature: signature, PublicKey: pub},
+			BatchItem{Message: []byte("другое сообщение"), Signature: signature, PublicKey: pub},
+			BatchItem{Message: message, Signature: corrupted, PublicKey: pub},
+			BatchItem{Message: message, Signature: signature[1:], PublicKey: pub},
+		)
+		if i%2 == 0 {
+			items = append(items, BatchItem{Message: message, Signature: signature, PublicKey: pub2})
+		}
+	}
+	items = append(items,
+		BatchItem{Message: []byte("ключ не на кривой"), Signature: items[0].Signature, PublicKey: offCurve},
+		BatchItem{Message: []byte("нет ключа"), Signature: items[0].Signature},
+	)
+	return items
+}
+
+// Результат совпадает с результатом VerifyDigest для каждого элемента
+func checkBatchResult(sign *Signer, item BatchItem, res BatchResult) error {
+	ok, err := sign.VerifyDigest(sign.Digest(item.Message), item.Signature, item.PublicKey)
+	if res.Valid != ok || (res.Err == nil) != (err == nil) || (err != nil && res.Err.Error() != err.Error()) {
+		return fmt.Errorf("пакет: %t, %v, VerifyDigest: %t, %v", res.Valid, res.Err, ok, err)
+	}
+	return nil
+}
+
+func TestBatchVerifier(t *testing.T) {
+	for _, ps := range ParamSets() {
+		for _, strict := range []bool{false, true} {
+			sign := NewSigner(ps.Curve(), ps.HashMode)
+			sign.SetStrictVerification(strict)
+			items := batchItems(t, sign)
+
+			results := NewBatchVerifier(sign, 0).Verify(items)
+			if len(results) != len(items) {
+				t.Fatalf("%s: %d результатов для %d элементов", ps.Name, len(results), len(items))
+			}
+			valid := 0
+			for i, res := range results {
+				if err := checkBatchResult(sign, items[i], res); err != nil {
+					t.Fatalf("%s, строгий режим %t, элемент %d: %v", ps.Name, strict, i, err)
+				}
+				if res.Valid {
+					valid++
+				}
+			}
+			if valid != 8 {
+				t.Fatalf("%s: верных подписей %d, ожидалось 8", ps.Name, valid)
+			}
+		}
+	}
+}
+
+func TestBatchVerifierFailFast(t *testing.T) {
+	ps, err := ParamSetByName("id-tc26-gost-3410-2012-256-paramSetB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := NewSigner(ps.Curve(), ps.HashMode)
+	items := batchItems(t, sign)
+
+	// Одна горутина проверяет элементы по порядку: первый верен, второй нет, остальные не проверяются
+	b := NewBatchVerifier(sign, 1)
+	b.SetFailFast(true)
+	results := b.Verify(items)
+	if !results[0].Valid || results[0].Err != nil {
+		t.Fatalf("элемент 0: %t, %v", results[0].Valid, results[0].Err)
+	}
+	if results[1].Valid || errors.Is(results[1].Err, ErrBatchAborted) {
+		t.Fatalf("элемент 1: %t, %v", results[1].Valid, results[1].Err)
+	}
+	for i, res := range results[2:] {
+		if res.Valid || !errors.Is(res.Err, ErrBatchAborted) {
+			t.Fatalf("элемент %d: %t, %v, ожидалось %v", i+2, res.Valid, res.Err, ErrBatchAborted)
+		}
+	}
+
+	// Несколько горутин: каждый элемент либо проверен как VerifyDigest, либо пропущен
+	b = NewBatchVerifier(sign, 4)
+	b.SetFailFast(true)
+	for i, res := range b.Verify(items) {
+		if errors.Is(res.Err, ErrBatchAborted) {
+			continue
+		}
+		if err := checkBatchResult(sign, items[i], res); err != nil {
+			t.Fatalf("элемент %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package utils
+
+// Списки отзыва сертификатов (CRL) X.509 v2 с подписью ГОСТ Р 34.10-2012 (RFC 5280 п.5)
+// Полные и разностные (delta) списки отзыва: разностный содержит изменения относительно
+// полного списка с номером из расширения deltaCRLIndicator
+// Список подписывается ключом сертификата УЦ, выпустившего отзываемые сертификаты,
+// косвенные списки отзыва и отдельные ключи подписи списков не поддерживаются
+// Подпись формируется и проверяется Signer, подробнее в utils/signed_data.go
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Тип PEM блока списка отзыва
+const pemRevocationList = "X509 CRL"
+
+var (
+	// Расширения списка отзыва и его элементов RFC 5280
+	oidExtensionCRLNumber         = asn1.ObjectIdentifier{2, 5, 29, 20}
+	oidExtensionReasonCode        = asn1.ObjectIdentifier{2, 5, 29, 21}
+	oidExtensionDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+	oidExtensionFreshestCRL       = asn1.ObjectIdentifier{2, 5, 29, 46}
+)
+
+// Причины отзыва сертификата CRLReason (RFC 5280 п.5.3.1)
+const (
+	ReasonUnspecified          = 0
+	ReasonKeyCompromise        = 1
+	ReasonCACompromise         = 2
+	ReasonAffiliationChanged   = 3
+	ReasonSuperseded           = 4
+	ReasonCessationOfOperation = 5
+	ReasonCertificateHold      = 6
+	// Только в разностном списке: сертификат исключен из списка отзыва
+	ReasonRemoveFromCRL      = 8
+	ReasonPrivilegeWithdrawn = 9
+	ReasonAACompromise       = 10
+)
+
+// Имена причин отзыва
+var reasonNames = map[int]string{
+	ReasonUnspecified:          "unspecified",
+	ReasonKeyCompromise:        "keyCompromise",
+	ReasonCACompromise:         "cACompromise",
+	ReasonAffiliationChanged:   "affiliationChanged",
+	ReasonSuperseded:           "superseded",
+	ReasonCessationOfOperation: "cessationOfOperation",
+	ReasonCertificateHold:      "certificateHold",
+	ReasonRemoveFromCRL:        "removeFromCRL",
+	ReasonPrivilegeWithdrawn:   "privilegeWithdrawn",
+	ReasonAACompromise:         "aACompromise",
+}
+
+// Имя причины отзыва
+func ReasonName(reason int) string {
+	if name, ok := reasonNames[reason]; ok {
+		return name
+	}
+	return fmt.Sprintf("reason(%d)", reason)
+}
+
+// Определение причины отзыва по имени
+func LookupReason(name string) (int, error) {
+	for reason, n := range reasonNames {
+		if n == name {
+			return reason, nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестная причина отзыва: %s", name)
+}
+
+// CertificateList
+type certificateList struct {
+	TBSCertList        asn1.RawValue
+	SignatureAlgorithm algorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+// TBSCertList
+type tbsCertList struct {
+	Version             int `asn1:"optional"`
+	Signature           algorithmIdentifier
+	Issuer              asn1.RawValue
+	ThisUpdate          time.Time
+	NextUpdate          time.Time            `asn1:"optional"`
+	RevokedCertificates []revokedCertificate `asn1:"optional"`
+	Extensions          []pkix.Extension     `asn1:"optional,explicit,tag:0"`
+}
+
+// Элемент списка отзыва
+type revokedCertificate struct {
+	SerialNumber   *big.Int
+	RevocationDate time.Time
+	Extensions     []pkix.Extension `asn1:"optional"`
+}
+
+// Отозванный сертификат
+type RevokedCertificate struct {
+	// Серийный номер сертификата
+	SerialNumber *big.Int
+	// Время отзыва
+	RevocationTime time.Time
+	// Причина отзыва, Reason*
+	ReasonCode int
+}
+
+// Шаблон списка отзыва
+type RevocationListTemplate struct {
+	// Номер списка, должен возрастать с каждым выпущенным списком
+	Number *big.Int
+	// Номер полного списка, относительно которого выпускается разностный список
+	// Если nil, выпускается полный список
+	BaseNumber *big.Int
+	// Время выпуска и время выпуска следующего списка
+	ThisUpdate, NextUpdate time.Time
+	// Отозванные сертификаты
+	RevokedCertificates []RevokedCertificate
+}
+
+// Список отзыва с подписью ГОСТ Р 34.10
+type RevocationList struct {
+	// Список в DER, подписываемая часть и издатель в исходном виде
+	Raw, RawTBSRevocationList, RawIssuer []byte
+	// Издатель
+	Issuer pkix.Name
+	// Идентификатор ключа издателя из authorityKeyIdentifier
+	AuthorityKeyId []byte
+	// Номер списка
+	Number *big.Int
+	// Номер полного списка для разностного списка, для полного - nil
+	BaseNumber *big.Int
+	// Время выпуска и время выпуска следующего списка (нулевое, если не задано)
+	ThisUpdate, NextUpdate time.Time
+	// Отозванные сертификаты
+	RevokedCertificates []RevokedCertificate
+	// URL актуальных разностных списков из расширения freshestCRL
+	FreshestCRL []string
+	// OID алгоритма подписи списка
+	SignatureAlgorithmOID asn1.ObjectIdentifier
+
+	// Алгоритм и значение подписи в исходном виде
+	signatureAlgorithm algorithmIdentifier
+	signature          asn1.BitString
+}
+
+// Расширение-число: cRLNumber или deltaCRLIndicator
+func numberExtension(id asn1.ObjectIdentifier, critical bool, n *big.Int) (pkix.Extension, error) {
+	if n == nil || n.Sign() < 0 {
+		return pkix.Extension{}, fmt.Errorf("номер списка отзыва должен быть неотрицательным")
+	}
+	value, err := asn1.Marshal(n)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: id, Critical: critical, Value: value}, nil
+}
+
+// Выпуск списка отзыва по шаблону tmpl, подписанного ключом privKey сертификата УЦ issuer
+// Сертификат издателя должен допускать подпись списков отзыва (cRLSign)
+// Возвращает список в DER, подпись которого проверена
+func (sign *Signer) CreateRevocationList(tmpl *RevocationListTemplate, issuer *Certificate, privKey *PrivateKey) ([]byte, error) {
+	if privKey.Curve == nil {
+		return nil, fmt.Errorf("приватный ключ издателя не привязан к кривой")
+	}
+	if sign.mode != privKey.Curve.Size() {
+		return nil, fmt.Errorf("режим %d не соответствует ключу издателя %d бит", sign.mode, privKey.Curve.Size())
+	}
+	if !privKey.Public().(*PublicKey).Equal(issuer.PublicKey) {
+		return nil, ErrKeyPairMismatch
+	}
+	if issuer.KeyUsage != 0 && issuer.KeyUsage&x509.KeyUsageCRLSign == 0 {
+		return nil, fmt.Errorf("ключ издателя %q не предназначен для подписи списков отзыва", issuer.Subject)
+	}
+	if !tmpl.NextUpdate.After(tmpl.ThisUpdate) {
+		return nil, fmt.Errorf("время выпуска следующего списка отзыва должно быть позже текущего")
+	}
+	if tmpl.BaseNumber != nil && tmpl.Number != nil && tmpl.BaseNumber.Cmp(tmpl.Number) >= 0 {
+		return nil, fmt.Errorf("номер разностного списка отзыва должен быть больше номера полного списка")
+	}
+
+	issuerKeyID := issuer.SubjectKeyId
+	if len(issuerKeyID) == 0 {
+		var err error
+		if issuerKeyID, err = issuer.PublicKey.KeyID(); err != nil {
+			return nil, err
+		}
+	}
+	value, err := asn1.Marshal(authorityKeyID{ID: issuerKeyID})
+	if err != nil {
+		return nil, err
+	}
+	exts := []pkix.Extension{{Id: oidExtensionAuthorityKeyID, Value: value}}
+	ext, err := numberExtension(oidExtensionCRLNumber, false, tmpl.Number)
+	if err != nil {
+		return nil, err
+	}
+	exts = append(exts, ext)
+	// deltaCRLIndicator всегда критично (RFC 5280 п.5.2.4)
+	if tmpl.BaseNumber != nil {
+		if ext, err = numberExtension(oidExtensionDeltaCRLIndicator, true, tmpl.BaseNumber); err != nil {
+			return nil, err
+		}
+		exts = append(exts, ext)
+	}
+
+	var revoked []revokedCertificate
+	for _, rc := range tmpl.RevokedCertificates {
+		if rc.SerialNumber == nil || rc.SerialNumber.Sign() <= 0 {
+			return nil, fmt.Errorf("серийный номер отозванного сертификата должен быть положительным")
+		}
+		if rc.ReasonCode == ReasonRemoveFromCRL && tmpl.BaseNumber == nil {
+			return nil, fmt.Errorf("причина removeFromCRL допустима только в разностном списке отзыва")
+		}
+		entry := revokedCertificate{SerialNumber: rc.SerialNumber, RevocationDate: rc.RevocationTime.UTC().Truncate(time.Second)}
+		// Причина unspecified не указывается (RFC 5280 п.5.3.1)
+		if rc.ReasonCode != ReasonUnspecified {
+			value, err := asn1.Marshal(asn1.Enumerated(rc.ReasonCode))
+			if err != nil {
+				return nil, err
+			}
+			entry.Extensions = []pkix.Extension{{Id: oidExtensionReasonCode, Value: value}}
+		}
+		revoked = append(revoked, entry)
+	}
+
+	algo, err := signatureAlgorithm(sign.mode)
+	if err != nil {
+		return nil, err
+	}
+	tbs, err := asn1.Marshal(tbsCertList{
+		Version:             1,
+		Signature:           algorithmIdentifier{Algorithm: algo},
+		Issuer:              asn1.RawValue{FullBytes: issuer.RawSubject},
+		ThisUpdate:          tmpl.ThisUpdate.UTC().Truncate(time.Second),
+		NextUpdate:          tmpl.NextUpdate.UTC().Truncate(time.Second),
+		RevokedCertificates: revoked,
+		Extensions:          exts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sigAlgo, signature, err := sign.signData(tbs, privKey)
+	if err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(certificateList{
+		TBSCertList:        asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: sigAlgo,
+		SignatureValue:     signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка выпущенного списка разбором и проверкой подписи
+	crl, err := ParseRevocationList(der)
+	if err != nil {
+		return nil, err
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, err
+	}
+	return der, nil
+}
+
+// Разбор списка отзыва из DER
+// Списки с неизвестными критичными расширениями отвергаются, так как не могут быть
+// корректно применены (RFC 5280 п.5.2). Подпись при разборе не проверяется
+func ParseRevocationList(der []byte) (*RevocationList, error) {
+	var raw certificateList
+	if rest, err := asn1.Unmarshal(der, &raw); err != nil {
+		return nil, fmt.Errorf("неверная структура списка отзыва: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после списка отзыва")
+	}
+	var tbs tbsCertList
+	if rest, err := asn1.Unmarshal(raw.TBSCertList.FullBytes, &tbs); err != nil {
+		return nil, fmt.Errorf("неверная структура списка отзыва: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные в списке отзыва")
+	}
+	if !tbs.Signature.Algorithm.Equal(raw.SignatureAlgorithm.Algorithm) {
+		return nil, fmt.Errorf("алгоритм подписи списка отзыва не совпадает с указанным в подписываемой части")
+	}
+
+	crl := &RevocationList{
+		Raw:                   der,
+		RawTBSRevocationList:  raw.TBSCertList.FullBytes,
+		RawIssuer:             tbs.Issuer.FullBytes,
+		ThisUpdate:            tbs.ThisUpdate,
+		NextUpdate:            tbs.NextUpdate,
+		SignatureAlgorithmOID: raw.SignatureAlgorithm.Algorithm,
+		signatureAlgorithm:    raw.SignatureAlgorithm,
+		signature:             raw.SignatureValue,
+	}
+	var issuer pkix.RDNSequence
+	if _, err := asn1.Unmarshal(tbs.Issuer.FullBytes, &issuer); err != nil {
+		return nil, fmt.Errorf("издатель списка отзыва: %w", err)
+	}
+	crl.Issuer.FillFromRDNSequence(&issuer)
+
+	for _, ext := range tbs.Extensions {
+		var err error
+		switch {
+		case ext.Id.Equal(oidExtensionAuthorityKeyID):
+			var akid authorityKeyID
+			_, err = asn1.Unmarshal(ext.Value, &akid)
+			crl.AuthorityKeyId = akid.ID
+		case ext.Id.Equal(oidExtensionCRLNumber):
+			_, err = asn1.Unmarshal(ext.Value, &crl.Number)
+		case ext.Id.Equal(oidExtensionDeltaCRLIndicator):
+			_, err = asn1.Unmarshal(ext.Value, &crl.BaseNumber)
+		case ext.Id.Equal(oidExtensionFreshestCRL):
+			crl.FreshestCRL, err = parseDistributionPoints(ext.Value)
+		case ext.Critical:
+			err = fmt.Errorf("неподдерживаемое критичное расширение")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("расширение списка отзыва %s: %w", ext.Id, err)
+		}
+	}
+	if crl.BaseNumber != nil && crl.Number == nil {
+		return nil, fmt.Errorf("разностный список отзыва не содержит номера cRLNumber")
+	}
+
+	for _, entry := range tbs.RevokedCertificates {
+		rc := RevokedCertificate{SerialNumber: entry.SerialNumber, RevocationTime: entry.RevocationDate}
+		for _, ext := range entry.Extensions {
+			switch {
+			case ext.Id.Equal(oidExtensionReasonCode):
+				var reason asn1.Enumerated
+				if _, err := asn1.Unmarshal(ext.Value, &reason); err != nil {
+					return nil, fmt.Errorf("причина отзыва сертификата %x: %w", entry.SerialNumber, err)
+				}
+				rc.ReasonCode = int(reason)
+			case ext.Critical:
+				// Например certificateIssuer косвенного списка отзыва
+				return nil, fmt.Errorf("отозванный сертификат %x: неподдерживаемое критичное расширение %s", entry.SerialNumber, ext.Id)
+			}
+		}
+		crl.RevokedCertificates = append(crl.RevokedCertificates, rc)
+	}
+	return crl, nil
+}
+
+// DistributionPoint, используется только fullName
+type distributionPoint struct {
+	DistributionPoint distributionPointName `asn1:"optional,tag:0"`
+	Reason            asn1.BitString        `asn1:"optional,tag:1"`
+	CRLIssuer         asn1.RawValue         `asn1:"optional,tag:2"`
+}
+
+// DistributionPointName
+type distributionPointName struct {
+	FullName []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// Разбор URL (uniformResourceIdentifier) из расширений cRLDistributionPoints и freshestCRL
+func parseDistributionPoints(value []byte) ([]string, error) {
+	var points []distributionPoint
+	if _, err := asn1.Unmarshal(value, &points); err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, dp := range points {
+		for _, name := range dp.DistributionPoint.FullName {
+			if name.Class == asn1.ClassContextSpecific && name.Tag == 6 {
+				urls = append(urls, string(name.Bytes))
+			}
+		}
+	}
+	return urls, nil
+}
+
+// Расширение со списком URL в формате cRLDistributionPoints
+func distributionPointsExtension(id asn1.ObjectIdentifier, urls []string) (pkix.Extension, error) {
+	points := make([]distributionPoint, 0, len(urls))
+	for _, url := range urls {
+		name := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte(url)}
+		points = append(points, distributionPoint{DistributionPoint: distributionPointName{FullName: []asn1.RawValue{name}}})
+	}
+	value, err := asn1.Marshal(points)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: id, Value: value}, nil
+}
+
+// Разбор первого списка отзыва из PEM с заголовком X509 CRL
+// Данные не в PEM разбираются как DER
+func ParseRevocationListPEM(data []byte) (*RevocationList, error) {
+	if !isPEM(data) {
+		return ParseRevocationList(data)
+	}
+	der, err := decodePEM(data, pemRevocationList)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRevocationList(der)
+}
+
+// Запись списка отзыва в PEM с заголовком X509 CRL
+func (crl *RevocationList) MarshalPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemRevocationList, Bytes: crl.Raw})
+}
+
+// Признак разностного списка отзыва
+func (crl *RevocationList) IsDelta() bool {
+	return crl.BaseNumber != nil
+}
+
+// Поиск отозванного сертификата по серийному номеру, nil - сертификат в списке отсутствует
+func (crl *RevocationList) Lookup(serial *big.Int) *RevokedCertificate {
+	for i := range crl.RevokedCertificates {
+		if crl.RevokedCertificates[i].SerialNumber.Cmp(serial) == 0 {
+			return &crl.RevokedCertificates[i]
+		}
+	}
+	return nil
+}
+
+// Проверка подписи списка отзыва ключом сертификата издателя issuer
+// Издатель списка должен совпадать с субъектом issuer, а issuer - допускать подпись списков отзыва
+func (crl *RevocationList) CheckSignatureFrom(issuer *Certificate) error {
+	if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) {
+		return fmt.Errorf("издатель списка отзыва %q не совпадает с субъектом %q", crl.Issuer, issuer.Subject)
+	}
+	if len(crl.AuthorityKeyId) != 0 && len(issuer.SubjectKeyId) != 0 && !bytes.Equal(crl.AuthorityKeyId, issuer.SubjectKeyId) {
+		return fmt.Errorf("список отзыва %q подписан другим ключом издателя", crl.Issuer)
+	}
+	if issuer.KeyUsage != 0 && issuer.KeyUsage&x509.KeyUsageCRLSign == 0 {
+		return fmt.Errorf("сертификат %q не допускает подпись списков отзыва: %w", issuer.Subject, ErrKeyUsage)
+	}
+	sign, err := verifierFor(issuer.PublicKey, crl.SignatureAlgorithmOID)
+	if err != nil {
+		return err
+	}
+	if err := sign.verifyData(crl.signatureAlgorithm, crl.RawTBSRevocationList, crl.signature, issuer.PublicKey); err != nil {
+		return fmt.Errorf("список отзыва %q: %w", crl.Issuer, err)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package utils
+
+// Хранилище списков отзыва и проверка статуса отзыва сертификатов (RFC 5280 п.6.3)
+// Списки добавляются из файлов или загружаются по URL из расширений cRLDistributionPoints
+// и freshestCRL. Разобранные списки и результаты проверки их подписей кешируются,
+// загруженный по URL список используется повторно до времени выпуска следующего списка
+// Статус определяется по последнему действующему полному списку издателя и, если есть,
+// последнему разностному списку к нему
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Максимальный размер загружаемого списка отзыва
+const maxCRLSize = 16 << 20
+
+var (
+	// Сертификат отозван
+	ErrRevoked = errors.New("сертификат отозван")
+	// Нет действующего списка отзыва издателя сертификата
+	ErrRevocationUnknown = errors.New("статус отзыва неизвестен: нет действующего списка отзыва")
+)
+
+// Хранилище списков отзыва
+// Безопасно для одновременного использования из нескольких горутин
+type CRLStore struct {
+	// HTTP клиент для загрузки списков по URL из сертификатов, если nil - списки не загружаются
+	Client *http.Client
+
+	mu   sync.Mutex
+	crls []*RevocationList
+	// Загруженные списки по URL
+	fetched map[string]*RevocationList
+	// Сертификаты издателей в DER, ключом которых проверена подпись списка
+	verified map[*RevocationList][]byte
+}
+
+// Конструктор пустого хранилища списков отзыва
+func NewCRLStore() *CRLStore {
+	return &CRLStore{
+		fetched:  make(map[string]*RevocationList),
+		verified: make(map[*RevocationList][]byte),
+	}
+}
+
+// Добавление списка отзыва
+// Повторное добавление того же списка игнорируется
+func (st *CRLStore) Add(crl *RevocationList) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, c := range st.crls {
+		if bytes.Equal(c.Raw, crl.Raw) {
+			return
+		}
+	}
+	st.crls = append(st.crls, crl)
+}
+
+// Добавление всех списков отзыва из PEM с заголовком X509 CRL или одного списка в DER
+func (st *CRLStore) AddPEM(data []byte) error {
+	if !isPEM(data) {
+		crl, err := ParseRevocationList(data)
+		if err != nil {
+			return err
+		}
+		st.Add(crl)
+		return nil
+	}
+	found := false
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != pemRevocationList {
+			continue
+		}
+		crl, err := ParseRevocationList(block.Bytes)
+		if err != nil {
+			return err
+		}
+		st.Add(crl)
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("не найден PEM блок %s", pemRevocationList)
+	}
+	return nil
+}
+
+// Добавление списков отзыва из файла или из всех файлов .crl и .pem каталога
+// Файлы PEM каталога без блоков X509 CRL, например сертификаты, пропускаются
+func (st *CRLStore) AddFile(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	files := []string{path}
+	if fi.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.crl", "*.pem"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return err
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("каталог %s не содержит списков отзыва", path)
+		}
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() && isPEM(data) && !bytes.Contains(data, []byte("-----BEGIN "+pemRevocationList+"-----")) {
+			continue
+		}
+		if err := st.AddPEM(data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Проверка того, что данные в PEM, а не в DER
+func isPEM(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN"))
+}
+
+// Проверка статуса отзыва сертификата cert, выпущенного issuer, на момент now
+// Возвращает ошибку ErrRevoked для отозванного сертификата и ErrRevocationUnknown,
+// если нет действующего полного списка отзыва издателя
+func (st *CRLStore) Check(cert, issuer *Certificate, now time.Time) error {
+	fetchErr := st.fetch(cert.CRLDistributionPoints, now)
+
+	complete := st.selectCRL(issuer, now, nil)
+	if complete == nil {
+		if fetchErr != nil {
+			return fmt.Errorf("сертификат %q: %w (%v)", cert.Subject, ErrRevocationUnknown, fetchErr)
+		}
+		return fmt.Errorf("сертификат %q: %w", cert.Subject, ErrRevocationUnknown)
+	}
+
+	// Разностные списки по freshestCRL сертификата и полного списка
+	var freshest []string
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidExtensionFreshestCRL) {
+			// Ошибка разбора не мешает проверке по полному списку
+			freshest, _ = parseDistributionPoints(ext.Value)
+		}
+	}
+	freshest = append(freshest, complete.FreshestCRL...)
+	// Отсутствие разностного списка не мешает проверке по полному списку
+	_ = st.fetch(freshest, now)
+
+	entry := complete.Lookup(cert.SerialNumber)
+	if delta := st.selectCRL(issuer, now, complete); delta != nil {
+		if rc := delta.Lookup(cert.SerialNumber); rc != nil {
+			entry = rc
+		}
+	}
+	if entry == nil || entry.ReasonCode == ReasonRemoveFromCRL {
+		return nil
+	}
+	return fmt.Errorf("сертификат %q (серийный номер %x) отозван %s, причина %s: %w", cert.Subject, cert.SerialNumber,
+		entry.RevocationTime.Format(time.RFC3339), ReasonName(entry.ReasonCode), ErrRevoked)
+}
+
+// Выбор последнего действующего на момент now списка издателя issuer с проверенной подписью
+// Если base равен nil, выбирается полный список, иначе - разностный список к полному списку base
+func (st *CRLStore) selectCRL(issuer *Certificate, now time.Time, base *RevocationList) *RevocationList {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	candidates := append([]*RevocationList(nil), st.crls...)
+	for _, crl := range st.fetched {
+		candidates = append(candidates, crl)
+	}
+
+	var best *RevocationList
+	for _, crl := range candidates {
+		if crl.IsDelta() != (base != nil) || !crl.current(now) {
+			continue
+		}
+		// Разностный список должен относиться к полному списку не новее base и быть новее его
+		// (RFC 5280 п.5.2.4)
+		if base != nil && (base.Number == nil || crl.BaseNumber.Cmp(base.Number) > 0 || crl.Number.Cmp(base.Number) <= 0) {
+			continue
+		}
+		if best != nil && !crl.newerThan(best) {
+			continue
+		}
+		if !st.checkSignature(crl, issuer) {
+			continue
+		}
+		best = crl
+	}
+	return best
+}
+
+// Проверка подписи списка ключом издателя с кешированием результата
+// Вызывается при захваченном st.mu
+func (st *CRLStore) checkSignature(crl *RevocationList, issuer *Certificate) bool {
+	if raw, ok := st.verified[crl]; ok && bytes.Equal(raw, issuer.Raw) {
+		return true
+	}
+	if crl.CheckSignatureFrom(issuer) != nil {
+		return false
+	}
+	st.verified[crl] = issuer.Raw
+	return true
+}
+
+// Загрузка списков отзыва по HTTP URL, отсутствующих в кеше или устаревших на момент now
+// Возвращает последнюю ошибку загрузки
+func (st *CRLStore) fetch(urls []string, now time.Time) error {
+	if st.Client == nil {
+		return nil
+	}
+	var lastErr error
+	for _, url := range urls {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+		st.mu.Lock()
+		cached, ok := st.fetched[url]
+		st.mu.Unlock()
+		if ok && cached.current(now) {
+			continue
+		}
+
+		crl, err := st.download(url)
+		if err != nil {
+			lastErr = fmt.Errorf("загрузка списка отзыва %s: %w", url, err)
+			continue
+		}
+		st.mu.Lock()
+		if cached != nil {
+			delete(st.verified, cached)
+		}
+		st.fetched[url] = crl
+		st.mu.Unlock()
+	}
+	return lastErr
+}
+
+// Загрузка и разбор списка отзыва по URL
+func (st *CRLStore) download(url string) (*RevocationList, error) {
+	resp, err := st.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ответ сервера: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCRLSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCRLSize {
+		return nil, fmt.Errorf("размер списка отзыва превышает %d байт", maxCRLSize)
+	}
+	return ParseRevocationListPEM(data)
+}
+
+// Проверка того, что список действует на момент now
+// Список без nextUpdate считается действующим с момента выпуска
+func (crl *RevocationList) current(now time.Time) bool {
+	if now.Before(crl.ThisUpdate) {
+		return false
+	}
+	return crl.NextUpdate.IsZero() || !now.After(crl.NextUpdate)
+}
+
+// Проверка того, что список новее other: по номеру, а без номера - по времени выпуска
+func (crl *RevocationList) newerThan(other *RevocationList) bool {
+	if crl.Number != nil && other.Number != nil {
+		return crl.Number.Cmp(other.Number) > 0
+	}
+	return crl.ThisUpdate.After(other.ThisUpdate)
+}
@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// УЦ и выпущенные им сертификаты с серийными номерами 1, 2, 3 для проверки статуса отзыва
+type crlFixture struct {
+	sign   *Signer
+	ca     *chainEntity
+	caCert *Certificate
+	leaves []*Certificate
+}
+
+func newCRLFixture(t *testing.T, crlURL string) *crlFixture {
+	t.Helper()
+	sign := NewSigner(NewCurve256ParamSetA(), 256)
+	f := &crlFixture{sign: sign, ca: newChainEntity(t, sign, "УЦ", true, -1)}
+	f.caCert = f.ca.issue(t, sign, nil, nil, nil)
+	leaf := newChainEntity(t, sign, "Пользователь", false, -1)
+	for serial := int64(1); serial <= 3; serial++ {
+		f.leaves = append(f.leaves, leaf.issue(t, sign, f.ca, f.caCert, func(tmpl *CertificateTemplate) {
+			tmpl.SerialNumber = big.NewInt(serial)
+			if crlURL != "" {
+				tmpl.CRLDistributionPoints = []string{crlURL}
+			}
+		}))
+	}
+	return f
+}
+
+// Выпуск списка отзыва УЦ, действующего неделю с thisUpdate
+func (f *crlFixture) crl(t *testing.T, number, base int64, thisUpdate time.Time, revoked ...RevokedCertificate) *RevocationList {
+	t.Helper()
+	tmpl := &RevocationListTemplate{
+		Number:              big.NewInt(number),
+		ThisUpdate:          thisUpdate,
+		NextUpdate:          thisUpdate.AddDate(0, 0, 7),
+		RevokedCertificates: revoked,
+	}
+	if base != 0 {
+		tmpl.BaseNumber = big.NewInt(base)
+	}
+	der, err := f.sign.CreateRevocationList(tmpl, f.caCert, f.ca.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crl
+}
+
+func TestRevocationListRoundTrip(t *testing.T) {
+	f := newCRLFixture(t, "")
+	revokedAt := chainNow.Add(-time.Hour)
+	full := f.crl(t, 5, 0, chainNow.Add(-time.Minute),
+		RevokedCertificate{SerialNumber: big.NewInt(2), RevocationTime: revokedAt, ReasonCode: ReasonKeyCompromise},
+		RevokedCertificate{SerialNumber: big.NewInt(3), RevocationTime: revokedAt},
+	)
+	if err := full.CheckSignatureFrom(f.caCert); err != nil {
+		t.Fatal(err)
+	}
+	if full.IsDelta() || full.Number.Int64() != 5 || !bytes.Equal(full.AuthorityKeyId, f.caCert.SubjectKeyId) ||
+		full.Issuer.CommonName != "УЦ" || !full.NextUpdate.Equal(full.ThisUpdate.AddDate(0, 0, 7)) {
+		t.Fatalf("полный список: номер %s, издатель %s", full.Number, full.Issuer)
+	}
+	if rc := full.Lookup(big.NewInt(2)); rc == nil || rc.ReasonCode != ReasonKeyCompromise || !rc.RevocationTime.Equal(revokedAt.Truncate(time.Second)) {
+		t.Fatalf("отозванный сертификат 2: %+v", rc)
+	}
+	// Причина unspecified не записывается и читается как unspecified
+	if rc := full.Lookup(big.NewInt(3)); rc == nil || rc.ReasonCode != ReasonUnspecified {
+		t.Fatalf("отозванный сертификат 3: %+v", rc)
+	}
+	if full.Lookup(big.NewInt(1)) != nil {
+		t.Fatal("найден неотозванный сертификат")
+	}
+	parsed, err := ParseRevocationListPEM(full.MarshalPEM())
+	if err != nil || !bytes.Equal(parsed.Raw, full.Raw) {
+		t.Fatalf("PEM: %v", err)
+	}
+
+	delta := f.crl(t, 6, 5, chainNow,
+		RevokedCertificate{SerialNumber: big.NewInt(3), RevocationTime: chainNow, ReasonCode: ReasonRemoveFromCRL},
+	)
+	if err := delta.CheckSignatureFrom(f.caCert); err != nil {
+		t.Fatal(err)
+	}
+	if !delta.IsDelta() || delta.BaseNumber.Int64() != 5 || delta.Number.Int64() != 6 {
+		t.Fatalf("разностный список: номер %s, полный список %s", delta.Number, delta.BaseNumber)
+	}
+	if rc := delta.Lookup(big.NewInt(3)); rc == nil || rc.ReasonCode != ReasonRemoveFromCRL {
+		t.Fatalf("исключенный сертификат 3: %+v", rc)
+	}
+
+	for name, tmpl := range map[string]*RevocationListTemplate{
+		"removeFromCRL в полном списке": {Number: big.NewInt(7), ThisUpdate: chainNow, NextUpdate: chainNow.Add(time.Hour),
+			RevokedCertificates: []RevokedCertificate{{SerialNumber: big.NewInt(1), ReasonCode: ReasonRemoveFromCRL}}},
+		"номер не больше номера полного списка": {Number: big.NewInt(5), BaseNumber: big.NewInt(5), ThisUpdate: chainNow, NextUpdate: chainNow.Add(time.Hour)},
+		"nextUpdate раньше thisUpdate":          {Number: big.NewInt(7), ThisUpdate: chainNow, NextUpdate: chainNow.Add(-time.Hour)},
+		"нулевой серийный номер": {Number: big.NewInt(7), ThisUpdate: chainNow, NextUpdate: chainNow.Add(time.Hour),
+			RevokedCertificates: []RevokedCertificate{{SerialNumber: big.NewInt(0)}}},
+	} {
+		if _, err := f.sign.CreateRevocationList(tmpl, f.caCert, f.ca.priv); err == nil {
+			t.Fatalf("%s: список отзыва выпущен", name)
+		}
+	}
+	tmpl := &RevocationListTemplate{Number: big.NewInt(7), ThisUpdate: chainNow, NextUpdate: chainNow.Add(time.Hour)}
+	if _, err := f.sign.CreateRevocationList(tmpl, f.caCert, NewPrivateKey(f.ca.priv.D)); err == nil {
+		t.Fatal("список отзыва выпущен ключом без кривой")
+	}
+	if _, err := ParseRevocationList(append(append([]byte(nil), full.Raw...), 0)); err == nil {
+		t.Fatal("принят список отзыва с лишними данными")
+	}
+}
+
+func TestCRLStoreCheck(t *testing.T) {
+	f := newCRLFixture(t, "")
+	good, keyCompromise, hold := f.leaves[0], f.leaves[1], f.leaves[2]
+	st := NewCRLStore()
+	if err := st.Check(good, f.caCert, chainNow); !errors.Is(err, ErrRevocationUnknown) {
+		t.Fatalf("без списков отзыва: %v", err)
+	}
+
+	full := f.crl(t, 5, 0, chainNow.Add(-time.Hour),
+		RevokedCertificate{SerialNumber: keyCompromise.SerialNumber, RevocationTime: chainNow.Add(-2 * time.Hour), ReasonCode: ReasonKeyCompromise},
+		RevokedCertificate{SerialNumber: hold.SerialNumber, RevocationTime: chainNow.Add(-2 * time.Hour), ReasonCode: ReasonCertificateHold},
+	)
+	st.Add(full)
+	st.Add(full)
+	if err := st.Check(good, f.caCert, chainNow); err != nil {
+		t.Fatal(err)
+	}
+	for _, cert := range []*Certificate{keyCompromise, hold} {
+		if err := st.Check(cert, f.caCert, chainNow); !errors.Is(err, ErrRevoked) {
+			t.Fatalf("сертификат %s: %v", cert.SerialNumber, err)
+		}
+	}
+	// Список отзыва еще не выпущен или уже устарел
+	for _, now := range []time.Time{full.ThisUpdate.Add(-time.Second), full.NextUpdate.Add(time.Second)} {
+		if err := st.Check(good, f.caCert, now); !errors.Is(err, ErrRevocationUnknown) {
+			t.Fatalf("на момент %s: %v", now, err)
+		}
+	}
+
+	// Разностный список к полному списку с номером больше номера полного списка не применяется
+	future := f.crl(t, 8, 7, chainNow.Add(-time.Minute),
+		RevokedCertificate{SerialNumber: good.SerialNumber, RevocationTime: chainNow.Add(-time.Minute)},
+	)
+	st.Add(future)
+	if delta := st.selectCRL(f.caCert, chainNow, full); delta != nil {
+		t.Fatalf("выбран разностный список %s к полному списку %s", delta.Number, delta.BaseNumber)
+	}
+	if err := st.Check(good, f.caCert, chainNow); err != nil {
+		t.Fatalf("применен разностный список к другому полному списку: %v", err)
+	}
+
+	// Разностный список исключает сертификат, приостановленный в полном списке
+	delta := f.crl(t, 6, 5, chainNow.Add(-time.Minute),
+		RevokedCertificate{SerialNumber: hold.SerialNumber, RevocationTime: chainNow.Add(-time.Minute), ReasonCode: ReasonRemoveFromCRL},
+	)
+	st.Add(delta)
+	if got := st.selectCRL(f.caCert, chainNow, full); got != delta {
+		t.Fatal("не выбран разностный список к полному списку")
+	}
+	if err := st.Check(hold, f.caCert, chainNow); err != nil {
+		t.Fatalf("сертификат исключен из списка отзыва: %v", err)
+	}
+	if err := st.Check(keyCompromise, f.caCert, chainNow); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("сертификат отозван в полном списке: %v", err)
+	}
+
+	// Отзыв проверяется при проверке цепочки
+	roots := NewTrustStore()
+	roots.Add(f.caCert)
+	opts := VerifyOptions{Roots: roots, CurrentTime: chainNow, CRLs: st}
+	if _, err := good.Verify(opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyCompromise.Verify(opts); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("цепочка с отозванным сертификатом: %v", err)
+	}
+	opts.CRLs = NewCRLStore()
+	if _, err := good.Verify(opts); !errors.Is(err, ErrRevocationUnknown) {
+		t.Fatalf("цепочка без списков отзыва: %v", err)
+	}
+}
+
+// Список подписан ключом УЦ, но сертификат УЦ не допускает подпись списков отзыва
+func TestCRLStoreRequiresCRLSign(t *testing.T) {
+	f := newCRLFixture(t, "")
+	full := f.crl(t, 1, 0, chainNow.Add(-time.Hour))
+	noCRLSign := f.ca.issue(t, f.sign, nil, nil, func(tmpl *CertificateTemplate) { tmpl.KeyUsage = x509.KeyUsageCertSign })
+
+	if err := full.CheckSignatureFrom(noCRLSign); !errors.Is(err, ErrKeyUsage) {
+		t.Fatalf("сертификат без cRLSign: %v", err)
+	}
+	if _, err := f.sign.CreateRevocationList(&RevocationListTemplate{Number: big.NewInt(2), ThisUpdate: chainNow, NextUpdate: chainNow.Add(time.Hour)}, noCRLSign, f.ca.priv); err == nil {
+		t.Fatal("список отзыва выпущен по сертификату без cRLSign")
+	}
+	st := NewCRLStore()
+	st.Add(full)
+	if err := st.Check(f.leaves[0], noCRLSign, chainNow); !errors.Is(err, ErrRevocationUnknown) {
+		t.Fatalf("список издателя без cRLSign: %v", err)
+	}
+	if err := st.Check(f.leaves[0], f.caCert, chainNow); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Загрузка списка по cRLDistributionPoints и повторное использование до nextUpdate
+func TestCRLStoreFetch(t *testing.T) {
+	var mu sync.Mutex
+	var served []byte
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		w.Write(served)
+	}))
+	defer srv.Close()
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	f := newCRLFixture(t, srv.URL+"/ca.crl")
+	cert := f.leaves[0]
+	first := f.crl(t, 1, 0, chainNow.Add(-time.Hour))
+	served = first.MarshalPEM()
+
+	st := NewCRLStore()
+	st.Client = srv.Client()
+	for i := 0; i < 3; i++ {
+		if err := st.Check(cert, f.caCert, chainNow); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("список загружен %d раз", n)
+	}
+
+	// После nextUpdate загружается следующий список, сервер отдает его в DER
+	later := first.NextUpdate.Add(time.Hour)
+	mu.Lock()
+	served = f.crl(t, 2, 0, later.Add(-time.Minute),
+		RevokedCertificate{SerialNumber: cert.SerialNumber, RevocationTime: later.Add(-time.Minute), ReasonCode: ReasonSuperseded},
+	).Raw
+	mu.Unlock()
+	if err := st.Check(cert, f.caCert, later); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("следующий список: %v", err)
+	}
+	if n := count(); n != 2 {
+		t.Fatalf("список загружен %d раз", n)
+	}
+
+	// Без HTTP клиента списки не загружаются
+	if err := NewCRLStore().Check(cert, f.caCert, chainNow); !errors.Is(err, ErrRevocationUnknown) {
+		t.Fatalf("без HTTP клиента: %v", err)
+	}
+	mu.Lock()
+	served = []byte("не список отзыва")
+	mu.Unlock()
+	st = NewCRLStore()
+	st.Client = srv.Client()
+	if err := st.Check(cert, f.caCert, chainNow); !errors.Is(err, ErrRevocationUnknown) {
+		t.Fatalf("неверный ответ сервера: %v", err)
+	}
+}
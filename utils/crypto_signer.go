@@ -0,0 +1,135 @@
+package utils
+
+// Реализация интерфейсов crypto.Signer и crypto.PublicKey
+// для использования ключей ГОСТ Р 34.10-2012 в коде, написанном под стандартную библиотеку Go
+// Дайджест передается в порядке байт RFC 6986 (как его выдают реализации Стрибога)
+// и интерпретируется как число в little-endian (RFC 7091 п.6.1),
+// подпись возвращается в стандартном формате s || r (RFC 4491 п.2.2.2)
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"fmt"
+	"io"
+)
+
+// Проверка соответствия интерфейсам на этапе компиляции
+var (
+	_ crypto.Signer     = (*PrivateKey)(nil)
+	_ crypto.PublicKey  = (*PublicKey)(nil)
+	_ crypto.SignerOpts = Streebog256
+)
+
+// Идентификатор хеш-функции Стрибог для crypto.SignerOpts
+// Стрибог не зарегистрирован в crypto.Hash, поэтому используется отдельный тип
+type StreebogHash int
+
+const (
+	// Стрибог с длиной хеша 256 бит
+	Streebog256 StreebogHash = 256
+	// Стрибог с длиной хеша 512 бит
+	Streebog512 StreebogHash = 512
+)
+
+// Реализация crypto.SignerOpts
+// Стрибог не имеет значения в crypto.Hash, поэтому возвращается 0. В стандартной библиотеке
+// crypto.Hash(0) означает "сообщение не хешировано", поэтому по HashFunc нельзя определить
+// хеш-функцию: вызывающий код должен передавать в PrivateKey.Sign именно Streebog256 или
+// Streebog512, любые другие opts, включая nil и crypto.Hash, отвергаются
+func (h StreebogHash) HashFunc() crypto.Hash {
+	return 0
+}
+
+// Длина дайджеста в байтах
+func (h StreebogHash) Size() int {
+	return int(h) / 8
+}
+
+// Название хеш-функции
+func (h StreebogHash) String() string {
+	return fmt.Sprintf("Streebog-%d", int(h))
+}
+
+// Получение публичного ключа Q = dP на кривой ключа
+// Для ключа, не привязанного к кривой, возвращается nil
+func (priv *PrivateKey) Public() crypto.PublicKey {
+	if priv.Curve == nil {
+		return nil
+	}
+	x, y := priv.Curve.Exp(priv.D, priv.Curve.X, priv.Curve.Y)
+	return NewPublicKeyOnCurve(priv.Curve, x, y)
+}
+
+// Подпись дайджеста, реализация crypto.Signer
+// opts должен быть Streebog256 или Streebog512 с размером, соответствующим кривой ключа,
+// по crypto.Hash хеш-функцию Стрибог определить нельзя, подробнее в StreebogHash.HashFunc
+// rand - источник случайности для k, если nil - используется crypto/rand
+// Перед возвратом подпись проверяется публичным ключом, при расхождении возвращается ErrSignatureFault
+func (priv *PrivateKey) Sign(rnd io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if priv.Curve == nil {
+		return nil, fmt.Errorf("приватный ключ не привязан к кривой")
+	}
+	sign := NewSigner(priv.Curve, priv.Curve.Size())
+
+	h, ok := opts.(StreebogHash)
+	if !ok {
+		return nil, fmt.Errorf("неподдерживаемые параметры подписи %#v, ожидается Streebog256 или Streebog512", opts)
+	}
+	if int(h) != sign.mode {
+		return nil, fmt.Errorf("хеш-функция %s не соответствует размеру ключа %d бит", h, sign.mode)
+	}
+	if err := sign.checkDigest(digest); err != nil {
+		return nil, err
+	}
+	sign.SetRand(rnd)
+
+	// Дайджест в little-endian
+	e := sign.hashToE(reverseBytes(digest))
+	r, s, err := sign.signE(sign.random(), e, priv.D)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка подписи для защиты от сбоев, всегда включена, подробнее в utils/fault.go
+	if err := sign.checkSignature(e, r, s, priv.D); err != nil {
+		return nil, err
+	}
+	return sign.EncodeSignatureSR(r, s), nil
+}
+
+// Сравнение приватных ключей, реализация интерфейса из crypto
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	other, ok := x.(*PrivateKey)
+	if !ok || !sameCurve(priv.Curve, other.Curve) {
+		return false
+	}
+	// Без кривой длина скаляра неизвестна, ключи сравниваются напрямую
+	if priv.Curve == nil {
+		return priv.D.Cmp(other.D) == 0
+	}
+	size := (priv.Curve.Q.BitLen() + 7) / 8
+	if (priv.D.BitLen()+7)/8 > size || (other.D.BitLen()+7)/8 > size {
+		return priv.D.Cmp(other.D) == 0
+	}
+	// Сравнение за постоянное время
+	a := priv.D.FillBytes(make([]byte, size))
+	b := other.D.FillBytes(make([]byte, size))
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// Сравнение публичных ключей, реализация интерфейса из crypto
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PublicKey)
+	if !ok || !sameCurve(pub.Curve, other.Curve) {
+		return false
+	}
+	return pub.X.Cmp(other.X) == 0 && pub.Y.Cmp(other.Y) == 0
+}
+
+// Проверка того, что ключи относятся к одной кривой
+func sameCurve(a, b *Curve) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a == b || a.equalParams(b)
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"crypto"
+	"testing"
+)
+
+// Подпись через crypto.Signer проверяется VerifySign после перевода в формат r || s
+func TestCryptoSigner(t *testing.T) {
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		pubKey, privKey, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !privKey.Public().(*PublicKey).Equal(pubKey) {
+			t.Fatalf("%s: Public не совпадает с публичным ключом пары", ps.Name)
+		}
+
+		message := []byte("crypto.Signer")
+		digest := reverseBytes(sign.Digest(message))
+		var signer crypto.Signer = privKey
+		signature, err := signer.Sign(nil, digest, StreebogHash(ps.HashMode))
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		rs, err := sign.SignatureFromSR(signature)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		if ok, err := sign.VerifySign(message, rs, pubKey); !ok {
+			t.Fatalf("%s: подпись не прошла проверку: %v", ps.Name, err)
+		}
+
+		// Параметры, по которым нельзя определить хеш-функцию, отвергаются
+		other := Streebog512
+		if ps.HashMode == 512 {
+			other = Streebog256
+		}
+		for _, opts := range []crypto.SignerOpts{nil, crypto.Hash(0), crypto.SHA256, other} {
+			if _, err := signer.Sign(nil, digest, opts); err == nil {
+				t.Fatalf("%s: принята подпись с параметрами %v", ps.Name, opts)
+			}
+		}
+	}
+}
+
+// Ключ, не привязанный к кривой, не используется как crypto.Signer
+func TestCryptoSignerWithoutCurve(t *testing.T) {
+	priv := NewPrivateKey(i2)
+	if pub := priv.Public(); pub != nil {
+		t.Fatalf("Public = %v, ожидалось nil", pub)
+	}
+	if _, err := priv.Sign(nil, make([]byte, 32), Streebog256); err == nil {
+		t.Fatal("принята подпись ключом без кривой")
+	}
+	if !priv.Equal(NewPrivateKey(i2)) || priv.Equal(NewPrivateKey(i3)) {
+		t.Fatal("неверное сравнение ключей без кривой")
+	}
+	if priv.Equal(NewPrivateKeyOnCurve(NewCurve256ParamSetA(), i2)) {
+		t.Fatal("ключ без кривой совпал с ключом на кривой")
+	}
+}
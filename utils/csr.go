@@ -0,0 +1,257 @@
+package utils
+
+// Запрос на сертификат PKCS#10 (RFC 2986) с ключом и подписью ГОСТ Р 34.10-2012
+// Общие поля запроса (субъект, атрибуты, запрашиваемые расширения) разбирает crypto/x509,
+// ключ ГОСТ и подпись запроса его ключом разбираются отдельно, подробнее в utils/signed_data.go
+// Запрашиваемые расширения передаются в атрибуте extensionRequest (PKCS#9)
+// Атрибуты субъекта ИНН, ОГРН, СНИЛС задаются в имени, подробнее в utils/dn.go
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+)
+
+// Тип PEM блока запроса на сертификат
+const pemCertificateRequest = "CERTIFICATE REQUEST"
+
+var (
+	// Атрибут запрашиваемых расширений extensionRequest, PKCS#9
+	oidExtensionRequest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14}
+	// Альтернативное имя субъекта subjectAltName
+	oidExtensionSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+	// Средство электронной подписи владельца subjectSignTool, приказ ФСБ России № 795
+	oidExtensionSubjectSignTool = asn1.ObjectIdentifier{1, 2, 643, 100, 111}
+)
+
+// CertificationRequest
+type certificationRequest struct {
+	CertificationRequestInfo asn1.RawValue
+	SignatureAlgorithm       algorithmIdentifier
+	SignatureValue           asn1.BitString
+}
+
+// CertificationRequestInfo
+type certificationRequestInfo struct {
+	Version    int
+	Subject    asn1.RawValue
+	PublicKey  asn1.RawValue
+	Attributes []requestAttribute `asn1:"tag:0"`
+}
+
+// Attribute
+type requestAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// Шаблон запроса на сертификат
+type CertificateRequestTemplate struct {
+	// Субъект
+	Subject pkix.Name
+	// Запрашиваемое использование ключа, если 0 - не запрашивается
+	KeyUsage x509.KeyUsage
+	// Запрашиваемые назначения ключа
+	ExtKeyUsage []x509.ExtKeyUsage
+	// Адреса электронной почты для subjectAltName
+	EmailAddresses []string
+	// Наименование средства электронной подписи владельца для subjectSignTool
+	SubjectSignTool string
+	// Дополнительные запрашиваемые расширения
+	ExtraExtensions []pkix.Extension
+}
+
+// Формирование запрашиваемых расширений по шаблону
+func (tmpl *CertificateRequestTemplate) extensions() ([]pkix.Extension, error) {
+	var exts []pkix.Extension
+	if tmpl.KeyUsage != 0 {
+		ext, err := keyUsageExtension(tmpl.KeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, ext)
+	}
+	if len(tmpl.ExtKeyUsage) != 0 {
+		ext, err := extKeyUsageExtension(tmpl.ExtKeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, ext)
+	}
+	if len(tmpl.EmailAddresses) != 0 {
+		// GeneralNames с rfc822Name [1] IA5String
+		var names []asn1.RawValue
+		for _, email := range tmpl.EmailAddresses {
+			for _, r := range email {
+				if r > 0x7f {
+					return nil, fmt.Errorf("адрес электронной почты %q должен содержать только символы ASCII", email)
+				}
+			}
+			names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, Bytes: []byte(email)})
+		}
+		value, err := asn1.Marshal(names)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, pkix.Extension{Id: oidExtensionSubjectAltName, Value: value})
+	}
+	if tmpl.SubjectSignTool != "" {
+		value, err := asn1.MarshalWithParams(tmpl.SubjectSignTool, "utf8")
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, pkix.Extension{Id: oidExtensionSubjectSignTool, Value: value})
+	}
+	for _, ext := range tmpl.ExtraExtensions {
+		for _, e := range exts {
+			if ext.Id.Equal(e.Id) {
+				return nil, fmt.Errorf("расширение %s задается полями шаблона", ext.Id)
+			}
+		}
+		exts = append(exts, ext)
+	}
+	return exts, nil
+}
+
+// Формирование запроса на сертификат по шаблону tmpl, подписанного приватным ключом субъекта
+// Возвращает запрос в DER, подпись которого проверена
+func (sign *Signer) CreateCertificateRequest(tmpl *CertificateRequestTemplate, privKey *PrivateKey) ([]byte, error) {
+	if privKey.Curve == nil {
+		return nil, fmt.Errorf("приватный ключ не привязан к кривой")
+	}
+	if sign.mode != privKey.Curve.Size() {
+		return nil, fmt.Errorf("режим %d не соответствует ключу %d бит", sign.mode, privKey.Curve.Size())
+	}
+	spki, err := MarshalPKIXPublicKey(privKey.Public().(*PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	subject, err := asn1.Marshal(tmpl.Subject.ToRDNSequence())
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := []requestAttribute{}
+	exts, err := tmpl.extensions()
+	if err != nil {
+		return nil, err
+	}
+	if len(exts) != 0 {
+		value, err := asn1.Marshal(exts)
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, requestAttribute{
+			Type:   oidExtensionRequest,
+			Values: []asn1.RawValue{{FullBytes: value}},
+		})
+	}
+
+	info, err := asn1.Marshal(certificationRequestInfo{
+		Version:    0,
+		Subject:    asn1.RawValue{FullBytes: subject},
+		PublicKey:  asn1.RawValue{FullBytes: spki},
+		Attributes: attributes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sigAlgo, signature, err := sign.signData(info, privKey)
+	if err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(certificationRequest{
+		CertificationRequestInfo: asn1.RawValue{FullBytes: info},
+		SignatureAlgorithm:       sigAlgo,
+		SignatureValue:           signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка сформированного запроса разбором и проверкой подписи
+	csr, err := ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+	return der, nil
+}
+
+// Запрос на сертификат с ключом ГОСТ Р 34.10
+type CertificateRequest struct {
+	// Поля запроса, разобранные crypto/x509
+	// PublicKey и SignatureAlgorithm стандартной библиотеки для ГОСТ не заполняются
+	*x509.CertificateRequest
+	// Публичный ключ субъекта
+	PublicKey *PublicKey
+	// Набор параметров ключа субъекта
+	ParamSet *ParamSet
+	// OID алгоритма подписи запроса
+	SignatureAlgorithmOID asn1.ObjectIdentifier
+
+	// Алгоритм и значение подписи в исходном виде
+	signatureAlgorithm algorithmIdentifier
+	signature          asn1.BitString
+}
+
+// Разбор запроса на сертификат из DER
+// Подпись запроса при разборе не проверяется, для проверки используется CheckSignature
+func ParseCertificateRequest(der []byte) (*CertificateRequest, error) {
+	var raw certificationRequest
+	if rest, err := asn1.Unmarshal(der, &raw); err != nil {
+		return nil, fmt.Errorf("неверная структура запроса на сертификат: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после запроса на сертификат")
+	}
+	xr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ParsePKIXPublicKey(xr.RawSubjectPublicKeyInfo)
+	if err != nil {
+		return nil, fmt.Errorf("ключ субъекта запроса: %w", err)
+	}
+	ps, err := keyParamSet(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateRequest{
+		CertificateRequest:    xr,
+		PublicKey:             pub,
+		ParamSet:              ps,
+		SignatureAlgorithmOID: raw.SignatureAlgorithm.Algorithm,
+		signatureAlgorithm:    raw.SignatureAlgorithm,
+		signature:             raw.SignatureValue,
+	}, nil
+}
+
+// Разбор запроса на сертификат из PEM с заголовком CERTIFICATE REQUEST
+func ParseCertificateRequestPEM(data []byte) (*CertificateRequest, error) {
+	der, err := decodePEM(data, pemCertificateRequest)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCertificateRequest(der)
+}
+
+// Запись запроса на сертификат в PEM с заголовком CERTIFICATE REQUEST
+func (csr *CertificateRequest) MarshalPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemCertificateRequest, Bytes: csr.Raw})
+}
+
+// Проверка подписи запроса ключом субъекта (доказательство владения приватным ключом)
+func (csr *CertificateRequest) CheckSignature() error {
+	sign, err := verifierFor(csr.PublicKey, csr.SignatureAlgorithmOID)
+	if err != nil {
+		return err
+	}
+	if err := sign.verifyData(csr.signatureAlgorithm, csr.RawTBSCertificateRequest, csr.signature, csr.PublicKey); err != nil {
+		return fmt.Errorf("запрос на сертификат %q: %w", csr.Subject, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+)
+
+func TestCertificateRequestRoundTrip(t *testing.T) {
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		pub, priv, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		name, err := ParseDistinguishedName("CN=Иванов Иван,SNILS=11223344595,INN=500100732259,C=RU")
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := sign.CreateCertificateRequest(&CertificateRequestTemplate{
+			Subject:         name,
+			KeyUsage:        x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+			ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+			EmailAddresses:  []string{"ivanov@example.ru"},
+			SubjectSignTool: "СКЗИ \"Тест\"",
+		}, priv)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+
+		csr, err := ParseCertificateRequest(der)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		if csr, err = ParseCertificateRequestPEM(csr.MarshalPEM()); err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		if err := csr.CheckSignature(); err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		if csr.ParamSet != ps || !csr.PublicKey.Equal(pub) || csr.Subject.CommonName != "Иванов Иван" {
+			t.Fatalf("%s: запрос %s с ключом %s", ps.Name, csr.Subject, csr.ParamSet.Name)
+		}
+		if len(csr.EmailAddresses) != 1 || csr.EmailAddresses[0] != "ivanov@example.ru" {
+			t.Fatalf("%s: адреса %v", ps.Name, csr.EmailAddresses)
+		}
+		// keyUsage, extKeyUsage, subjectAltName и subjectSignTool в extensionRequest
+		oids := map[string]bool{}
+		for _, ext := range csr.Extensions {
+			oids[ext.Id.String()] = true
+		}
+		for _, oid := range []string{"2.5.29.15", "2.5.29.37", "2.5.29.17", "1.2.643.100.111"} {
+			if !oids[oid] {
+				t.Fatalf("%s: нет запрошенного расширения %s", ps.Name, oid)
+			}
+		}
+
+		// Измененная подпись не проходит проверку
+		tampered := append([]byte(nil), der...)
+		tampered[len(tampered)-1] ^= 1
+		csr, err = ParseCertificateRequest(tampered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := csr.CheckSignature(); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("%s: измененная подпись: %v", ps.Name, err)
+		}
+	}
+}
+
+func TestCertificateRequestRejects(t *testing.T) {
+	sign := NewSigner(NewCurve256ParamSetA(), 256)
+	_, priv, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := ParseDistinguishedName("CN=Тест")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &CertificateRequestTemplate{Subject: name}
+
+	if _, err := sign.CreateCertificateRequest(tmpl, NewPrivateKey(priv.D)); err == nil {
+		t.Fatal("сформирован запрос ключом без кривой")
+	}
+	if _, err := NewSigner(NewCurve256ParamSetA(), 512).CreateCertificateRequest(tmpl, priv); err == nil {
+		t.Fatal("сформирован запрос в режиме другого размера")
+	}
+	if _, err := sign.CreateCertificateRequest(&CertificateRequestTemplate{Subject: name, EmailAddresses: []string{"почта@example.ru"}}, priv); err == nil {
+		t.Fatal("сформирован запрос с адресом не в ASCII")
+	}
+
+	der, err := sign.CreateCertificateRequest(tmpl, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseCertificateRequest(append(der, 0)); err == nil {
+		t.Fatal("принят запрос с лишними данными")
+	}
+	// Ключ субъекта заменен ключом другой пары
+	other, _, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr.PublicKey = other
+	if err := csr.CheckSignature(); err == nil {
+		t.Fatal("подпись запроса принята чужим ключом")
+	}
+}
@@ -0,0 +1,106 @@
+package utils
+
+// Режим гаммирования с преобразованием ключа CTR-ACPKM
+// Р 1323565.1.017-2018, RFC 8645 https://datatracker.ietf.org/doc/rfc8645/
+// Гаммирование выполняется как в режиме CTR ГОСТ Р 34.13-2015, счетчик CTR = IV || 0...0,
+// после каждой секции из sectionSize байт ключ заменяется на ACPKM(K):
+// K' = MSB_256(E_K(D_1) || ... || E_K(D_J)), D = 80 81 ... 9F
+// Счетчик при смене ключа не сбрасывается
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// Поток гаммы CTR-ACPKM, реализует cipher.Stream
+type ctrACPKM struct {
+	// Конструктор блочного шифра по ключу
+	newBlock func(key []byte) (cipher.Block, error)
+	// Текущий блочный шифр
+	block cipher.Block
+	// Размер секции в байтах
+	sectionSize int
+	// Счетчик
+	ctr []byte
+	// Неиспользованная часть последнего блока гаммы
+	gamma []byte
+	// Число байт гаммы, выработанных на текущем ключе
+	used int
+}
+
+// Проверка соответствия интерфейсу на этапе компиляции
+var _ cipher.Stream = (*ctrACPKM)(nil)
+
+// "Конструктор" для потока CTR-ACPKM
+// iv - половина блока, sectionSize - кратный длине блока размер секции в байтах
+func newCTRACPKM(newBlock func(key []byte) (cipher.Block, error), key, iv []byte, sectionSize int) (*ctrACPKM, error) {
+	block, err := newBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	n := block.BlockSize()
+	if len(iv) != n/2 {
+		return nil, fmt.Errorf("неверная длина синхропосылки: %d, должна быть %d", len(iv), n/2)
+	}
+	if sectionSize <= 0 || sectionSize%n != 0 {
+		return nil, fmt.Errorf("размер секции %d должен быть кратен длине блока %d", sectionSize, n)
+	}
+	ctr := make([]byte, n)
+	copy(ctr, iv)
+	return &ctrACPKM{
+		newBlock:    newBlock,
+		block:       block,
+		sectionSize: sectionSize,
+		ctr:         ctr,
+	}, nil
+}
+
+// Выработка нового ключа ACPKM(K) из текущего
+func (c *ctrACPKM) rekey() error {
+	n := c.block.BlockSize()
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = 0x80 + byte(i)
+	}
+	for i := 0; i < len(key); i += n {
+		c.block.Encrypt(key[i:i+n], key[i:i+n])
+	}
+	block, err := c.newBlock(key)
+	if err != nil {
+		return err
+	}
+	c.block = block
+	c.used = 0
+	return nil
+}
+
+// Наложение гаммы на src, результат записывается в dst
+func (c *ctrACPKM) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("ctrACPKM: длина dst меньше длины src")
+	}
+	n := c.block.BlockSize()
+	for i := range src {
+		if len(c.gamma) == 0 {
+			if c.used == c.sectionSize {
+				if err := c.rekey(); err != nil {
+					panic(err)
+				}
+			}
+			gamma := make([]byte, n)
+			c.block.Encrypt(gamma, c.ctr)
+			c.gamma = gamma
+			c.used += n
+
+			// CTR = CTR + 1 (mod 2^n)
+			for j := n - 1; j >= 0; j-- {
+				c.ctr[j]++
+				if c.ctr[j] != 0 {
+					break
+				}
+			}
+		}
+		dst[i] = src[i] ^ c.gamma[0]
+		c.gamma = c.gamma[1:]
+	}
+}
@@ -1,119 +1,173 @@
 package utils
 
 import (
+	"encoding/asn1"
 	"math/big"
+	"sync"
 )
 
 type Curve struct {
+	// Имя и OID набора параметров, заполняются при получении кривой из реестра
+	// Подробнее в utils/registry.go
+	Name string
+	OID  asn1.ObjectIdentifier
+
 	A *big.Int
 	B *big.Int
 	P *big.Int
 	Q *big.Int
 	X *big.Int
 	Y *big.Int
+
+	// Кофактор m/q, где m - порядок группы точек кривой
+	Cofactor *big.Int
+	// Коэффициенты e, d скрученной кривой Эдвардса eu^2 + v^2 = 1 + du^2v^2,
+	// заданы только для кривых, имеющих такое представление
+	E *big.Int
+	D *big.Int
+
+	// Параметры кривой во внутреннем представлении, вычисляются при первом обращении
+	once  sync.Once
+	arith *curveArith
+}
+
+// Получение параметров кривой во внутреннем представлении
+// Подробнее в utils/point.go и utils/field.go
+func (c *Curve) arithmetic() *curveArith {
+	c.once.Do(func() {
+		c.arith = newCurveArith(c)
+	})
+	return c.arith
 }
 
 // Фунция сложения двух точек
+// Точка на бесконечности представляется парой (0, 0)
 func (c *Curve) Add(p1x, p1y, p2x, p2y *big.Int) (*big.Int, *big.Int) {
-	// Временные переменные
-	tr1, tr2, p3x, p3y := big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)
-	if p1x.Cmp(p2x) == 0 && p1y.Cmp(p2y) == 0 {
-		// Если P1 == P2
-		// x1^2
-		tr1 = new(big.Int).Mul(p1x, p1x)
-		// 3*x1^2
-		tr1 = new(big.Int).Mul(tr1, i3)
-
-		// 3*x1^2 + a
-		tr1 = new(big.Int).Add(tr1, c.A)
-		p3y = new(big.Int).Set(tr1)
-		// 2y1
-		tr2 = new(big.Int).Mul(p1y, i2)
-		// (3*x1^2 + a) / 2y1
-		// ищем обратный элемент чтобы избавиться от деления и гарантировано получить целое число
-		_, rev_tr2, _ := extendedGCD(tr2, c.P)
-		// умножаем на обратный элемент (эквивалент делению)
-		tr1 = new(big.Int).Mul(tr1, rev_tr2)
-
-		// ((3*x1^2 + a) / 2y1)^2
-		tr1 = new(big.Int).Mul(tr1, tr1)
-
-		// 2x1
-		tr2 = new(big.Int).Mul(i2, p1x)
-
-		// x3 = ((3*x1^2 + a) / 2y1)^2 -2x1
-		p3x = new(big.Int).Sub(tr1, tr2)
-		// x3 = ((3*x1^2 + a) / 2y1)^2 -2x1 (mod p)
-		p3x = new(big.Int).Mod(p3x, c.P)
-
-		// y3
-		// (x1 - x3)
-		tr1 = new(big.Int).Sub(p1x, p3x)
-		// (3*x1^2 + a) * (x1 - x3)
-		p3y = new(big.Int).Mul(p3y, tr1)
-		// ((3*x1^2 + a) * (x1 - x3)) / y2
-		p3y = new(big.Int).Mul(p3y, rev_tr2)
-		// y3 = (((3*x1^2 + a) * (x1 - x3)) / y2) - y1
-		p3y = new(big.Int).Sub(p3y, p1y)
-		//  y3 = (((3*x1^2 + a) * (x1 - x3)) / y2) - y1 (mod p)
-		p3y = new(big.Int).Mod(p3y, c.P)
-	} else if p1x.Cmp(new(big.Int).Mul(p2x, iM1)) != 0 && p1y.Cmp(new(big.Int).Mul(p2y, iM1)) != 0 {
-		// y2 - y1
-		tr1 = new(big.Int).Sub(p2y, p1y)
-
-		// x2 - x1
-		tr2 = new(big.Int).Sub(p2x, p1x)
-
-		// (y2 - y1) / (x2 - x1)
-		// ищем обратный элемент чтобы избавиться от деления и гарантировано получить целое число
-		_, rev_tr2, _ := extendedGCD(tr2, c.P)
-		// умножаем на обратный элемент (эквивалент делению)
-		tr1 = new(big.Int).Mul(tr1, rev_tr2)
-		// сохраняем чтобы еще раз не высчитывать для y
-		p3y = new(big.Int).Set(tr1)
-		// ((y2 - y1) / (x2 - x1))^2
-		tr1 = new(big.Int).Mul(tr1, tr1)
-		// ((y2 - y1) / (x2 - x1))^2 - x1
-		p3x = new(big.Int).Sub(tr1, p1x)
-		// x3 = ((y2 - y1) / (x2 - x1))^2 - x1 - x2
-		p3x = new(big.Int).Sub(p3x, p2x)
-		// x3 = ((y2 - y1) / (x2 - x1))^2 - x1 - x2 (mod p)
-		p3x = new(big.Int).Mod(p3x, c.P)
-
-		// y
-		// (x1 - x3)
-		tr1 = new(big.Int).Sub(p1x, p3x)
-		// ((y2 - y1) / (x2 - x1))*(x1 - x3)
-		tr1 = new(big.Int).Mul(p3y, tr1)
-		// y3 = ((y2 - y1) / (x2 - x1))*(x1 - x3) - y1
-		p3y = new(big.Int).Sub(tr1, p1y)
-		// y3 = ((y2 - y1) / (x2 - x1))*(x1 - x3) - y1 (mod p)
-		p3y = new(big.Int).Mod(p3y, c.P)
-	}
+	ca := c.arithmetic()
 
-	return p3x, p3y
+	var p1, p2, p3 point
+	ca.fromAffine(&p1, p1x, p1y)
+	ca.fromAffine(&p2, p2x, p2y)
+	ca.add(&p3, &p1, &p2)
 
+	return ca.toAffine(&p3)
 }
 
 // Умножение точки на число
-// Рекурсивная вариация алгоритма Double-and-add
+// Вычисляется за постоянное время для чисел меньше q
+// Точка на бесконечности представляется парой (0, 0)
 func (c *Curve) Exp(degree, xS, yS *big.Int) (*big.Int, *big.Int) {
-	// Получаем текущее значение числа
-	dg := new(big.Int).Set(degree)
+	ca := c.arithmetic()
+
+	var p, r point
+	ca.fromAffine(&p, xS, yS)
+	ca.scalarMult(&r, ca.scalarBytes(degree), &p)
 
-	// Если число 0, возврат точек
-	if dg.Cmp(i1) == 0 {
-		return xS, yS
+	x, y := ca.toAffine(&r)
+	// Для отрицательного числа результат - противоположная точка
+	if degree.Sign() < 0 && y.Sign() != 0 {
+		y.Sub(c.P, y)
 	}
+	return x, y
+}
 
-	if dg.Bit(0) == 1 {
-		// Если младший бит числа == 1, высчитываем резльтут этой же функции и возвращаем результат сложения начальной точки с ним
-		px, py := c.Exp(new(big.Int).Sub(dg, i1), xS, yS)
-		return c.Add(xS, yS, px, py)
-	} else {
-		// Если младший бит числа == 0, высчитываем резльтут этой же функции и возвращаем результат с удвоеной точкой (2P)
-		dg.Rsh(dg, 1)
-		px, py := c.Add(xS, yS, xS, yS)
-		return c.Exp(dg, px, py)
+// Умножение базовой точки P на число 0 <= k < q по общей таблице кратных P
+func (c *Curve) expBase(k *big.Int) (*big.Int, *big.Int) {
+	ca := c.arithmetic()
+
+	var r point
+	ca.scalarMultTable(&r, ca.scalarBytes(k), &ca.base)
+	return ca.toAffine(&r)
+}
+
+// Вычисление таблицы кратных точки (x, y) для повторных умножений
+func (c *Curve) newTable(x, y *big.Int) *pointTable {
+	ca := c.arithmetic()
+
+	var p point
+	table := new(pointTable)
+	ca.fromAffine(&p, x, y)
+	ca.precompute(table, &p)
+	return table
+}
+
+// Умножение точки, заданной таблицей кратных, на число 0 <= k < q
+func (c *Curve) expTable(k *big.Int, table *pointTable) (*big.Int, *big.Int) {
+	ca := c.arithmetic()
+
+	var r point
+	ca.scalarMultTable(&r, ca.scalarBytes(k), table)
+	return ca.toAffine(&r)
+}
+
+// Получение кофактора кривой
+// Если кофактор не задан, он считается равным 1
+func (c *Curve) cofactor() *big.Int {
+	if c.Cofactor == nil {
+		return i1
 	}
+	return c.Cofactor
+}
+
+// Проверка совпадения параметров двух кривых
+func (c *Curve) equalParams(other *Curve) bool {
+	return c.P.Cmp(other.P) == 0 && c.A.Cmp(other.A) == 0 && c.B.Cmp(other.B) == 0 &&
+		c.Q.Cmp(other.Q) == 0 && c.X.Cmp(other.X) == 0 && c.Y.Cmp(other.Y) == 0
+}
+
+// Проверка совпадения параметров кривых
+// Кривые разных наборов параметров с одинаковыми значениями считаются равными
+func (c *Curve) Equal(other *Curve) bool {
+	return sameCurve(c, other)
+}
+
+// Размер кривой в битах 256/512, он же режим работы Signer
+// Определяется длиной порядка подгруппы q
+func (c *Curve) Size() int {
+	if c.Q.BitLen() <= 256 {
+		return 256
+	}
+	return 512
+}
+
+// Проверка принадлежности точки кривой: y^2 = x^3 + ax + b (mod p)
+// Координаты должны лежать в интервале [0, p)
+func (c *Curve) IsOnCurve(x, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(c.P) >= 0 || y.Sign() < 0 || y.Cmp(c.P) >= 0 {
+		return false
+	}
+
+	// y^2
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, c.P)
+
+	// x^3 + ax + b
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Add(rhs, c.A)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, c.B)
+	rhs.Mod(rhs, c.P)
+
+	return lhs.Cmp(rhs) == 0
+}
+
+// Проверка того, что точка отлична от точки на бесконечности и n·(x, y) = O
+// Результат проверяется в проективных координатах, поэтому исключительный случай
+// полных формул сложения (0 : 0 : 0) не принимается за точку на бесконечности
+func (c *Curve) hasOrder(x, y, n *big.Int) bool {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false
+	}
+	ca := c.arithmetic()
+
+	var p, r point
+	ca.fromAffine(&p, x, y)
+	ca.scalarMult(&r, ca.scalarBytes(n), &p)
+
+	return ca.isIdentity(&r) == 1
+}
+
+// Проверка того, что пара (x, y) обозначает точку на бесконечности
+func isInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
 }
@@ -0,0 +1,180 @@
+package utils
+
+// Загрузка параметров эллиптической кривой из файла
+// Поддерживаются JSON и ASN.1 структура ECParameters (SEC 1, RFC 3279) в DER или PEM
+// Перед использованием параметры проходят проверку ValidateCurve
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// OID prime-field из X9.62
+var oidPrimeField = asn1.ObjectIdentifier{1, 2, 840, 10045, 1, 1}
+
+// Представление кривой в JSON
+// Числа записываются строками в десятичном виде или в шестнадцатеричном с префиксом 0x
+type curveJSON struct {
+	Name     string `json:"name,omitempty"`
+	OID      string `json:"oid,omitempty"`
+	P        string `json:"p"`
+	A        string `json:"a"`
+	B        string `json:"b"`
+	Q        string `json:"q"`
+	Cofactor string `json:"cofactor,omitempty"`
+	X        string `json:"x"`
+	Y        string `json:"y"`
+	E        string `json:"e,omitempty"`
+	D        string `json:"d,omitempty"`
+}
+
+// ASN.1 структура ECParameters
+type ecParameters struct {
+	Version  int
+	FieldID  ecFieldID
+	Curve    ecCurve
+	Base     []byte
+	Order    *big.Int
+	Cofactor *big.Int `asn1:"optional"`
+}
+
+// ASN.1 структура FieldID для простого поля
+type ecFieldID struct {
+	FieldType asn1.ObjectIdentifier
+	Prime     *big.Int
+}
+
+// ASN.1 структура Curve
+type ecCurve struct {
+	A    []byte
+	B    []byte
+	Seed asn1.BitString `asn1:"optional"`
+}
+
+// Загрузка и проверка параметров кривой из файла
+// Формат определяется по содержимому: JSON, PEM (EC PARAMETERS) или DER
+func LoadCurve(path string) (*Curve, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c *Curve
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		c, err = ParseCurveJSON(trimmed)
+	} else if block, _ := pem.Decode(trimmed); block != nil {
+		if block.Type != "EC PARAMETERS" {
+			return nil, fmt.Errorf("неверный тип PEM блока: %s, должен быть EC PARAMETERS", block.Type)
+		}
+		c, err = ParseCurveASN1(block.Bytes)
+	} else {
+		c, err = ParseCurveASN1(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Кривая принимается только после проверки
+	if err := ValidateCurve(c); err != nil {
+		return nil, fmt.Errorf("параметры кривой не прошли проверку: %w", err)
+	}
+	return c, nil
+}
+
+// Разбор параметров кривой из JSON
+// Проверка параметров не выполняется
+func ParseCurveJSON(data []byte) (*Curve, error) {
+	var cj curveJSON
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return nil, fmt.Errorf("невозможно разобрать JSON с параметрами кривой: %w", err)
+	}
+
+	c := &Curve{Name: cj.Name}
+	if cj.OID != "" {
+		oid, ok := parseOID(cj.OID)
+		if !ok {
+			return nil, fmt.Errorf("неверный OID набора параметров: %s", cj.OID)
+		}
+		c.OID = oid
+	}
+	if cj.Cofactor == "" {
+		cj.Cofactor = "1"
+	}
+
+	fields := []struct {
+		name  string
+		value string
+		dst   **big.Int
+	}{
+		{"p", cj.P, &c.P},
+		{"a", cj.A, &c.A},
+		{"b", cj.B, &c.B},
+		{"q", cj.Q, &c.Q},
+		{"cofactor", cj.Cofactor, &c.Cofactor},
+		{"x", cj.X, &c.X},
+		{"y", cj.Y, &c.Y},
+		{"e", cj.E, &c.E},
+		{"d", cj.D, &c.D},
+	}
+	for _, f := range fields {
+		// Параметры формы Эдвардса необязательны
+		if f.value == "" && (f.name == "e" || f.name == "d") {
+			continue
+		}
+		v, ok := new(big.Int).SetString(f.value, 0)
+		if !ok {
+			return nil, fmt.Errorf("невозможно получить параметр %s: должен быть числом в десятичном виде или шестнадцатеричном с префиксом 0x", f.name)
+		}
+		*f.dst = v
+	}
+	return c, nil
+}
+
+// Разбор параметров кривой из DER структуры ECParameters
+// Проверка параметров не выполняется
+func ParseCurveASN1(der []byte) (*Curve, error) {
+	var params ecParameters
+	rest, err := asn1.Unmarshal(der, &params)
+	if err != nil {
+		return nil, fmt.Errorf("невозможно разобрать ASN.1 структуру ECParameters: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после структуры ECParameters")
+	}
+	if params.Version != 1 {
+		return nil, fmt.Errorf("неподдерживаемая версия ECParameters: %d", params.Version)
+	}
+	if !params.FieldID.FieldType.Equal(oidPrimeField) {
+		return nil, fmt.Errorf("поддерживаются только кривые над простым полем")
+	}
+
+	p := params.FieldID.Prime
+	size := (p.BitLen() + 7) / 8
+
+	// Базовая точка в несжатом виде 04 || x || y
+	if len(params.Base) != 1+2*size || params.Base[0] != 4 {
+		return nil, fmt.Errorf("базовая точка должна быть записана в несжатом виде")
+	}
+
+	cofactor := params.Cofactor
+	if cofactor == nil {
+		cofactor = big.NewInt(1)
+	}
+
+	return &Curve{
+		A: new(big.Int).SetBytes(params.Curve.A),
+		B: new(big.Int).SetBytes(params.Curve.B),
+		P: p,
+		Q: params.Order,
+		X: new(big.Int).SetBytes(params.Base[1 : 1+size]),
+		Y: new(big.Int).SetBytes(params.Base[1+size:]),
+
+		Cofactor: cofactor,
+	}, nil
+}
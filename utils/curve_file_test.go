@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadCurve(t *testing.T) {
+	files := []struct {
+		path  string
+		curve *Curve
+	}{
+		// JSON с параметрами формы Эдвардса, числа в десятичном и шестнадцатеричном виде
+		{"testdata/curve-tc26-256-A.json", NewCurve256ParamSetA()},
+		// ECParameters, сформированные openssl asn1parse -genconf и проверенные openssl ecparam -check
+		{"testdata/curve-tc26-512-A.der", NewCurve512ParamSetA()},
+		{"testdata/curve-tc26-512-A.pem", NewCurve512ParamSetA()},
+	}
+	for _, f := range files {
+		c, err := LoadCurve(f.path)
+		if err != nil {
+			t.Fatalf("%s: %v", f.path, err)
+		}
+		if !c.equalParams(f.curve) || c.cofactor().Cmp(f.curve.cofactor()) != 0 {
+			t.Fatalf("%s: параметры не совпадают с ожидаемыми", f.path)
+		}
+		if f.curve.IsEdwards() && (c.E.Cmp(f.curve.E) != 0 || c.D.Cmp(f.curve.D) != 0) {
+			t.Fatalf("%s: параметры формы Эдвардса не совпадают с ожидаемыми", f.path)
+		}
+	}
+
+	c, err := LoadCurve("testdata/curve-tc26-256-A.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "id-tc26-gost-3410-2012-256-paramSetA" || c.OID.String() != "1.2.643.7.1.2.1.1.1" {
+		t.Fatalf("имя %s, OID %s", c.Name, c.OID)
+	}
+}
+
+func TestLoadCurveRejects(t *testing.T) {
+	json, err := os.ReadFile("testdata/curve-tc26-256-A.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := os.ReadFile("testdata/curve-tc26-512-A.der")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemData, err := os.ReadFile("testdata/curve-tc26-512-A.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Сжатая базовая точка: 02 || x вместо 04 || x || y
+	compressed := append([]byte(nil), der...)
+	compressed[223] = 2
+
+	// Версия ECParameters 2
+	version2 := append([]byte(nil), der...)
+	version2[6] = 2
+
+	rejects := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"неверный JSON", []byte(`{"p": 1`), "JSON"},
+		{"число не в десятичном и не в шестнадцатеричном виде", bytes.Replace(json, []byte(`"cofactor": "4"`), []byte(`"cofactor": "four"`), 1), "cofactor"},
+		{"неверный OID", bytes.Replace(json, []byte("1.2.643.7.1.2.1.1.1"), []byte("1.2.x"), 1), "OID"},
+		{"задан только e", bytes.Replace(json, []byte(`"d": "0x`), []byte(`"dd": "0x`), 1), "проверку"},
+		{"точка не на кривой", bytes.Replace(json, []byte("32879423AB1A"), []byte("32879423AB1B"), 1), "проверку"},
+		{"лишние данные после DER", append(append([]byte(nil), der...), 0), "лишние данные"},
+		{"сжатая базовая точка", compressed, "несжатом"},
+		{"версия ECParameters", version2, "версия"},
+		{"неверный тип PEM", bytes.Replace(pemData, []byte("EC PARAMETERS"), []byte("EC PRIVATE KEY"), 2), "EC PARAMETERS"},
+	}
+	dir := t.TempDir()
+	for i, r := range rejects {
+		path := filepath.Join(dir, strings.Repeat("x", i+1))
+		if err := os.WriteFile(path, r.data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadCurve(path); err == nil || !strings.Contains(err.Error(), r.err) {
+			t.Fatalf("%s: %v, ожидалась ошибка с %q", r.name, err, r.err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package utils
+
+// Проверка параметров эллиптической кривой на соответствие требованиям
+// ГОСТ Р 34.10-2012 п.5.2 перед их использованием
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Проверка параметров кривой
+// Возвращает ошибку с описанием первого невыполненного требования
+func ValidateCurve(c *Curve) error {
+	if c.P == nil || c.A == nil || c.B == nil || c.Q == nil || c.X == nil || c.Y == nil {
+		return fmt.Errorf("не заданы все параметры кривой: p, a, b, q, x, y")
+	}
+
+	// p - простое число больше 3, не длиннее 512 бит
+	if c.P.Cmp(i3) <= 0 || c.P.BitLen() > maxLimbs*64 || !c.P.ProbablyPrime(32) {
+		return fmt.Errorf("модуль p должен быть простым числом больше 3 длиной не более 512 бит")
+	}
+
+	// Коэффициенты и координаты базовой точки должны быть вычетами по модулю p
+	for _, v := range []*big.Int{c.A, c.B, c.X, c.Y} {
+		if v.Sign() < 0 || v.Cmp(c.P) >= 0 {
+			return fmt.Errorf("коэффициенты кривой и координаты базовой точки должны лежать в интервале [0, p)")
+		}
+	}
+
+	// 2^254 < q < 2^256 или 2^508 < q < 2^512, q - простое
+	// Для простого q это равносильно длине 255-256 или 509-512 бит
+	qLen := c.Q.BitLen()
+	if !(qLen >= 255 && qLen <= 256) && !(qLen >= 509 && qLen <= 512) {
+		return fmt.Errorf("порядок подгруппы q должен удовлетворять 2^254 < q < 2^256 или 2^508 < q < 2^512")
+	}
+	if !c.Q.ProbablyPrime(32) {
+		return fmt.Errorf("порядок подгруппы q должен быть простым числом")
+	}
+
+	// Кофактор положителен
+	h := c.cofactor()
+	if h.Sign() <= 0 {
+		return fmt.Errorf("кофактор должен быть положительным числом")
+	}
+
+	// Порядок группы m = h·q удовлетворяет теореме Хассе: |m - p - 1| <= 2√p
+	m := new(big.Int).Mul(h, c.Q)
+	diff := new(big.Int).Sub(m, c.P)
+	diff.Sub(diff, i1)
+	diff.Mul(diff, diff)
+	if diff.Cmp(new(big.Int).Lsh(c.P, 2)) > 0 {
+		return fmt.Errorf("порядок группы точек m = %s·q нарушает границу Хассе", h)
+	}
+
+	// Кривая не аномальная: m != p
+	if m.Cmp(c.P) == 0 {
+		return fmt.Errorf("кривая аномальная: порядок группы точек равен p")
+	}
+
+	// Кривая не сингулярная: 4a^3 + 27b^2 != 0 (mod p)
+	a3 := new(big.Int).Exp(c.A, i3, c.P)
+	a3.Mul(a3, big.NewInt(4))
+	b2 := new(big.Int).Mul(c.B, c.B)
+	b2.Mul(b2, big.NewInt(27))
+	disc := new(big.Int).Add(a3, b2)
+	disc.Mod(disc, c.P)
+	if disc.Sign() == 0 {
+		return fmt.Errorf("кривая сингулярная: 4a^3 + 27b^2 = 0 (mod p)")
+	}
+
+	// Инвариант J(E) = 1728·4a^3 / (4a^3 + 27b^2) не равен 0 и 1728
+	j := new(big.Int).Mul(big.NewInt(1728), a3)
+	j.Mul(j, new(big.Int).ModInverse(disc, c.P))
+	j.Mod(j, c.P)
+	if j.Sign() == 0 || j.Cmp(new(big.Int).Mod(big.NewInt(1728), c.P)) == 0 {
+		return fmt.Errorf("инвариант кривой J(E) не должен быть равен 0 или 1728")
+	}
+
+	// Условие MOV: p^t != 1 (mod q) для t = 1..B, B = 31 для 256 бит и 131 для 512 бит
+	bound := 31
+	if c.Size() == 512 {
+		bound = 131
+	}
+	pt := new(big.Int).Mod(c.P, c.Q)
+	pq := new(big.Int).Set(pt)
+	for t := 1; t <= bound; t++ {
+		if pt.Cmp(i1) == 0 {
+			return fmt.Errorf("нарушено условие MOV: p^%d = 1 (mod q)", t)
+		}
+		pt.Mul(pt, pq)
+		pt.Mod(pt, c.Q)
+	}
+
+	// Базовая точка лежит на кривой и имеет порядок q
+	if !c.IsOnCurve(c.X, c.Y) {
+		return fmt.Errorf("базовая точка не лежит на кривой")
+	}
+	if !c.hasOrder(c.X, c.Y, c.Q) {
+		return fmt.Errorf("порядок базовой точки не равен q")
+	}
+
+	// Параметры формы Эдвардса согласованы с формой Вейерштрасса
+	if c.E != nil || c.D != nil {
+		if err := validateEdwards(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Проверка параметров скрученной кривой Эдвардса
+// e·d·(e - d) != 0 и a = s^2 - 3t^2, b = 2t^3 - ts^2 (mod p)
+func validateEdwards(c *Curve) error {
+	if !c.IsEdwards() {
+		return fmt.Errorf("для формы Эдвардса должны быть заданы оба коэффициента e и d")
+	}
+
+	ed := new(big.Int).Mul(c.E, c.D)
+	ed.Mul(ed, new(big.Int).Sub(c.E, c.D))
+	if ed.Mod(ed, c.P).Sign() == 0 {
+		return fmt.Errorf("коэффициенты формы Эдвардса должны удовлетворять e·d·(e - d) != 0")
+	}
+
+	s, t := c.edwardsST()
+	s2 := new(big.Int).Mul(s, s)
+	t2 := new(big.Int).Mul(t, t)
+
+	a := new(big.Int).Sub(s2, new(big.Int).Mul(i3, t2))
+	a.Mod(a, c.P)
+
+	b := new(big.Int).Mul(i2, new(big.Int).Mul(t2, t))
+	b.Sub(b, new(big.Int).Mul(t, s2))
+	b.Mod(b, c.P)
+
+	if a.Cmp(new(big.Int).Mod(c.A, c.P)) != 0 || b.Cmp(new(big.Int).Mod(c.B, c.P)) != 0 {
+		return fmt.Errorf("коэффициенты формы Эдвардса не соответствуют коэффициентам a и b")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// Копия параметров кривой
+func copyCurve(c *Curve) *Curve {
+	return &Curve{
+		P: new(big.Int).Set(c.P),
+		A: new(big.Int).Set(c.A),
+		B: new(big.Int).Set(c.B),
+		Q: new(big.Int).Set(c.Q),
+		X: new(big.Int).Set(c.X),
+		Y: new(big.Int).Set(c.Y),
+
+		Cofactor: c.Cofactor,
+		E:        c.E,
+		D:        c.D,
+	}
+}
+
+func TestValidateCurveParamSets(t *testing.T) {
+	for _, ps := range ParamSets() {
+		if err := ValidateCurve(ps.Curve()); err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+	}
+}
+
+func TestValidateCurveRejects(t *testing.T) {
+	// Кривая с m = 4q = p + 1: p^2 = 1 (mod q), условие MOV нарушено при t = 2
+	// q - наименьшее простое больше 2^255, для которого 4q - 1 тоже простое
+	movQ := new(big.Int).Lsh(i1, 255)
+	movQ.Add(movQ, big.NewInt(59409))
+	movP := new(big.Int).Lsh(movQ, 2)
+	movP.Sub(movP, i1)
+
+	rejects := []struct {
+		name   string
+		curve  *Curve
+		modify func(c *Curve)
+		err    string
+	}{
+		{"не заданы параметры", NewCurve256CryptoProParamSetA(), func(c *Curve) { c.B = nil }, "не заданы"},
+		{"составное p", NewCurve256CryptoProParamSetA(), func(c *Curve) { c.P = big.NewInt(1000003 * 1000033) }, "модуль p"},
+		{"составное q", NewCurve256CryptoProParamSetA(), func(c *Curve) { c.Q.Add(c.Q, i1) }, "простым"},
+		{"длина q", NewCurve256CryptoProParamSetA(), func(c *Curve) { c.Q.Rsh(c.Q, 8) }, "2^254"},
+		{"координата вне [0, p)", NewCurve256CryptoProParamSetA(), func(c *Curve) { c.X.Add(c.X, c.P) }, "[0, p)"},
+		{"нулевой кофактор", NewCurve256ParamSetA(), func(c *Curve) { c.Cofactor = big.NewInt(0) }, "кофактор"},
+		{"граница Хассе", NewCurve256ParamSetA(), func(c *Curve) { c.Cofactor = big.NewInt(8) }, "Хассе"},
+		{"аномальная кривая", NewCurve256CryptoProParamSetA(), func(c *Curve) { c.Q.Set(c.P) }, "аномальная"},
+		// 4a^3 + 27b^2 = 4(-3)^3 + 27·2^2 = 0
+		{"сингулярная кривая", NewCurve256CryptoProParamSetA(), func(c *Curve) {
+			c.A.Sub(c.P, i3)
+			c.B.SetInt64(2)
+		}, "сингулярная"},
+		{"J(E) = 0", NewCurve256CryptoProParamSetA(), func(c *Curve) { c.A.SetInt64(0) }, "J(E)"},
+		{"J(E) = 1728", NewCurve256CryptoProParamSetA(), func(c *Curve) { c.B.SetInt64(0) }, "J(E)"},
+		{"условие MOV", NewCurve256CryptoProParamSetA(), func(c *Curve) {
+			c.P, c.Q, c.Cofactor = movP, movQ, big.NewInt(4)
+			c.A.SetInt64(1)
+			c.B.SetInt64(1)
+			c.X.SetInt64(0)
+			c.Y.SetInt64(1)
+		}, "MOV"},
+		{"базовая точка не на кривой", NewCurve512ParamSetA(), func(c *Curve) { c.Y.Add(c.Y, i1) }, "не лежит"},
+		// G + T, где T - точка четвертого порядка: q·(G + T) = q·T != O
+		{"порядок базовой точки", NewCurve256ParamSetA(), func(c *Curve) {
+			tx, ty, err := c.EdwardsToWeierstrass(big.NewInt(1), big.NewInt(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.X, c.Y = c.Add(c.X, c.Y, tx, ty)
+		}, "порядок базовой точки"},
+		{"задан только e", NewCurve256ParamSetA(), func(c *Curve) { c.D = nil }, "оба коэффициента"},
+		{"e = d", NewCurve256ParamSetA(), func(c *Curve) { c.D = c.E }, "e·d·(e - d)"},
+		{"e, d не соответствуют a, b", NewCurve256ParamSetA(), func(c *Curve) { c.D = new(big.Int).Add(c.D, i1) }, "не соответствуют"},
+	}
+	for _, r := range rejects {
+		c := copyCurve(r.curve)
+		r.modify(c)
+		if err := ValidateCurve(c); err == nil || !strings.Contains(err.Error(), r.err) {
+			t.Fatalf("%s: %v, ожидалась ошибка с %q", r.name, err, r.err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package utils
+
+// Разбор отличительного имени (DN) из строки вида "CN=Тестовый УЦ,O=Организация,C=RU"
+// Атрибуты записываются в порядке их указания в строке, каждый в отдельном RDN
+// Запятая и обратная косая черта в значении экранируются обратной косой чертой
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+)
+
+// Атрибут отличительного имени
+type dnAttribute struct {
+	// OID типа атрибута
+	oid asn1.ObjectIdentifier
+	// Тег ASN.1 строки значения, 0 - PrintableString или UTF8String по содержимому
+	tag int
+	// Проверка значения, например контрольного числа, может быть nil
+	check func(value string) error
+}
+
+// Известные атрибуты отличительного имени по коротким именам
+var dnAttributes = map[string]dnAttribute{
+	"CN":           {asn1.ObjectIdentifier{2, 5, 4, 3}, 0, nil},
+	"SN":           {asn1.ObjectIdentifier{2, 5, 4, 4}, 0, nil},
+	"SERIALNUMBER": {asn1.ObjectIdentifier{2, 5, 4, 5}, asn1.TagPrintableString, nil},
+	"C":            {asn1.ObjectIdentifier{2, 5, 4, 6}, asn1.TagPrintableString, nil},
+	"L":            {asn1.ObjectIdentifier{2, 5, 4, 7}, 0, nil},
+	"ST":           {asn1.ObjectIdentifier{2, 5, 4, 8}, 0, nil},
+	"STREET":       {asn1.ObjectIdentifier{2, 5, 4, 9}, 0, nil},
+	"O":            {asn1.ObjectIdentifier{2, 5, 4, 10}, 0, nil},
+	"OU":           {asn1.ObjectIdentifier{2, 5, 4, 11}, 0, nil},
+	"T":            {asn1.ObjectIdentifier{2, 5, 4, 12}, 0, nil},
+	"GN":           {asn1.ObjectIdentifier{2, 5, 4, 42}, 0, nil},
+	"E":            {asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}, asn1.TagIA5String, nil},
+
+	// Атрибуты, установленные приказом ФСБ России от 27.12.2011 № 795
+	"INN":    {asn1.ObjectIdentifier{1, 2, 643, 3, 131, 1, 1}, asn1.TagNumericString, checkINN},
+	"INNLE":  {asn1.ObjectIdentifier{1, 2, 643, 100, 4}, asn1.TagNumericString, checkINNLE},
+	"OGRN":   {asn1.ObjectIdentifier{1, 2, 643, 100, 1}, asn1.TagNumericString, checkOGRN},
+	"OGRNIP": {asn1.ObjectIdentifier{1, 2, 643, 100, 5}, asn1.TagNumericString, checkOGRNIP},
+	"SNILS":  {asn1.ObjectIdentifier{1, 2, 643, 100, 3}, asn1.TagNumericString, checkSNILS},
+}
+
+// Разбор отличительного имени из строки
+func ParseDistinguishedName(s string) (pkix.Name, error) {
+	var name pkix.Name
+	parts, err := splitDN(s)
+	if err != nil {
+		return name, err
+	}
+	for _, part := range parts {
+		i := strings.IndexByte(part, '=')
+		if i < 0 {
+			return name, fmt.Errorf("атрибут имени %q должен иметь вид ТИП=значение", part)
+		}
+		key, value := strings.ToUpper(strings.TrimSpace(part[:i])), strings.TrimSpace(part[i+1:])
+		attr, ok := dnAttributes[key]
+		if !ok {
+			return name, fmt.Errorf("неизвестный атрибут имени: %s", key)
+		}
+		if value == "" {
+			return name, fmt.Errorf("пустое значение атрибута имени %s", key)
+		}
+		atv, err := attr.value(key, value)
+		if err != nil {
+			return name, err
+		}
+		name.ExtraNames = append(name.ExtraNames, atv)
+	}
+	if len(name.ExtraNames) == 0 {
+		return name, fmt.Errorf("пустое отличительное имя")
+	}
+	return name, nil
+}
+
+// Формирование значения атрибута с нужным типом строки
+func (attr dnAttribute) value(key, value string) (pkix.AttributeTypeAndValue, error) {
+	atv := pkix.AttributeTypeAndValue{Type: attr.oid, Value: value}
+	switch attr.tag {
+	case 0:
+		return atv, nil
+	case asn1.TagPrintableString:
+		if !isPrintableString(value) {
+			return atv, fmt.Errorf("значение атрибута %s должно содержать только латинские буквы, цифры и знаки PrintableString", key)
+		}
+	case asn1.TagIA5String:
+		for _, r := range value {
+			if r > 0x7f {
+				return atv, fmt.Errorf("значение атрибута %s должно содержать только символы ASCII", key)
+			}
+		}
+	case asn1.TagNumericString:
+		if !isDigits(value) {
+			return atv, fmt.Errorf("значение атрибута %s должно содержать только цифры", key)
+		}
+	}
+	if attr.check != nil {
+		if err := attr.check(value); err != nil {
+			return atv, fmt.Errorf("неверное значение атрибута %s: %w", key, err)
+		}
+	}
+	atv.Value = asn1.RawValue{Tag: attr.tag, Bytes: []byte(value)}
+	return atv, nil
+}
+
+// Проверка того, что строка состоит только из цифр
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Контрольное число: сумма цифр с весами по модулю mod, затем по модулю 10
+func checkDigit(digits string, weights []int, mod int) byte {
+	sum := 0
+	for i, w := range weights {
+		sum += int(digits[i]-'0') * w
+	}
+	return byte(sum%mod%10) + '0'
+}
+
+// Проверка ИНН физического лица (12 цифр)
+// ИНН юридического лица допускается в виде 10 цифр с двумя ведущими нулями
+func checkINN(value string) error {
+	if len(value) != 12 {
+		return fmt.Errorf("ИНН должен содержать 12 цифр")
+	}
+	if strings.HasPrefix(value, "00") {
+		return checkINNLE(value[2:])
+	}
+	if value[10] != checkDigit(value, []int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}, 11) ||
+		value[11] != checkDigit(value, []int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}, 11) {
+		return fmt.Errorf("неверное контрольное число ИНН")
+	}
+	return nil
+}
+
+// Проверка ИНН юридического лица (10 цифр)
+func checkINNLE(value string) error {
+	if len(value) != 10 {
+		return fmt.Errorf("ИНН юридического лица должен содержать 10 цифр")
+	}
+	if value[9] != checkDigit(value, []int{2, 4, 10, 3, 5, 9, 4, 6, 8}, 11) {
+		return fmt.Errorf("неверное контрольное число ИНН")
+	}
+	return nil
+}
+
+// Проверка ОГРН (13 цифр)
+func checkOGRN(value string) error {
+	if len(value) != 13 {
+		return fmt.Errorf("ОГРН должен содержать 13 цифр")
+	}
+	if !checkRegistrationNumber(value, 11) {
+		return fmt.Errorf("неверное контрольное число ОГРН")
+	}
+	return nil
+}
+
+// Проверка ОГРНИП (15 цифр)
+func checkOGRNIP(value string) error {
+	if len(value) != 15 {
+		return fmt.Errorf("ОГРНИП должен содержать 15 цифр")
+	}
+	if !checkRegistrationNumber(value, 13) {
+		return fmt.Errorf("неверное контрольное число ОГРНИП")
+	}
+	return nil
+}
+
+// Контрольное число ОГРН и ОГРНИП: остаток от деления числа без последней цифры на mod, по модулю 10
+func checkRegistrationNumber(value string, mod int64) bool {
+	var rem int64
+	for _, r := range value[:len(value)-1] {
+		rem = (rem*10 + int64(r-'0')) % mod
+	}
+	return value[len(value)-1] == byte(rem%10)+'0'
+}
+
+// Проверка СНИЛС: 11 цифр, последние две - контрольное число
+// Контрольное число проверяется для номеров больше 001-001-998
+func checkSNILS(value string) error {
+	if len(value) != 11 {
+		return fmt.Errorf("СНИЛС должен содержать 11 цифр")
+	}
+	if value[:9] <= "001001998" {
+		return nil
+	}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(value[i]-'0') * (9 - i)
+	}
+	sum = sum % 101 % 100
+	if value[9:] != fmt.Sprintf("%02d", sum) {
+		return fmt.Errorf("неверное контрольное число СНИЛС")
+	}
+	return nil
+}
+
+// Проверка допустимости символов PrintableString
+func isPrintableString(s string) bool {
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune(" '()+,-./:=?", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Разбиение строки имени на атрибуты по неэкранированным запятым
+func splitDN(s string) ([]string, error) {
+	var parts []string
+	var cur strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("незавершенное экранирование в конце имени")
+	}
+	if strings.TrimSpace(cur.String()) != "" || len(parts) != 0 {
+		parts = append(parts, cur.String())
+	}
+	return parts, nil
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"strings"
+	"testing"
+)
+
+// Номера с верными контрольными числами и те же номера с ошибкой
+func TestDistinguishedNameNumbers(t *testing.T) {
+	numbers := []struct {
+		attr         string
+		valid, wrong string
+	}{
+		{"INN", "500100732259", "500100732258"},
+		{"INN", "007707083893", "007707083894"},
+		{"INNLE", "7707083893", "7707083894"},
+		{"OGRN", "1027700132195", "1027700132196"},
+		{"OGRNIP", "304500116000157", "304500116000158"},
+		{"SNILS", "11223344595", "11223344596"},
+		// Для номеров до 001-001-998 контрольное число не проверяется
+		{"SNILS", "00100199800", "0010019980"},
+	}
+	for _, n := range numbers {
+		name, err := ParseDistinguishedName(n.attr + "=" + n.valid)
+		if err != nil {
+			t.Fatalf("%s=%s: %v", n.attr, n.valid, err)
+		}
+		atv := name.ExtraNames[0]
+		if !atv.Type.Equal(dnAttributes[n.attr].oid) {
+			t.Fatalf("%s: OID %s", n.attr, atv.Type)
+		}
+		if v, ok := atv.Value.(asn1.RawValue); !ok || v.Tag != asn1.TagNumericString || string(v.Bytes) != n.valid {
+			t.Fatalf("%s: значение %v, ожидалась NumericString %s", n.attr, atv.Value, n.valid)
+		}
+		if _, err := ParseDistinguishedName(n.attr + "=" + n.wrong); err == nil {
+			t.Fatalf("принят неверный номер %s=%s", n.attr, n.wrong)
+		}
+	}
+
+	rejects := []string{
+		"INN=7707083893",
+		"INNLE=500100732259",
+		"OGRN=304500116000157",
+		"OGRNIP=1027700132195",
+		"SNILS=112-233-445 95",
+		"INN=50010073225a",
+	}
+	for _, s := range rejects {
+		if _, err := ParseDistinguishedName(s); err == nil {
+			t.Fatalf("принято имя %s", s)
+		}
+	}
+}
+
+func TestParseDistinguishedName(t *testing.T) {
+	name, err := ParseDistinguishedName(`CN=ООО "Ромашка\, и партнеры", O = Организация\\Отдел ,c=RU,E=user@example.ru,SERIALNUMBER=12-34`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		oid   asn1.ObjectIdentifier
+		tag   int
+		value string
+	}{
+		{asn1.ObjectIdentifier{2, 5, 4, 3}, asn1.TagUTF8String, `ООО "Ромашка, и партнеры"`},
+		{asn1.ObjectIdentifier{2, 5, 4, 10}, asn1.TagUTF8String, `Организация\Отдел`},
+		{asn1.ObjectIdentifier{2, 5, 4, 6}, asn1.TagPrintableString, "RU"},
+		{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}, asn1.TagIA5String, "user@example.ru"},
+		{asn1.ObjectIdentifier{2, 5, 4, 5}, asn1.TagPrintableString, "12-34"},
+	}
+
+	// Атрибуты записываются в порядке указания, каждый в отдельном RDN, с тегом строки по типу атрибута
+	der, err := asn1.Marshal(name.ToRDNSequence())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rdns []asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &rdns); err != nil {
+		t.Fatal(err)
+	}
+	if len(rdns) != len(want) {
+		t.Fatalf("%d RDN, ожидалось %d", len(rdns), len(want))
+	}
+	for i, rdn := range rdns {
+		var set []struct {
+			Type  asn1.ObjectIdentifier
+			Value asn1.RawValue
+		}
+		if _, err := asn1.UnmarshalWithParams(rdn.FullBytes, &set, "set"); err != nil {
+			t.Fatal(err)
+		}
+		if len(set) != 1 || !set[0].Type.Equal(want[i].oid) || set[0].Value.Tag != want[i].tag || string(set[0].Value.Bytes) != want[i].value {
+			t.Fatalf("RDN %d: %s, тег %d, %q", i, set[0].Type, set[0].Value.Tag, set[0].Value.Bytes)
+		}
+	}
+
+	// Имя разбирается crypto/x509 обратно
+	var parsed pkix.RDNSequence
+	if _, err := asn1.Unmarshal(der, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	var back pkix.Name
+	back.FillFromRDNSequence(&parsed)
+	if back.CommonName != want[0].value || len(back.Organization) != 1 || back.Organization[0] != want[1].value {
+		t.Fatalf("разобрано имя %s", back)
+	}
+
+	rejects := map[string]string{
+		"":                      "пустое",
+		"CN":                    "ТИП=значение",
+		"CN=":                   "пустое значение",
+		"X=1":                   "неизвестный атрибут",
+		`CN=Имя\`:               "экранирование",
+		"C=РФ":                  "PrintableString",
+		"E=почта@example.ru":    "ASCII",
+		"SERIALNUMBER=№1":       "PrintableString",
+		"CN=Имя,,O=Организация": "ТИП=значение",
+	}
+	for s, msg := range rejects {
+		if _, err := ParseDistinguishedName(s); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("%q: %v, ожидалась ошибка с %q", s, err, msg)
+		}
+	}
+}
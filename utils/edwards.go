@@ -0,0 +1,121 @@
+package utils
+
+// Бирациональное отображение между кривой в форме Вейерштрасса
+// y^2 = x^3 + ax + b и скрученной кривой Эдвардса eu^2 + v^2 = 1 + du^2v^2
+// Р 1323565.1.024-2019, RFC 7836 п.A.2
+// Вычисления подписи ведутся в форме Вейерштрасса, представление Эдвардса
+// используется для обмена точками с реализациями, работающими в этой форме
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Проверка наличия у кривой представления в форме скрученной кривой Эдвардса
+func (c *Curve) IsEdwards() bool {
+	return c.E != nil && c.D != nil
+}
+
+// Вычисление параметров отображения
+// s = (e - d) / 4, t = (e + d) / 6 (mod p)
+func (c *Curve) edwardsST() (*big.Int, *big.Int) {
+	s := new(big.Int).Sub(c.E, c.D)
+	s.Mul(s, new(big.Int).ModInverse(big.NewInt(4), c.P))
+	s.Mod(s, c.P)
+
+	t := new(big.Int).Add(c.E, c.D)
+	t.Mul(t, new(big.Int).ModInverse(big.NewInt(6), c.P))
+	t.Mod(t, c.P)
+
+	return s, t
+}
+
+// Перевод точки из формы Вейерштрасса в форму Эдвардса
+// u = (x - t) / y, v = (x - t - s) / (x - t + s)
+// Точка на бесконечности (0, 0) переходит в нейтральный элемент (0, 1)
+func (c *Curve) WeierstrassToEdwards(x, y *big.Int) (*big.Int, *big.Int, error) {
+	if !c.IsEdwards() {
+		return nil, nil, fmt.Errorf("кривая не имеет представления в форме Эдвардса")
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return big.NewInt(0), big.NewInt(1), nil
+	}
+
+	s, t := c.edwardsST()
+
+	// x - t
+	xt := new(big.Int).Sub(x, t)
+	xt.Mod(xt, c.P)
+
+	// Точка второго порядка (t, 0) переходит в (0, -1)
+	if y.Sign() == 0 && xt.Sign() == 0 {
+		return big.NewInt(0), new(big.Int).Sub(c.P, i1), nil
+	}
+
+	// x - t + s
+	den := new(big.Int).Add(xt, s)
+	den.Mod(den, c.P)
+	if y.Sign() == 0 || den.Sign() == 0 {
+		return nil, nil, fmt.Errorf("точка является исключительной для отображения в форму Эдвардса")
+	}
+
+	// u = (x - t) / y
+	u := new(big.Int).Mul(xt, new(big.Int).ModInverse(y, c.P))
+	u.Mod(u, c.P)
+
+	// v = (x - t - s) / (x - t + s)
+	v := new(big.Int).Sub(xt, s)
+	v.Mul(v, new(big.Int).ModInverse(den, c.P))
+	v.Mod(v, c.P)
+
+	return u, v, nil
+}
+
+// Перевод точки из формы Эдвардса в форму Вейерштрасса
+// x = s(1 + v) / (1 - v) + t, y = s(1 + v) / ((1 - v)u)
+// Нейтральный элемент (0, 1) переходит в точку на бесконечности (0, 0)
+func (c *Curve) EdwardsToWeierstrass(u, v *big.Int) (*big.Int, *big.Int, error) {
+	if !c.IsEdwards() {
+		return nil, nil, fmt.Errorf("кривая не имеет представления в форме Эдвардса")
+	}
+
+	s, t := c.edwardsST()
+
+	u = new(big.Int).Mod(u, c.P)
+	v = new(big.Int).Mod(v, c.P)
+
+	// 1 - v
+	den := new(big.Int).Sub(i1, v)
+	den.Mod(den, c.P)
+
+	if u.Sign() == 0 {
+		// (0, 1) - нейтральный элемент
+		if den.Sign() == 0 {
+			return big.NewInt(0), big.NewInt(0), nil
+		}
+		// (0, -1) - точка второго порядка
+		if new(big.Int).Add(v, i1).Cmp(c.P) == 0 {
+			return t, big.NewInt(0), nil
+		}
+		return nil, nil, fmt.Errorf("точка является исключительной для отображения в форму Вейерштрасса")
+	}
+	if den.Sign() == 0 {
+		return nil, nil, fmt.Errorf("точка является исключительной для отображения в форму Вейерштрасса")
+	}
+
+	// s(1 + v) / (1 - v)
+	w := new(big.Int).Add(i1, v)
+	w.Mul(w, s)
+	w.Mul(w, new(big.Int).ModInverse(den, c.P))
+	w.Mod(w, c.P)
+
+	// x = s(1 + v) / (1 - v) + t
+	x := new(big.Int).Add(w, t)
+	x.Mod(x, c.P)
+
+	// y = s(1 + v) / ((1 - v)u)
+	y := new(big.Int).Mul(w, new(big.Int).ModInverse(u, c.P))
+	y.Mod(y, c.P)
+
+	return x, y, nil
+}
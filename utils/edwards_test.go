@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// Кривые с представлением в форме скрученной кривой Эдвардса
+func edwardsTestCurves() []struct {
+	name  string
+	curve *Curve
+	mode  int
+} {
+	return []struct {
+		name  string
+		curve *Curve
+		mode  int
+	}{
+		{"id-tc26-gost-3410-2012-256-paramSetA", NewCurve256ParamSetA(), 256},
+		{"id-tc26-gost-3410-2012-512-paramSetC", NewCurve512ParamSetC(), 512},
+	}
+}
+
+// Проверка eu^2 + v^2 = 1 + du^2v^2 (mod p)
+func onEdwardsCurve(c *Curve, u, v *big.Int) bool {
+	u2 := new(big.Int).Mul(u, u)
+	v2 := new(big.Int).Mul(v, v)
+	left := new(big.Int).Mul(c.E, u2)
+	left.Add(left, v2).Mod(left, c.P)
+	right := new(big.Int).Mul(c.D, u2)
+	right.Mul(right, v2).Add(right, i1).Mod(right, c.P)
+	return left.Cmp(right) == 0
+}
+
+// Точка четвертого порядка (1, 0) кривой Эдвардса с e = 1 в форме Вейерштрасса
+func edwardsOrder4Point(t *testing.T, c *Curve) (*big.Int, *big.Int) {
+	t.Helper()
+	x, y, err := c.EdwardsToWeierstrass(big.NewInt(1), big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x, y
+}
+
+func TestEdwardsMapping(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	for _, tc := range edwardsTestCurves() {
+		c := tc.curve
+		if !c.IsEdwards() {
+			t.Fatalf("%s: нет представления Эдвардса", tc.name)
+		}
+
+		points := [][2]*big.Int{{c.X, c.Y}}
+		for i := 0; i < 10; i++ {
+			x, y := c.Exp(new(big.Int).Rand(rnd, c.Q), c.X, c.Y)
+			points = append(points, [2]*big.Int{x, y})
+		}
+		for _, pt := range points {
+			u, v, err := c.WeierstrassToEdwards(pt[0], pt[1])
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if !onEdwardsCurve(c, u, v) {
+				t.Fatalf("%s: (%x, %x) не лежит на кривой Эдвардса", tc.name, u, v)
+			}
+			x, y, err := c.EdwardsToWeierstrass(u, v)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if x.Cmp(pt[0]) != 0 || y.Cmp(pt[1]) != 0 {
+				t.Fatalf("%s: (%x, %x) -> (%x, %x) -> (%x, %x)", tc.name, pt[0], pt[1], u, v, x, y)
+			}
+		}
+
+		// Нейтральный элемент и точки малого порядка
+		special := [][4]*big.Int{
+			{big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(1)},
+			{nil, nil, big.NewInt(0), new(big.Int).Sub(c.P, i1)},
+			{nil, nil, big.NewInt(1), big.NewInt(0)},
+		}
+		for _, sp := range special {
+			x, y, err := c.EdwardsToWeierstrass(sp[2], sp[3])
+			if err != nil {
+				t.Fatalf("%s: (%x, %x): %v", tc.name, sp[2], sp[3], err)
+			}
+			if sp[0] != nil && (x.Cmp(sp[0]) != 0 || y.Cmp(sp[1]) != 0) {
+				t.Fatalf("%s: (%x, %x) -> (%x, %x)", tc.name, sp[2], sp[3], x, y)
+			}
+			u, v, err := c.WeierstrassToEdwards(x, y)
+			if err != nil {
+				t.Fatalf("%s: (%x, %x): %v", tc.name, x, y, err)
+			}
+			if u.Cmp(sp[2]) != 0 || v.Cmp(sp[3]) != 0 {
+				t.Fatalf("%s: (%x, %x) -> (%x, %x) -> (%x, %x)", tc.name, sp[2], sp[3], x, y, u, v)
+			}
+		}
+
+		// Кривая без представления Эдвардса
+		if _, _, err := NewCurve512ParamSetA().WeierstrassToEdwards(c.X, c.Y); err == nil {
+			t.Fatalf("отображение выполнено для кривой без представления Эдвардса")
+		}
+	}
+}
+
+func TestEdwardsSignVerify(t *testing.T) {
+	for _, tc := range edwardsTestCurves() {
+		sign := NewSigner(tc.curve, tc.mode)
+		pub, priv, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := []byte("подпись на кривой Эдвардса")
+		signature, err := sign.SignBytes(message, priv)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if ok, err := sign.VerifySign(message, signature, pub); !ok || err != nil {
+			t.Fatalf("%s: верная подпись отвергнута: %v", tc.name, err)
+		}
+		if ok, _ := sign.VerifySign([]byte("другое сообщение"), signature, pub); ok {
+			t.Fatalf("%s: принята подпись другого сообщения", tc.name)
+		}
+	}
+}
+
+// Число из little-endian шестнадцатеричной строки, в таком виде значения приведены в RFC 7836
+func leHexInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return new(big.Int).SetBytes(reverseBytes(b))
+}
+
+// RFC 7836 п.A.2, id-tc26-gost-3410-12-512-paramSetA
+func TestVKOVectors(t *testing.T) {
+	c := NewCurve512ParamSetA()
+	sign := NewSigner(c, 512)
+	dA := leHexInt(t, "c990ecd972fce84ec4db022778f50fcac726f46708384b8d458304962d7147f8"+
+		"c2db41cef22c90b102f2968404f9b9be6d47c79692d81826b32b8daca43cb667")
+	dB := leHexInt(t, "48c859f7b6f11585887cc05ec6ef1390cfea739b1a18c0d4662293ef63b79e3b"+
+		"8014070b44918590b4b996acfea4edfbbbcccc8c06edd8bf5bda92a51392d0db")
+	ukm := leHexInt(t, "1d80603c8544c727")
+
+	xA, yA := c.Exp(dA, c.X, c.Y)
+	xB, yB := c.Exp(dB, c.X, c.Y)
+	if want := leHexInt(t, "aab0eda4abff21208d18799fb9a8556654ba783070eba10cb9abb253ec56dcf5"+
+		"d3ccba6192e464e6e5bcb6dea137792f2431f6c897eb1b3c0cc14327b1adc0a7"); xA.Cmp(want) != 0 {
+		t.Fatalf("открытый ключ A: x = %x, ожидалось %x", xA, want)
+	}
+
+	vectors := []struct {
+		hashSize int
+		kek      string
+	}{
+		{256, "c9a9a77320e2cc559ed72dce6f47e2192ccea95fa648670582c054c0ef36c221"},
+		{512, "79f002a96940ce7bde3259a52e015297adaad84597a0d205b50e3e1719f97bfa" +
+			"7ee1d2661fa9979a5aa235b558a7e6d9f88f982dd63fc35a8ec0dd5e242d3bdf"},
+	}
+	for _, v := range vectors {
+		kA, err := sign.VKO(NewPrivateKey(dA), NewPublicKey(xB, yB), ukm, v.hashSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kB, err := sign.VKO(NewPrivateKey(dB), NewPublicKey(xA, yA), ukm, v.hashSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(kA) != v.kek || !bytes.Equal(kA, kB) {
+			t.Fatalf("VKO %d: %x и %x, ожидалось %s", v.hashSize, kA, kB, v.kek)
+		}
+	}
+}
+
+// Ключ второй стороны малого порядка или с составляющей малого порядка отвергается
+func TestVKOSmallOrder(t *testing.T) {
+	for _, tc := range edwardsTestCurves() {
+		c := tc.curve
+		sign := NewSigner(c, tc.mode)
+		pubA, privA, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubB, privB, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ukm := big.NewInt(1)
+
+		t4x, t4y := edwardsOrder4Point(t, c)
+		t2x, t2y, err := c.EdwardsToWeierstrass(big.NewInt(0), new(big.Int).Sub(c.P, i1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mx, my := c.Add(pubB.X, pubB.Y, t4x, t4y)
+		for _, small := range [][2]*big.Int{{t4x, t4y}, {t2x, t2y}, {mx, my}} {
+			var keyErr *InvalidPublicKeyError
+			if _, err := sign.VKO(privA, NewPublicKey(small[0], small[1]), ukm, 256); !errors.As(err, &keyErr) {
+				t.Fatalf("%s: ключ (%x, %x): %v, ожидалась ошибка InvalidPublicKeyError", tc.name, small[0], small[1], err)
+			}
+		}
+
+		want, err := sign.VKO(privA, pubB, ukm, 256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := sign.VKO(privB, pubA, ukm, 256); err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("%s: общие ключи сторон не совпадают: %x, %x, %v", tc.name, want, got, err)
+		}
+	}
+}
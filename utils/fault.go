@@ -0,0 +1,30 @@
+package utils
+
+// Защита от атак на сбои при выработке подписи
+// Сбой при вычислении kP или s (например, изменение бита в памяти) может дать
+// неверную подпись, по которой восстанавливается приватный ключ.
+// В режиме проверки после подписи каждая подпись проверяется публичным ключом Q = dP
+// до возврата вызывающему, при расхождении подпись не возвращается
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Выработанная подпись не прошла проверку, возможен сбой при вычислениях
+var ErrSignatureFault = errors.New("выработанная подпись не прошла проверку, подпись не выдана")
+
+// Включение или выключение проверки каждой подписи перед ее возвратом
+func (sign *Signer) SetVerifyAfterSign(verify bool) {
+	sign.verifyAfterSign = verify
+}
+
+// Проверка выработанной пары (r, s) для числа e публичным ключом, вычисленным из d
+func (sign *Signer) checkSignature(e, r, s, d *big.Int) error {
+	x, y := sign.c.Exp(d, sign.c.X, sign.c.Y)
+	ok, err := sign.verifyRS(e, r, s, NewPublicKeyOnCurve(sign.c, x, y))
+	if !ok || err != nil {
+		return ErrSignatureFault
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// Искаженная при выработке подпись не выдается
+func TestCheckSignatureFault(t *testing.T) {
+	ps, err := ParamSetByName("id-tc26-gost-3410-2012-512-paramSetA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := NewSigner(ps.Curve(), ps.HashMode)
+	_, privKey, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := sign.hashToE(sign.Digest([]byte("сбой")))
+	r, s, err := sign.signE(sign.random(), e, privKey.D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sign.checkSignature(e, r, s, privKey.D); err != nil {
+		t.Fatalf("верная подпись отвергнута: %v", err)
+	}
+
+	// Сбой в s, r или e
+	faults := [][3]*big.Int{
+		{e, r, new(big.Int).Xor(s, i1)},
+		{e, new(big.Int).Xor(r, i2), s},
+		{new(big.Int).Add(e, i1), r, s},
+	}
+	for i, f := range faults {
+		if err := sign.checkSignature(f[0], f[1], f[2], privKey.D); !errors.Is(err, ErrSignatureFault) {
+			t.Fatalf("сбой %d: %v, ожидалось %v", i, err, ErrSignatureFault)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package utils
+
+// Арифметика в простом поле GF(p) на машинных словах фиксированной длины
+// Для простых чисел вида 2^(64n) - c (CryptoPro-A, tc26-512-A) и
+// 2^(64n-1) + c (CryptoPro-B, tc26-512-B) используется быстрая редукция,
+// для остальных - умножение Монтгомери.
+// Все операции над элементами поля выполняются за постоянное время
+// и не выделяют память в куче.
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+const (
+	// Максимальное число 64-битных слов в элементе поля (512 бит)
+	maxLimbs = 8
+)
+
+// Способы редукции после умножения
+const (
+	// p = 2^(64n) - c
+	reductionMinus = iota
+	// p = 2^(64n-1) + c
+	reductionPlus
+	// Умножение Монтгомери для произвольного нечетного p
+	reductionMontgomery
+)
+
+// Элемент поля, слова хранятся от младшего к старшему
+type fieldElement [maxLimbs]uint64
+
+// Параметры поля GF(p)
+type field struct {
+	// Модуль p
+	P *big.Int
+	// Модуль p в виде слов
+	p fieldElement
+	// Число используемых слов
+	n int
+	// Способ редукции
+	reduction int
+	// Константа c для быстрой редукции
+	c uint64
+	// -p^(-1) mod 2^64 для редукции Монтгомери
+	m0inv uint64
+	// R^2 mod p, R = 2^(64n), для перевода в представление Монтгомери
+	rr fieldElement
+	// Единица во внутреннем представлении
+	one fieldElement
+	// p - 2, показатель степени для вычисления обратного элемента
+	pMinus2 *big.Int
+}
+
+// "Конструктор" для типа field
+// p должно быть нечетным простым числом длиной не более 512 бит
+func newField(p *big.Int) *field {
+	if p.Sign() <= 0 || p.Bit(0) == 0 || p.BitLen() > maxLimbs*64 {
+		panic("utils: модуль поля должен быть нечетным положительным числом не длиннее 512 бит")
+	}
+
+	f := &field{
+		P:         new(big.Int).Set(p),
+		n:         (p.BitLen() + 63) / 64,
+		reduction: reductionMontgomery,
+		pMinus2:   new(big.Int).Sub(p, i2),
+	}
+	bigToLimbs(&f.p, p, f.n)
+
+	// Определение вида простого числа
+	if p.BitLen() == f.n*64 {
+		// c = 2^(64n) - p
+		c := new(big.Int).Lsh(i1, uint(f.n*64))
+		c.Sub(c, p)
+		// c = p - 2^(64n-1)
+		c2 := new(big.Int).Lsh(i1, uint(f.n*64-1))
+		c2.Sub(p, c2)
+
+		if c.BitLen() <= 32 {
+			f.reduction = reductionMinus
+			f.c = c.Uint64()
+		} else if c2.BitLen() <= 31 {
+			f.reduction = reductionPlus
+			f.c = c2.Uint64()
+		}
+	}
+
+	if f.reduction == reductionMontgomery {
+		// -p^(-1) mod 2^64 методом Ньютона
+		inv := uint64(1)
+		for i := 0; i < 6; i++ {
+			inv *= 2 - f.p[0]*inv
+		}
+		f.m0inv = -inv
+
+		// R^2 mod p
+		rr := new(big.Int).Lsh(i1, uint(2*f.n*64))
+		rr.Mod(rr, p)
+		bigToLimbs(&f.rr, rr, f.n)
+
+		// R mod p
+		r := new(big.Int).Lsh(i1, uint(f.n*64))
+		r.Mod(r, p)
+		bigToLimbs(&f.one, r, f.n)
+	} else {
+		f.one[0] = 1
+	}
+
+	return f
+}
+
+// Перевод неотрицательного числа меньше 2^(64n) в слова
+// Слова берутся напрямую из v.Bits() без промежуточного буфера
+func bigToLimbs(z *fieldElement, v *big.Int, n int) {
+	*z = fieldElement{}
+	for i, w := range v.Bits() {
+		if bits.UintSize == 64 {
+			if i >= n {
+				break
+			}
+			z[i] = uint64(w)
+		} else {
+			if i/2 >= n {
+				break
+			}
+			z[i/2] |= uint64(w) << (32 * (i % 2))
+		}
+	}
+}
+
+// Перевод слов в число
+// Выделяется память только под результат
+func limbsToBig(x *fieldElement, n int) *big.Int {
+	words := make([]big.Word, n*64/bits.UintSize)
+	for i := range words {
+		if bits.UintSize == 64 {
+			words[i] = big.Word(x[i])
+		} else {
+			words[i] = big.Word(x[i/2] >> (32 * (i % 2)))
+		}
+	}
+	return new(big.Int).SetBits(words)
+}
+
+// Запись числа v (mod p) в элемент поля
+// Для 0 <= v < p память не выделяется
+func (f *field) fromBig(z *fieldElement, v *big.Int) {
+	if v.Sign() < 0 || v.Cmp(f.P) >= 0 {
+		v = new(big.Int).Mod(v, f.P)
+	}
+	bigToLimbs(z, v, f.n)
+	if f.reduction == reductionMontgomery {
+		f.montMul(z, z, &f.rr)
+	}
+}
+
+// Получение числа из элемента поля
+func (f *field) toBig(x *fieldElement) *big.Int {
+	if f.reduction == reductionMontgomery {
+		var t, one fieldElement
+		one[0] = 1
+		f.montMul(&t, x, &one)
+		return limbsToBig(&t, f.n)
+	}
+	return limbsToBig(x, f.n)
+}
+
+// Условное вычитание p
+// r - значение длиной n слов, carry - перенос в слово n.
+// Если r + carry·2^(64n) >= p, в z записывается разность, иначе r
+func (f *field) condSubP(z, r *fieldElement, carry uint64) {
+	var s fieldElement
+	var b uint64
+	for i := 0; i < f.n; i++ {
+		s[i], b = bits.Sub64(r[i], f.p[i], b)
+	}
+	// Вычитаем, если был перенос или не было заема
+	mask := -(carry | (b ^ 1))
+	for i := 0; i < f.n; i++ {
+		z[i] = (s[i] & mask) | (r[i] &^ mask)
+	}
+}
+
+// Сложение z = x + y (mod p)
+func (f *field) add(z, x, y *fieldElement) {
+	var r fieldElement
+	var c uint64
+	for i := 0; i < f.n; i++ {
+		r[i], c = bits.Add64(x[i], y[i], c)
+	}
+	f.condSubP(z, &r, c)
+}
+
+// Вычитание z = x - y (mod p)
+func (f *field) sub(z, x, y *fieldElement) {
+	var r fieldElement
+	var b uint64
+	for i := 0; i < f.n; i++ {
+		r[i], b = bits.Sub64(x[i], y[i], b)
+	}
+	// Если был заем, прибавляем p
+	mask := -b
+	var c uint64
+	for i := 0; i < f.n; i++ {
+		z[i], c = bits.Add64(r[i], f.p[i]&mask, c)
+	}
+}
+
+// Противоположный элемент z = -x (mod p)
+func (f *field) neg(z, x *fieldElement) {
+	var zero fieldElement
+	f.sub(z, &zero, x)
+}
+
+// Умножение z = x·y (mod p)
+func (f *field) mul(z, x, y *fieldElement) {
+	if f.reduction == reductionMontgomery {
+		f.montMul(z, x, y)
+		return
+	}
+
+	// Произведение длиной 2n слов
+	var t [2 * maxLimbs]uint64
+	n := f.n
+	for i := 0; i < n; i++ {
+		var carry uint64
+		for j := 0; j < n; j++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			var c uint64
+			lo, c = bits.Add64(lo, t[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			t[i+j] = lo
+			carry = hi
+		}
+		t[i+n] = carry
+	}
+
+	if f.reduction == reductionMinus {
+		f.reduceMinus(z, &t)
+	} else {
+		f.reducePlus(z, &t)
+	}
+}
+
+// Возведение в квадрат z = x^2 (mod p)
+func (f *field) sqr(z, x *fieldElement) {
+	f.mul(z, x, x)
+}
+
+// Редукция для p = 2^(64n) - c
+// T = H·2^(64n) + L ≡ L + c·H (mod p)
+func (f *field) reduceMinus(z *fieldElement, t *[2 * maxLimbs]uint64) {
+	n := f.n
+	var r fieldElement
+	var top, c uint64
+
+	// Первая свертка: r + top·2^(64n) = L + c·H
+	for i := 0; i < n; i++ {
+		hi, lo := bits.Mul64(t[n+i], f.c)
+		lo, c = bits.Add64(lo, t[i], 0)
+		hi += c
+		lo, c = bits.Add64(lo, top, 0)
+		hi += c
+		r[i] = lo
+		top = hi
+	}
+
+	// Вторая свертка: top·2^(64n) ≡ top·c, top·c < 2^64 так как c < 2^32
+	var carry uint64
+	r[0], carry = bits.Add64(r[0], top*f.c, 0)
+	for i := 1; i < n; i++ {
+		r[i], carry = bits.Add64(r[i], 0, carry)
+	}
+
+	// Если снова был перенос, r мало и прибавление c не дает переноса
+	r[0], c = bits.Add64(r[0], carry*f.c, 0)
+	for i := 1; i < n; i++ {
+		r[i], c = bits.Add64(r[i], 0, c)
+	}
+
+	// r < 2^(64n) < 2p
+	f.condSubP(z, &r, 0)
+}
+
+// Редукция для p = 2^(64n-1) + c
+// 2^(64n) = 2p - 2c ≡ -2c (mod p), T = H·2^(64n) + L ≡ L - 2c·H (mod p)
+func (f *field) reducePlus(z *fieldElement, t *[2 * maxLimbs]uint64) {
+	n := f.n
+	c2 := 2 * f.c
+
+	// M = 2c·H, младшие n слов и старшее слово mt
+	var m fieldElement
+	var mt, c uint64
+	for i := 0; i < n; i++ {
+		hi, lo := bits.Mul64(t[n+i], c2)
+		lo, c = bits.Add64(lo, mt, 0)
+		hi += c
+		m[i] = lo
+		mt = hi
+	}
+
+	// L - M = r - (b + mt)·2^(64n) ≡ r + (b + mt)·2c
+	var r fieldElement
+	var b uint64
+	for i := 0; i < n; i++ {
+		r[i], b = bits.Sub64(t[i], m[i], b)
+	}
+
+	// (b + mt)·2c < 2^64 так как 2c < 2^32
+	var carry uint64
+	r[0], carry = bits.Add64(r[0], (b+mt)*c2, 0)
+	for i := 1; i < n; i++ {
+		r[i], carry = bits.Add64(r[i], 0, carry)
+	}
+
+	// Значение r + carry·2^(64n) < 2^(64n) + p, после вычитания p оно меньше 2^(64n) < 2p
+	f.condSubP(&r, &r, carry)
+	f.condSubP(z, &r, 0)
+}
+
+// Умножение Монтгомери z = x·y·R^(-1) (mod p), алгоритм CIOS
+func (f *field) montMul(z, x, y *fieldElement) {
+	var t [maxLimbs + 2]uint64
+	n := f.n
+	for i := 0; i < n; i++ {
+		// t = t + x[i]·y
+		var carry, c uint64
+		for j := 0; j < n; j++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			lo, c = bits.Add64(lo, t[j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			t[j] = lo
+			carry = hi
+		}
+		t[n], c = bits.Add64(t[n], carry, 0)
+		t[n+1] = c
+
+		// t = (t + m·p) / 2^64
+		m := t[0] * f.m0inv
+		hi, lo := bits.Mul64(m, f.p[0])
+		_, c = bits.Add64(lo, t[0], 0)
+		carry = hi + c
+		for j := 1; j < n; j++ {
+			hi, lo = bits.Mul64(m, f.p[j])
+			lo, c = bits.Add64(lo, t[j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			t[j-1] = lo
+			carry = hi
+		}
+		t[n-1], c = bits.Add64(t[n], carry, 0)
+		t[n] = t[n+1] + c
+	}
+
+	// t < 2p
+	var r fieldElement
+	copy(r[:n], t[:n])
+	f.condSubP(z, &r, t[n])
+}
+
+// Обратный элемент z = x^(-1) = x^(p-2) (mod p)
+// Показатель степени открыт, поэтому ветвление по его битам допустимо
+// Для x = 0 результат равен 0
+func (f *field) inv(z, x *fieldElement) {
+	r := f.one
+	base := *x
+	for i := f.pMinus2.BitLen() - 1; i >= 0; i-- {
+		f.sqr(&r, &r)
+		if f.pMinus2.Bit(i) == 1 {
+			f.mul(&r, &r, &base)
+		}
+	}
+	*z = r
+}
+
+// Проверка равенства нулю, возвращает 1 если x = 0
+func (f *field) isZero(x *fieldElement) uint64 {
+	var acc uint64
+	for i := 0; i < f.n; i++ {
+		acc |= x[i]
+	}
+	return 1 ^ ((acc | -acc) >> 63)
+}
+
+// Проверка равенства, возвращает 1 если x = y
+func (f *field) equal(x, y *fieldElement) uint64 {
+	var acc uint64
+	for i := 0; i < f.n; i++ {
+		acc |= x[i] ^ y[i]
+	}
+	return 1 ^ ((acc | -acc) >> 63)
+}
+
+// Условный выбор z = cond ? x : y, cond равно 0 или 1
+func (f *field) selectElement(z, x, y *fieldElement, cond uint64) {
+	mask := -cond
+	for i := 0; i < f.n; i++ {
+		z[i] = (x[i] & mask) | (y[i] &^ mask)
+	}
+}
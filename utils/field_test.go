@@ -0,0 +1,295 @@
+package utils
+
+// Сверка арифметики поля и точек на машинных словах с math/big для всех наборов параметров
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// Число итераций случайных проверок для каждого набора параметров
+const fieldTestIterations = 200
+
+// Случайное число 0 <= v < n
+func randomBelow(rnd *rand.Rand, n *big.Int) *big.Int {
+	return new(big.Int).Rand(rnd, n)
+}
+
+// Граничные значения элементов поля: 0, 1, 2, p - 2, p - 1
+func fieldEdgeValues(p *big.Int) []*big.Int {
+	return []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		new(big.Int).Sub(p, i2),
+		new(big.Int).Sub(p, i1),
+	}
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	for _, ps := range ParamSets() {
+		ps := ps
+		t.Run(ps.Name, func(t *testing.T) {
+			f := newField(ps.Curve().P)
+			p := f.P
+			rnd := rand.New(rand.NewSource(1))
+
+			values := fieldEdgeValues(p)
+			for i := 0; i < fieldTestIterations; i++ {
+				values = append(values, randomBelow(rnd, p))
+			}
+
+			for i, a := range values {
+				b := values[(i*7+3)%len(values)]
+				var x, y, z fieldElement
+				f.fromBig(&x, a)
+				f.fromBig(&y, b)
+
+				if got := f.toBig(&x); got.Cmp(a) != 0 {
+					t.Fatalf("fromBig/toBig(%x) = %x", a, got)
+				}
+
+				want := new(big.Int).Add(a, b)
+				f.add(&z, &x, &y)
+				if got := f.toBig(&z); got.Cmp(want.Mod(want, p)) != 0 {
+					t.Fatalf("%x + %x = %x, ожидалось %x", a, b, got, want)
+				}
+
+				want = new(big.Int).Sub(a, b)
+				f.sub(&z, &x, &y)
+				if got := f.toBig(&z); got.Cmp(want.Mod(want, p)) != 0 {
+					t.Fatalf("%x - %x = %x, ожидалось %x", a, b, got, want)
+				}
+
+				want = new(big.Int).Neg(a)
+				f.neg(&z, &x)
+				if got := f.toBig(&z); got.Cmp(want.Mod(want, p)) != 0 {
+					t.Fatalf("-%x = %x, ожидалось %x", a, got, want)
+				}
+
+				want = new(big.Int).Mul(a, b)
+				f.mul(&z, &x, &y)
+				if got := f.toBig(&z); got.Cmp(want.Mod(want, p)) != 0 {
+					t.Fatalf("%x * %x = %x, ожидалось %x", a, b, got, want)
+				}
+
+				want = new(big.Int).Mul(a, a)
+				f.sqr(&z, &x)
+				if got := f.toBig(&z); got.Cmp(want.Mod(want, p)) != 0 {
+					t.Fatalf("%x ^ 2 = %x, ожидалось %x", a, got, want)
+				}
+
+				// Для 0 обратный элемент не существует, inv возвращает 0
+				want = new(big.Int).ModInverse(a, p)
+				if want == nil {
+					want = big.NewInt(0)
+				}
+				f.inv(&z, &x)
+				if got := f.toBig(&z); got.Cmp(want) != 0 {
+					t.Fatalf("%x ^ -1 = %x, ожидалось %x", a, got, want)
+				}
+
+				if f.equal(&x, &y) != boolToUint64(a.Cmp(b) == 0) {
+					t.Fatalf("equal(%x, %x) неверно", a, b)
+				}
+				if f.isZero(&x) != boolToUint64(a.Sign() == 0) {
+					t.Fatalf("isZero(%x) неверно", a)
+				}
+			}
+		})
+	}
+}
+
+// Редукция произведения длиной 2n слов, включая значения больше p^2
+func TestFieldReduce(t *testing.T) {
+	for _, ps := range ParamSets() {
+		ps := ps
+		t.Run(ps.Name, func(t *testing.T) {
+			f := newField(ps.Curve().P)
+			if f.reduction == reductionMontgomery {
+				t.Skip("редукция Монтгомери проверяется через mul")
+			}
+			p := f.P
+			rnd := rand.New(rand.NewSource(2))
+
+			// Максимальное значение (2^(64n) - 1)^2 и случайные значения до 2^(128n)
+			limit := new(big.Int).Lsh(i1, uint(128*f.n))
+			top := new(big.Int).Lsh(i1, uint(64*f.n))
+			top.Sub(top, i1)
+			values := []*big.Int{big.NewInt(0), new(big.Int).Mul(top, top), new(big.Int).Mul(p, p)}
+			for i := 0; i < fieldTestIterations; i++ {
+				values = append(values, randomBelow(rnd, limit))
+			}
+
+			for _, v := range values {
+				var wide [2 * maxLimbs]uint64
+				buf := v.FillBytes(make([]byte, 16*f.n))
+				for i := 0; i < 2*f.n; i++ {
+					for j := 0; j < 8; j++ {
+						wide[i] |= uint64(buf[len(buf)-1-i*8-j]) << (8 * j)
+					}
+				}
+
+				var z fieldElement
+				if f.reduction == reductionMinus {
+					f.reduceMinus(&z, &wide)
+				} else {
+					f.reducePlus(&z, &wide)
+				}
+				want := new(big.Int).Mod(v, p)
+				if got := limbsToBig(&z, f.n); got.Cmp(want) != 0 {
+					t.Fatalf("%x mod p = %x, ожидалось %x", v, got, want)
+				}
+			}
+		})
+	}
+}
+
+// Сложение точек в аффинных координатах на math/big
+// Точка на бесконечности - (0, 0)
+func refAdd(c *Curve, x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if isInfinity(x1, y1) {
+		return x2, y2
+	}
+	if isInfinity(x2, y2) {
+		return x1, y1
+	}
+	p := c.P
+	var l *big.Int
+	if x1.Cmp(x2) == 0 {
+		if new(big.Int).Add(y1, y2).Mod(new(big.Int).Add(y1, y2), p).Sign() == 0 {
+			return big.NewInt(0), big.NewInt(0)
+		}
+		// l = (3x^2 + a) / 2y
+		num := new(big.Int).Mul(x1, x1)
+		num.Mul(num, i3).Add(num, c.A)
+		den := new(big.Int).Lsh(y1, 1)
+		l = num.Mul(num, den.ModInverse(den, p))
+	} else {
+		// l = (y2 - y1) / (x2 - x1)
+		num := new(big.Int).Sub(y2, y1)
+		den := new(big.Int).Sub(x2, x1)
+		den.Mod(den, p)
+		l = num.Mul(num, den.ModInverse(den, p))
+	}
+	l.Mod(l, p)
+	x3 := new(big.Int).Mul(l, l)
+	x3.Sub(x3, x1).Sub(x3, x2).Mod(x3, p)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, l).Sub(y3, y1).Mod(y3, p)
+	return x3, y3
+}
+
+// Умножение точки на число методом "удвоение-сложение" на math/big
+func refExp(c *Curve, k, x, y *big.Int) (*big.Int, *big.Int) {
+	rx, ry := big.NewInt(0), big.NewInt(0)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		rx, ry = refAdd(c, rx, ry, rx, ry)
+		if k.Bit(i) == 1 {
+			rx, ry = refAdd(c, rx, ry, x, y)
+		}
+	}
+	return rx, ry
+}
+
+func TestScalarMult(t *testing.T) {
+	for _, ps := range ParamSets() {
+		ps := ps
+		t.Run(ps.Name, func(t *testing.T) {
+			c := ps.Curve()
+			rnd := rand.New(rand.NewSource(3))
+
+			scalars := []*big.Int{
+				big.NewInt(0),
+				big.NewInt(1),
+				big.NewInt(2),
+				big.NewInt(15),
+				big.NewInt(16),
+				new(big.Int).Sub(c.Q, i1),
+				new(big.Int).Set(c.Q),
+			}
+			for i := 0; i < fieldTestIterations/10; i++ {
+				scalars = append(scalars, randomBelow(rnd, c.Q))
+			}
+
+			// Произвольная точка подгруппы для проверки умножения не только базовой точки
+			px, py := refExp(c, randomBelow(rnd, c.Q), c.X, c.Y)
+
+			for _, k := range scalars {
+				wantX, wantY := refExp(c, k, c.X, c.Y)
+				if x, y := c.Exp(k, c.X, c.Y); x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+					t.Fatalf("Exp(%x, P) = (%x, %x), ожидалось (%x, %x)", k, x, y, wantX, wantY)
+				}
+				if k.Cmp(c.Q) < 0 {
+					if x, y := c.expBase(k); x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+						t.Fatalf("expBase(%x) = (%x, %x), ожидалось (%x, %x)", k, x, y, wantX, wantY)
+					}
+				}
+
+				wantX, wantY = refExp(c, k, px, py)
+				if x, y := c.Exp(k, px, py); x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+					t.Fatalf("Exp(%x, Q) = (%x, %x), ожидалось (%x, %x)", k, x, y, wantX, wantY)
+				}
+			}
+
+			// Сложение, удвоение и сложение с противоположной точкой
+			nx, ny := px, new(big.Int).Sub(c.P, py)
+			for _, pair := range [][4]*big.Int{
+				{c.X, c.Y, px, py},
+				{px, py, px, py},
+				{px, py, nx, ny},
+				{big.NewInt(0), big.NewInt(0), px, py},
+			} {
+				wantX, wantY := refAdd(c, pair[0], pair[1], pair[2], pair[3])
+				if x, y := c.Add(pair[0], pair[1], pair[2], pair[3]); x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+					t.Fatalf("Add = (%x, %x), ожидалось (%x, %x)", x, y, wantX, wantY)
+				}
+			}
+		})
+	}
+}
+
+// Перевод логического значения в 0 или 1
+func boolToUint64(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Перевод в элемент поля, умножение, возведение в квадрат и редукция не выделяют память
+func TestFieldAllocs(t *testing.T) {
+	for _, ps := range ParamSets() {
+		f := newField(ps.Curve().P)
+		rnd := rand.New(rand.NewSource(4))
+		a, b := randomBelow(rnd, f.P), randomBelow(rnd, f.P)
+		var x, y, z fieldElement
+		var wide [2 * maxLimbs]uint64
+		for i := range wide[:2*f.n] {
+			wide[i] = rnd.Uint64()
+		}
+
+		checks := map[string]func(){
+			"fromBig": func() { f.fromBig(&x, a); f.fromBig(&y, b) },
+			"mul":     func() { f.mul(&z, &x, &y) },
+			"sqr":     func() { f.sqr(&z, &x) },
+			"reduce": func() {
+				w := wide
+				switch f.reduction {
+				case reductionMinus:
+					f.reduceMinus(&z, &w)
+				case reductionPlus:
+					f.reducePlus(&z, &w)
+				default:
+					f.montMul(&z, &x, &y)
+				}
+			},
+		}
+		for name, fn := range checks {
+			if n := testing.AllocsPerRun(100, fn); n != 0 {
+				t.Fatalf("%s: %s выделяет память: %v", ps.Name, name, n)
+			}
+		}
+	}
+}
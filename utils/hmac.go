@@ -0,0 +1,45 @@
+package utils
+
+// HMAC на основе хеш-функции "Стрибог"
+// HMAC_GOSTR3411_2012_256 и HMAC_GOSTR3411_2012_512, Р 50.1.113-2016, RFC 7836 п.4.1
+// Ключ, сообщение и результат - строки байт в порядке RFC 6986
+
+// Вычисление HMAC с длиной хеша hash_size (256/512)
+// HMAC(K, m) = H((K ⊕ opad) || H((K ⊕ ipad) || m)), размер блока 64 байта
+func hmacStreebog(hash_size int, key, data []byte) []byte {
+	// Ключ длиннее блока заменяется его хешем
+	if len(key) > BLOCK_SIZE {
+		key = streebog(hash_size, key)
+	}
+
+	ipad := make([]byte, BLOCK_SIZE, BLOCK_SIZE+len(data))
+	opad := make([]byte, BLOCK_SIZE, BLOCK_SIZE+hash_size/8)
+	copy(ipad, key)
+	copy(opad, key)
+	for i := 0; i < BLOCK_SIZE; i++ {
+		ipad[i] ^= 0x36
+		opad[i] ^= 0x5c
+	}
+
+	inner := streebog(hash_size, append(ipad, data...))
+	return streebog(hash_size, append(opad, inner...))
+}
+
+// Функция диверсификации KDF_TREE_GOSTR3411_2012_256, Р 50.1.113-2016 п.4.5, RFC 7836 п.4.5
+// K(i) = HMAC256(K, [i]_R || label || 0x00 || seed || [L]_b), длина счетчика R = 1 байт,
+// L - длина результата в битах, записывается минимальным числом байт в big-endian
+// length должно быть кратно 32 байтам и не больше 255 * 32
+func kdfTree256(key, label, seed []byte, length int) []byte {
+	bitLen := length * 8
+	var l []byte
+	for v := bitLen; v > 0; v >>= 8 {
+		l = append([]byte{byte(v)}, l...)
+	}
+
+	out := make([]byte, 0, length)
+	for i := 1; len(out) < length; i++ {
+		data := concatBytes([]byte{byte(i)}, label, []byte{0x00}, seed, l)
+		out = append(out, hmacStreebog(256, key, data)...)
+	}
+	return out[:length]
+}
@@ -0,0 +1,183 @@
+package utils
+
+// Тест с известным ответом: контрольные примеры из приложения А ГОСТ Р 34.10-2012
+// А.1 - кривая id-GostR3410-2001-TestParamSet, режим 256
+// А.2 - кривая id-tc26-gost-3410-2012-512-paramSetTest, режим 512
+// Значение k из стандарта подается через источник случайности Signer (SetRand),
+// поэтому проверяется тот же путь выработки подписи, что и в SignBytes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"testing"
+)
+
+// Контрольный пример подписи
+type katVector struct {
+	// Название набора параметров
+	paramSet string
+	// Режим 256/512
+	mode int
+	// Приватный ключ d
+	d string
+	// Публичный ключ Q = (x, y)
+	x, y string
+	// Число e, полученное из хеша сообщения
+	e string
+	// k из примера
+	k string
+	// Ожидаемая подпись
+	r, s string
+	// Хеш сообщения M1 из приложения А ГОСТ Р 34.11-2012 в режиме mode
+	hash string
+}
+
+// Сообщение M1 из приложения А ГОСТ Р 34.11-2012
+const katMessage = "32313039383736353433323130393837363534333231303938373635343332" +
+	"3130393837363534333231303938373635343332313039383736353433323130"
+
+// Контрольные примеры из приложения А
+var katVectors = []katVector{
+	{
+		paramSet: "id-GostR3410-2001-TestParamSet",
+		mode:     256,
+		d:        "7A929ADE789BB9BE10ED359DD39A72C11B60961F49397EEE1D19CE9891EC3B28",
+		x:        "7F2B49E270DB6D90D8595BEC458B50C58585BA1D4E9B788F6689DBD8E56FD80B",
+		y:        "26F1B489D6701DD185C8413A977B3CBBAF64D1C593D26627DFFB101A87FF77DA",
+		e:        "2DFBC1B372D89A1188C09C52E0EEC61FCE52032AB1022E8E67ECE6672B043EE5",
+		k:        "77105C9B20BCD3122823C8CF6FCC7B956DE33814E95B7FE64FED924594DCEAB3",
+		r:        "41AA28D2F1AB148280CD9ED56FEDA41974053554A42767B83AD043FD39DC0493",
+		s:        "01456C64BA4642A1653C235A98A60249BCD6D3F746B631DF928014F6C5BF9C40",
+		hash:     "00557BE5E584FD52A449B16B0251D05D27F94AB76CBAA6DA890B59D8EF1E159D",
+	},
+	{
+		paramSet: "id-tc26-gost-3410-2012-512-paramSetTest",
+		mode:     512,
+		d: "0BA6048AADAE241BA40936D47756D7C93091A0E8514669700EE7508E508B1020" +
+			"72E8123B2200A0563322DAD2827E2714A2636B7BFD18AADFC62967821FA18DD4",
+		x: "115DC5BC96760C7B48598D8AB9E740D4C4A85A65BE33C1815B5C320C854621DD" +
+			"5A515856D13314AF69BC5B924C8B4DDFF75C45415C1D9DD9DD33612CD530EFE1",
+		y: "37C7C90CD40B0F5621DC3AC1B751CFA0E2634FA0503B3D52639F5D7FB72AFD61" +
+			"EA199441D943FFE7F0C70A2759A3CDB84C114E1F9339FDF27F35ECA93677BEEC",
+		e: "3754F3CFACC9E0615C4F4A7C4D8DAB531B09B6F9C170C533A71D147035B0C591" +
+			"7184EE536593F4414339976C647C5D5A407ADEDB1D560C4FC6777D2972075B8C",
+		k: "0359E7F4B1410FEACC570456C6801496946312120B39D019D455986E364F3658" +
+			"86748ED7A44B3E794434006011842286212273A6D14CF70EA3AF71BB1AE679F1",
+		r: "2F86FA60A081091A23DD795E1E3C689EE512A3C82EE0DCC2643C78EEA8FCACD3" +
+			"5492558486B20F1C9EC197C90699850260C93BCBCD9C5C3317E19344E173AE36",
+		s: "1081B394696FFE8E6585E7A9362D26B6325F56778AADBC081C0BFBE933D52FF5" +
+			"823CE288E8C4F362526080DF7F70CE406A6EEB1F56919CB92A9853BDE73E5B4A",
+		hash: "486F64C1917879417FEF082B3381A4E211C324F074654C38823A7B76F830AD00" +
+			"FA1FBAE42B1285C0352F227524BC9AB16254288DD6863DCCD5B9F54A1AD0541B",
+	},
+}
+
+// Число из шестнадцатеричной строки контрольного примера
+func katInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		t.Fatalf("неверное число в контрольном примере: %s", s)
+	}
+	return v
+}
+
+// Байты из шестнадцатеричной строки контрольного примера
+func katBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("неверные данные в контрольном примере: %s", s)
+	}
+	return b
+}
+
+// Источник "случайности", выдающий k контрольного примера
+// signE считывает 64 байта и берет их по модулю q, k < q поэтому сохраняется
+func katReader(k *big.Int) io.Reader {
+	return bytes.NewReader(k.FillBytes(make([]byte, 64)))
+}
+
+// Подготовка Signer и ключей контрольного примера с проверкой Q = dP
+func katSetup(t *testing.T, v katVector) (*Signer, *PrivateKey, *PublicKey) {
+	t.Helper()
+	ps, err := ParamSetByName(v.paramSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := NewSigner(ps.Curve(), v.mode)
+	sign.SetVerifyAfterSign(true)
+	d := katInt(t, v.d)
+	x, y := sign.c.Exp(d, sign.c.X, sign.c.Y)
+	if x.Cmp(katInt(t, v.x)) != 0 || y.Cmp(katInt(t, v.y)) != 0 {
+		t.Fatal("публичный ключ не совпадает с контрольным примером")
+	}
+	return sign, NewPrivateKeyOnCurve(sign.c, d), NewPublicKeyOnCurve(sign.c, x, y)
+}
+
+// Подпись хеша, которому соответствует число e из приложения А
+func TestKATDigest(t *testing.T) {
+	for _, v := range katVectors {
+		v := v
+		t.Run(v.paramSet, func(t *testing.T) {
+			sign, privKey, pubKey := katSetup(t, v)
+			// e < 2^mode, поэтому хеш с α = e - это e в mode/8 байтах
+			digest := katInt(t, v.e).FillBytes(make([]byte, v.mode/8))
+			want := sign.EncodeSignature(katInt(t, v.r), katInt(t, v.s))
+
+			sign.SetRand(katReader(katInt(t, v.k)))
+			signature, err := sign.SignDigest(digest, privKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(signature, want) {
+				t.Fatalf("подпись %x не совпадает с контрольным примером %x", signature, want)
+			}
+			if ok, err := sign.VerifyDigest(digest, want, pubKey); !ok {
+				t.Fatalf("подпись контрольного примера не прошла проверку: %v", err)
+			}
+		})
+	}
+}
+
+// Подпись сообщения M1 из ГОСТ Р 34.11-2012 ключом и k из приложения А ГОСТ Р 34.10-2012
+// Проверяются хеш по контрольному примеру ГОСТ Р 34.11-2012, переход от хеша к e и подпись:
+// r = (kP).x mod q не зависит от сообщения и совпадает с примером, s = (rd + ke) mod q
+func TestKATMessage(t *testing.T) {
+	message := katBytes(t, katMessage)
+	for _, v := range katVectors {
+		v := v
+		t.Run(v.paramSet, func(t *testing.T) {
+			sign, privKey, pubKey := katSetup(t, v)
+			q := sign.c.Q
+
+			if digest := sign.Digest(message); !bytes.Equal(digest, katBytes(t, v.hash)) {
+				t.Fatalf("хеш сообщения %x не совпадает с контрольным примером %s", digest, v.hash)
+			}
+
+			// e = α mod q, если e = 0, то e = 1
+			e := new(big.Int).Mod(katInt(t, v.hash), q)
+			if e.Sign() == 0 {
+				e.SetInt64(1)
+			}
+			r, k := katInt(t, v.r), katInt(t, v.k)
+			s := new(big.Int).Mul(r, privKey.D)
+			s.Add(s, new(big.Int).Mul(k, e)).Mod(s, q)
+			want := sign.EncodeSignature(r, s)
+
+			sign.SetRand(katReader(k))
+			signature, err := sign.SignBytes(message, privKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(signature, want) {
+				t.Fatalf("подпись %x, ожидалось %x", signature, want)
+			}
+			if ok, err := sign.VerifySign(message, signature, pubKey); !ok {
+				t.Fatalf("подпись не прошла проверку: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package utils
+
+// Самоописывающий формат файла ключа
+// Файл - PEM блок с заголовками:
+//   Version: версия формата
+//   Param-Set: OID набора параметров кривой
+//   Param-Set-Name: имя набора параметров, справочно
+//   Key-Id: идентификатор ключа в hex, подробнее в PublicKey.KeyID
+//   Created: время создания ключа в RFC 3339
+// Содержимое блока:
+// - GOST PUBLIC KEY: SubjectPublicKeyInfo DER
+// - GOST PRIVATE KEY: PrivateKeyInfo PKCS#8 DER
+// - GOST ENCRYPTED PRIVATE KEY: EncryptedPrivateKeyInfo DER
+// Заголовки позволяют выбрать кривую и проверить ключ без расшифрования
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Текущая версия формата файла ключа
+const KeyFileVersion = 1
+
+const (
+	// Типы PEM блоков файла ключа
+	pemKeyFilePublic           = "GOST PUBLIC KEY"
+	pemKeyFilePrivate          = "GOST PRIVATE KEY"
+	pemKeyFileEncryptedPrivate = "GOST ENCRYPTED PRIVATE KEY"
+
+	// Заголовки
+	keyFileHeaderVersion      = "Version"
+	keyFileHeaderParamSet     = "Param-Set"
+	keyFileHeaderParamSetName = "Param-Set-Name"
+	keyFileHeaderKeyID        = "Key-Id"
+	keyFileHeaderCreated      = "Created"
+)
+
+// Длина идентификатора ключа в байтах
+const keyIDSize = 20
+
+// Разобранный файл ключа
+type KeyFile struct {
+	// Версия формата
+	Version int
+	// Набор параметров кривой
+	ParamSet *ParamSet
+	// Идентификатор ключа
+	KeyID []byte
+	// Время создания ключа
+	Created time.Time
+	// Публичный ключ, для файла публичного ключа
+	PublicKey *PublicKey
+	// Приватный ключ, для файла открытого приватного ключа
+	PrivateKey *PrivateKey
+
+	// EncryptedPrivateKeyInfo DER, для файла зашифрованного приватного ключа
+	encrypted []byte
+}
+
+// Идентификатор публичного ключа: первые 160 бит хеша Стрибог-256
+// от значения ключа в SubjectPublicKeyInfo (по аналогии со способом 1 RFC 7093)
+func (pub *PublicKey) KeyID() ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+	return streebog(256, spki.PublicKey.RightAlign())[:keyIDSize], nil
+}
+
+// Формирование PEM блока файла ключа с заголовками
+func keyFileBlock(blockType string, c *Curve, keyID []byte, created time.Time, der []byte) ([]byte, error) {
+	ps, err := keyParamSet(c)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type: blockType,
+		Headers: map[string]string{
+			keyFileHeaderVersion:      strconv.Itoa(KeyFileVersion),
+			keyFileHeaderParamSet:     ps.OID.String(),
+			keyFileHeaderParamSetName: ps.Name,
+			keyFileHeaderKeyID:        hex.EncodeToString(keyID),
+			keyFileHeaderCreated:      created.UTC().Format(time.RFC3339),
+		},
+		Bytes: der,
+	}), nil
+}
+
+// Идентификатор ключа для приватного ключа, вычисляется по публичному ключу
+func privateKeyID(priv *PrivateKey) ([]byte, error) {
+	if priv.Curve == nil {
+		return nil, fmt.Errorf("приватный ключ не привязан к кривой")
+	}
+	return priv.Public().(*PublicKey).KeyID()
+}
+
+// Запись публичного ключа в формате файла ключа
+func MarshalPublicKeyFile(pub *PublicKey, created time.Time) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := pub.KeyID()
+	if err != nil {
+		return nil, err
+	}
+	return keyFileBlock(pemKeyFilePublic, pub.Curve, keyID, created, der)
+}
+
+// Запись приватного ключа в формате файла ключа без шифрования
+func MarshalPrivateKeyFile(priv *PrivateKey, created time.Time) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := privateKeyID(priv)
+	if err != nil {
+		return nil, err
+	}
+	return keyFileBlock(pemKeyFilePrivate, priv.Curve, keyID, created, der)
+}
+
+// Запись приватного ключа в формате файла ключа с шифрованием паролем
+// Подробнее о шифровании в utils/pkcs8_encrypted.go
+func MarshalEncryptedPrivateKeyFile(rnd io.Reader, priv *PrivateKey, password []byte, pc PBECipher, created time.Time) ([]byte, error) {
+	der, err := EncryptPKCS8PrivateKey(rnd, priv, password, pc)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := privateKeyID(priv)
+	if err != nil {
+		return nil, err
+	}
+	return keyFileBlock(pemKeyFileEncryptedPrivate, priv.Curve, keyID, created, der)
+}
+
+// Проверка того, что данные являются файлом ключа в самоописывающем формате
+func IsKeyFile(data []byte) bool {
+	block, _ := pem.Decode(bytes.TrimSpace(data))
+	if block == nil {
+		return false
+	}
+	switch block.Type {
+	case pemKeyFilePublic, pemKeyFilePrivate, pemKeyFileEncryptedPrivate:
+		return true
+	}
+	return false
+}
+
+// Разбор файла ключа
+// Для публичного и открытого приватного ключа проверяется соответствие
+// набора параметров и идентификатора ключа заголовкам
+func ParseKeyFile(data []byte) (*KeyFile, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(data))
+	if block == nil {
+		return nil, fmt.Errorf("файл ключа не содержит PEM блок")
+	}
+
+	kf := &KeyFile{}
+	var err error
+	if kf.Version, err = strconv.Atoi(block.Headers[keyFileHeaderVersion]); err != nil {
+		return nil, fmt.Errorf("неверная версия формата файла ключа: %q", block.Headers[keyFileHeaderVersion])
+	}
+	if kf.Version < 1 || kf.Version > KeyFileVersion {
+		return nil, fmt.Errorf("неподдерживаемая версия формата файла ключа: %d", kf.Version)
+	}
+	oid, ok := parseOID(block.Headers[keyFileHeaderParamSet])
+	if !ok {
+		return nil, fmt.Errorf("неверный OID набора параметров в файле ключа: %q", block.Headers[keyFileHeaderParamSet])
+	}
+	if kf.ParamSet, err = ParamSetByOID(oid); err != nil {
+		return nil, err
+	}
+	if kf.KeyID, err = hex.DecodeString(block.Headers[keyFileHeaderKeyID]); err != nil || len(kf.KeyID) == 0 {
+		return nil, fmt.Errorf("неверный идентификатор ключа в файле ключа: %q", block.Headers[keyFileHeaderKeyID])
+	}
+	if kf.Created, err = time.Parse(time.RFC3339, block.Headers[keyFileHeaderCreated]); err != nil {
+		return nil, fmt.Errorf("неверное время создания ключа в файле ключа: %q", block.Headers[keyFileHeaderCreated])
+	}
+
+	switch block.Type {
+	case pemKeyFilePublic:
+		if kf.PublicKey, err = ParsePKIXPublicKey(block.Bytes); err != nil {
+			return nil, err
+		}
+		if err := kf.check(kf.PublicKey); err != nil {
+			return nil, err
+		}
+	case pemKeyFilePrivate:
+		if kf.PrivateKey, err = ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return nil, err
+		}
+		if err := kf.check(kf.PrivateKey.Public().(*PublicKey)); err != nil {
+			return nil, err
+		}
+	case pemKeyFileEncryptedPrivate:
+		kf.encrypted = block.Bytes
+	default:
+		return nil, fmt.Errorf("неизвестный тип файла ключа: %s", block.Type)
+	}
+	return kf, nil
+}
+
+// Проверка соответствия ключа заголовкам файла
+func (kf *KeyFile) check(pub *PublicKey) error {
+	if !pub.Curve.OID.Equal(kf.ParamSet.OID) {
+		return fmt.Errorf("набор параметров ключа %s не соответствует заголовку файла %s", pub.Curve.Name, kf.ParamSet.Name)
+	}
+	keyID, err := pub.KeyID()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(keyID, kf.KeyID) {
+		return fmt.Errorf("идентификатор ключа не соответствует заголовку файла")
+	}
+	return nil
+}
+
+// Проверка того, что файл содержит зашифрованный приватный ключ
+func (kf *KeyFile) IsEncrypted() bool {
+	return kf.encrypted != nil
+}
+
+// Расшифрование приватного ключа из файла
+// Для незашифрованного файла возвращает приватный ключ без изменений
+func (kf *KeyFile) Decrypt(password []byte) (*PrivateKey, error) {
+	if !kf.IsEncrypted() {
+		if kf.PrivateKey == nil {
+			return nil, fmt.Errorf("файл не содержит приватный ключ")
+		}
+		return kf.PrivateKey, nil
+	}
+	priv, err := DecryptPKCS8PrivateKey(kf.encrypted, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := kf.check(priv.Public().(*PublicKey)); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Изменение PEM блока файла ключа
+func rewriteKeyFile(t *testing.T, data []byte, modify func(block *pem.Block)) []byte {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("файл ключа не содержит PEM блок")
+	}
+	modify(block)
+	return pem.EncodeToMemory(block)
+}
+
+func TestKeyFileRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		pub, priv, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyID, err := pub.KeyID()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pubData, err := MarshalPublicKeyFile(pub, created)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		privData, err := MarshalPrivateKeyFile(priv, created)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+
+		for name, data := range map[string][]byte{"публичный": pubData, "приватный": privData} {
+			if !IsKeyFile(data) {
+				t.Fatalf("%s: %s ключ не распознан как файл ключа", ps.Name, name)
+			}
+			kf, err := ParseKeyFile(data)
+			if err != nil {
+				t.Fatalf("%s: %s ключ: %v", ps.Name, name, err)
+			}
+			// Кривая определяется по заголовку файла
+			if kf.ParamSet != ps || kf.Version != KeyFileVersion || !kf.Created.Equal(created) || !bytes.Equal(kf.KeyID, keyID) {
+				t.Fatalf("%s: %s ключ: заголовки %s, %d, %s, %x", ps.Name, name, kf.ParamSet.Name, kf.Version, kf.Created, kf.KeyID)
+			}
+			if kf.IsEncrypted() {
+				t.Fatalf("%s: %s ключ считается зашифрованным", ps.Name, name)
+			}
+			if kf.PublicKey != nil && !kf.PublicKey.Equal(pub) {
+				t.Fatalf("%s: публичный ключ не совпадает после разбора", ps.Name)
+			}
+			if kf.PrivateKey != nil && !kf.PrivateKey.Equal(priv) {
+				t.Fatalf("%s: приватный ключ не совпадает после разбора", ps.Name)
+			}
+		}
+		kf, err := ParseKeyFile(pubData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := kf.Decrypt(nil); err == nil {
+			t.Fatalf("%s: получен приватный ключ из файла публичного ключа", ps.Name)
+		}
+	}
+}
+
+func TestKeyFileEncrypted(t *testing.T) {
+	ps, err := ParamSetByName("id-tc26-gost-3410-2012-256-paramSetA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := NewSigner(ps.Curve(), ps.HashMode)
+	_, priv, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	password := []byte("пароль")
+	data, err := MarshalEncryptedPrivateKeyFile(nil, priv, password, PBEMagma, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kf, err := ParseKeyFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Набор параметров известен без расшифрования
+	if !kf.IsEncrypted() || kf.ParamSet != ps || kf.PrivateKey != nil {
+		t.Fatalf("заголовки зашифрованного ключа: %t, %s", kf.IsEncrypted(), kf.ParamSet.Name)
+	}
+	got, err := kf.Decrypt(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(priv) {
+		t.Fatal("приватный ключ не совпадает после расшифрования")
+	}
+	if _, err := kf.Decrypt([]byte("пароль1")); err == nil {
+		t.Fatal("ключ расшифрован неверным паролем")
+	}
+
+	// Идентификатор ключа проверяется после расшифрования
+	other := rewriteKeyFile(t, data, func(b *pem.Block) { b.Headers["Key-Id"] = strings.Repeat("00", keyIDSize) })
+	if kf, err := ParseKeyFile(other); err != nil {
+		t.Fatal(err)
+	} else if _, err := kf.Decrypt(password); err == nil {
+		t.Fatal("принят зашифрованный ключ с чужим идентификатором")
+	}
+}
+
+func TestKeyFileRejects(t *testing.T) {
+	ps, err := ParamSetByName("id-tc26-gost-3410-2012-512-paramSetC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := NewSigner(ps.Curve(), ps.HashMode)
+	pub, priv, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubData, err := MarshalPublicKeyFile(pub, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	privData, err := MarshalPrivateKeyFile(priv, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := func(name, value string) func(b *pem.Block) {
+		return func(b *pem.Block) { b.Headers[name] = value }
+	}
+	rejects := []struct {
+		name   string
+		data   []byte
+		modify func(b *pem.Block)
+		err    string
+	}{
+		{"следующая версия формата", pubData, header("Version", "2"), "версия"},
+		{"нулевая версия формата", privData, header("Version", "0"), "версия"},
+		{"версия не число", pubData, header("Version", "v1"), "версия"},
+		{"нет версии", pubData, func(b *pem.Block) { delete(b.Headers, "Version") }, "версия"},
+		{"неизвестный OID", pubData, header("Param-Set", "1.2.643.7.1.2.1.2.9"), "1.2.643.7.1.2.1.2.9"},
+		{"неверный OID", privData, header("Param-Set", "1.2.x"), "OID"},
+		{"OID другого набора", pubData, header("Param-Set", "1.2.643.7.1.2.1.2.1"), "не соответствует"},
+		{"чужой идентификатор ключа", privData, header("Key-Id", strings.Repeat("ab", keyIDSize)), "идентификатор"},
+		{"неверный идентификатор ключа", pubData, header("Key-Id", "xyz"), "идентификатор"},
+		{"неверное время создания", pubData, header("Created", "вчера"), "время"},
+		{"неизвестный тип", pubData, func(b *pem.Block) { b.Type = "GOST SECRET KEY" }, "тип"},
+	}
+	for _, r := range rejects {
+		data := rewriteKeyFile(t, r.data, r.modify)
+		if _, err := ParseKeyFile(data); err == nil || !strings.Contains(err.Error(), r.err) {
+			t.Fatalf("%s: %v, ожидалась ошибка с %q", r.name, err, r.err)
+		}
+	}
+
+	if _, err := ParseKeyFile([]byte("12345\n")); err == nil {
+		t.Fatal("принят файл без PEM блока")
+	}
+	if IsKeyFile([]byte("12345\n")) || IsKeyFile(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY"})) {
+		t.Fatal("файл другого формата распознан как файл ключа")
+	}
+	if _, err := MarshalPublicKeyFile(NewPublicKey(pub.X, pub.Y), time.Now()); err == nil {
+		t.Fatal("записан ключ без кривой")
+	}
+}
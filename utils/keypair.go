@@ -0,0 +1,36 @@
+package utils
+
+// Проверка соответствия приватного и публичного ключей
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Публичный ключ не соответствует приватному
+var ErrKeyPairMismatch = errors.New("публичный ключ не соответствует приватному ключу")
+
+// Проверка ключевой пары пользователя для кривой Signer:
+// - 0 < d < q
+// - публичный ключ проходит ValidatePublicKey
+// - Q = dP
+func (sign *Signer) CheckKeyPair(pubKey *PublicKey, privKey *PrivateKey) error {
+	if privKey == nil || privKey.D == nil {
+		return fmt.Errorf("приватный ключ не задан")
+	}
+	if privKey.Curve != nil && !sameCurve(sign.c, privKey.Curve) {
+		return fmt.Errorf("приватный ключ относится к другой кривой")
+	}
+	if privKey.D.Sign() <= 0 || privKey.D.Cmp(sign.c.Q) >= 0 {
+		return fmt.Errorf("приватный ключ должен удовлетворять 0 < d < q")
+	}
+	if err := sign.ValidatePublicKey(pubKey); err != nil {
+		return err
+	}
+
+	x, y := sign.c.Exp(privKey.D, sign.c.X, sign.c.Y)
+	if x.Cmp(pubKey.X) != 0 || y.Cmp(pubKey.Y) != 0 {
+		return ErrKeyPairMismatch
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestCheckKeyPair(t *testing.T) {
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		pubKey, privKey, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sign.CheckKeyPair(pubKey, privKey); err != nil {
+			t.Fatalf("%s: верная пара отвергнута: %v", ps.Name, err)
+		}
+
+		otherPub, otherPriv, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sign.CheckKeyPair(otherPub, privKey); !errors.Is(err, ErrKeyPairMismatch) {
+			t.Fatalf("%s: чужой публичный ключ: %v, ожидалась ошибка ErrKeyPairMismatch", ps.Name, err)
+		}
+		if err := sign.CheckKeyPair(pubKey, otherPriv); !errors.Is(err, ErrKeyPairMismatch) {
+			t.Fatalf("%s: чужой приватный ключ: %v, ожидалась ошибка ErrKeyPairMismatch", ps.Name, err)
+		}
+	}
+}
+
+func TestCheckKeyPairRejects(t *testing.T) {
+	c := NewCurve256ParamSetA()
+	sign := NewSigner(c, 256)
+	pubKey, privKey, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rejects := []struct {
+		name string
+		pub  *PublicKey
+		priv *PrivateKey
+		err  string
+	}{
+		{"приватный ключ не задан", pubKey, nil, "не задан"},
+		{"d = 0", pubKey, NewPrivateKeyOnCurve(c, big.NewInt(0)), "0 < d < q"},
+		{"d = q", pubKey, NewPrivateKeyOnCurve(c, new(big.Int).Set(c.Q)), "0 < d < q"},
+		{"ключ другой кривой", pubKey, NewPrivateKeyOnCurve(NewCurve512ParamSetA(), privKey.D), "другой кривой"},
+		{"публичный ключ не на кривой", NewPublicKeyOnCurve(c, pubKey.X, new(big.Int).Add(pubKey.Y, i1)), privKey, "не лежит на кривой"},
+	}
+	for _, r := range rejects {
+		if err := sign.CheckKeyPair(r.pub, r.priv); err == nil || !strings.Contains(err.Error(), r.err) {
+			t.Fatalf("%s: %v, ожидалась ошибка с %q", r.name, err, r.err)
+		}
+	}
+}
+
+// Публичный ключ, восстановленный по приватному, совпадает с исходным
+func TestRegenPublicKey(t *testing.T) {
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		pubKey, privKey, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Ключ, прочитанный из файла, привязывается к кривой выбранного набора параметров
+		regen, ok := NewPrivateKeyOnCurve(ps.Curve(), privKey.D).Public().(*PublicKey)
+		if !ok {
+			t.Fatalf("%s: публичный ключ не восстановлен", ps.Name)
+		}
+		if !regen.Equal(NewPublicKeyOnCurve(ps.Curve(), pubKey.X, pubKey.Y)) {
+			t.Fatalf("%s: восстановленный ключ (%x, %x) не совпадает с исходным (%x, %x)", ps.Name, regen.X, regen.Y, pubKey.X, pubKey.Y)
+		}
+		if err := sign.CheckKeyPair(regen, privKey); err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+	}
+}
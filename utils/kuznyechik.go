@@ -0,0 +1,178 @@
+package utils
+
+// Блочный шифр "Кузнечик", ГОСТ Р 34.12-2015
+// RFC 7801 https://datatracker.ietf.org/doc/rfc7801/
+// Длина блока 128 бит, длина ключа 256 бит
+// Реализация повторяет описание стандарта без таблиц предвычислений
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// Длина блока "Кузнечика" в байтах
+const kuznyechikBlockSize = 16
+
+// Нелинейная биекция π
+var kuznyechikPi = [256]byte{
+	252, 238, 221, 17, 207, 110, 49, 22, 251, 196, 250, 218, 35, 197, 4, 77,
+	233, 119, 240, 219, 147, 46, 153, 186, 23, 54, 241, 187, 20, 205, 95, 193,
+	249, 24, 101, 90, 226, 92, 239, 33, 129, 28, 60, 66, 139, 1, 142, 79,
+	5, 132, 2, 174, 227, 106, 143, 160, 6, 11, 237, 152, 127, 212, 211, 31,
+	235, 52, 44, 81, 234, 200, 72, 171, 242, 42, 104, 162, 253, 58, 206, 204,
+	181, 112, 14, 86, 8, 12, 118, 18, 191, 114, 19, 71, 156, 183, 93, 135,
+	21, 161, 150, 41, 16, 123, 154, 199, 243, 145, 120, 111, 157, 158, 178, 177,
+	50, 117, 25, 61, 255, 53, 138, 126, 109, 84, 198, 128, 195, 189, 13, 87,
+	223, 245, 36, 169, 62, 168, 67, 201, 215, 121, 214, 246, 124, 34, 185, 3,
+	224, 15, 236, 222, 122, 148, 176, 188, 220, 232, 40, 80, 78, 51, 10, 74,
+	167, 151, 96, 115, 30, 0, 98, 68, 26, 184, 56, 130, 100, 159, 38, 65,
+	173, 69, 70, 146, 39, 94, 85, 47, 140, 163, 165, 125, 105, 213, 149, 59,
+	7, 88, 179, 64, 134, 172, 29, 247, 48, 55, 107, 228, 136, 217, 231, 137,
+	225, 27, 131, 73, 76, 63, 248, 254, 141, 83, 170, 144, 202, 216, 133, 97,
+	32, 113, 103, 164, 45, 43, 9, 91, 203, 155, 37, 208, 190, 229, 108, 82,
+	89, 166, 116, 210, 230, 244, 180, 192, 209, 102, 175, 194, 57, 75, 99, 182,
+}
+
+// Обратная биекция π^-1, заполняется при инициализации пакета
+var kuznyechikPiInv [256]byte
+
+// Коэффициенты линейного преобразования l
+var kuznyechikL = [16]byte{148, 32, 133, 16, 194, 192, 1, 251, 1, 192, 194, 16, 133, 32, 148, 1}
+
+func init() {
+	for i, v := range kuznyechikPi {
+		kuznyechikPiInv[v] = byte(i)
+	}
+}
+
+// Тип шифра "Кузнечик" с развернутыми итерационными ключами, реализует cipher.Block
+type kuznyechik struct {
+	// Итерационные ключи K1..K10
+	keys [10][kuznyechikBlockSize]byte
+}
+
+// Проверка соответствия интерфейсу на этапе компиляции
+var _ cipher.Block = (*kuznyechik)(nil)
+
+// Умножение в поле GF(2^8) с многочленом x^8 + x^7 + x^6 + x + 1
+func kuznyechikMul(a, b byte) byte {
+	var r byte
+	for b != 0 {
+		if b&1 != 0 {
+			r ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0xc3
+		}
+		b >>= 1
+	}
+	return r
+}
+
+// Линейное преобразование l, байт a[0] соответствует старшему элементу a15
+func kuznyechikLinear(a *[kuznyechikBlockSize]byte) byte {
+	var r byte
+	for i := 0; i < kuznyechikBlockSize; i++ {
+		r ^= kuznyechikMul(a[i], kuznyechikL[i])
+	}
+	return r
+}
+
+// Преобразование L = R^16
+func kuznyechikLTransform(a *[kuznyechikBlockSize]byte) {
+	for i := 0; i < 16; i++ {
+		// R(a15..a0) = l(a15..a0) || a15..a1
+		l := kuznyechikLinear(a)
+		copy(a[1:], a[:kuznyechikBlockSize-1])
+		a[0] = l
+	}
+}
+
+// Обратное преобразование L^-1
+func kuznyechikLInverse(a *[kuznyechikBlockSize]byte) {
+	for i := 0; i < 16; i++ {
+		// R^-1(a15..a0) = a14..a0 || l(a14..a0, a15)
+		first := a[0]
+		copy(a[:], a[1:])
+		a[kuznyechikBlockSize-1] = first
+		a[kuznyechikBlockSize-1] = kuznyechikLinear(a)
+	}
+}
+
+// Преобразование X[k](a) = k ⊕ a
+func kuznyechikXor(a, k *[kuznyechikBlockSize]byte) {
+	for i := range a {
+		a[i] ^= k[i]
+	}
+}
+
+// "Конструктор" для шифра "Кузнечик", ключ 32 байта
+func newKuznyechik(key []byte) (*kuznyechik, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("неверная длина ключа Кузнечик: %d, должна быть 32", len(key))
+	}
+	k := &kuznyechik{}
+	var a1, a0 [kuznyechikBlockSize]byte
+	copy(a1[:], key[:16])
+	copy(a0[:], key[16:])
+	k.keys[0], k.keys[1] = a1, a0
+
+	for i := 1; i <= 32; i++ {
+		// C_i = L(Vec128(i))
+		var c [kuznyechikBlockSize]byte
+		c[kuznyechikBlockSize-1] = byte(i)
+		kuznyechikLTransform(&c)
+
+		// F[C](a1, a0) = (LSX[C](a1) ⊕ a0, a1)
+		t := a1
+		kuznyechikXor(&t, &c)
+		for j := range t {
+			t[j] = kuznyechikPi[t[j]]
+		}
+		kuznyechikLTransform(&t)
+		kuznyechikXor(&t, &a0)
+		a0, a1 = a1, t
+
+		if i%8 == 0 {
+			k.keys[i/4], k.keys[i/4+1] = a1, a0
+		}
+	}
+	return k, nil
+}
+
+// Размер блока в байтах
+func (k *kuznyechik) BlockSize() int {
+	return kuznyechikBlockSize
+}
+
+// Зашифрование блока E = X[K10]LSX[K9]...LSX[K1]
+func (k *kuznyechik) Encrypt(dst, src []byte) {
+	var a [kuznyechikBlockSize]byte
+	copy(a[:], src[:kuznyechikBlockSize])
+	for i := 0; i < 9; i++ {
+		kuznyechikXor(&a, &k.keys[i])
+		for j := range a {
+			a[j] = kuznyechikPi[a[j]]
+		}
+		kuznyechikLTransform(&a)
+	}
+	kuznyechikXor(&a, &k.keys[9])
+	copy(dst, a[:])
+}
+
+// Расшифрование блока D = X[K1]S^-1L^-1...S^-1L^-1X[K10]
+func (k *kuznyechik) Decrypt(dst, src []byte) {
+	var a [kuznyechikBlockSize]byte
+	copy(a[:], src[:kuznyechikBlockSize])
+	kuznyechikXor(&a, &k.keys[9])
+	for i := 8; i >= 0; i-- {
+		kuznyechikLInverse(&a)
+		for j := range a {
+			a[j] = kuznyechikPiInv[a[j]]
+		}
+		kuznyechikXor(&a, &k.keys[i])
+	}
+	copy(dst, a[:])
+}
@@ -0,0 +1,90 @@
+package utils
+
+// Блочный шифр "Магма", ГОСТ Р 34.12-2015
+// RFC 8891 https://datatracker.ietf.org/doc/rfc8891/
+// Длина блока 64 бита, длина ключа 256 бит, узлы замены id-tc26-gost-28147-param-Z
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Длина блока "Магмы" в байтах
+const magmaBlockSize = 8
+
+// Подстановки π0..π7, πi применяется к i-й четверке бит начиная с младшей
+var magmaPi = [8][16]byte{
+	{12, 4, 6, 2, 10, 5, 11, 9, 14, 8, 13, 7, 0, 3, 15, 1},
+	{6, 8, 2, 3, 9, 10, 5, 12, 1, 14, 4, 7, 11, 13, 0, 15},
+	{11, 3, 5, 8, 2, 15, 10, 13, 14, 1, 7, 4, 12, 9, 6, 0},
+	{12, 8, 2, 1, 13, 4, 15, 6, 7, 0, 10, 5, 3, 14, 9, 11},
+	{7, 15, 5, 10, 8, 1, 6, 13, 0, 9, 3, 14, 11, 4, 2, 12},
+	{5, 13, 15, 6, 9, 2, 12, 10, 11, 7, 8, 1, 4, 3, 14, 0},
+	{8, 14, 2, 5, 6, 9, 1, 12, 15, 4, 11, 0, 13, 10, 3, 7},
+	{1, 7, 14, 13, 0, 5, 8, 3, 4, 15, 10, 6, 9, 12, 11, 2},
+}
+
+// Тип шифра "Магма" с развернутыми итерационными ключами, реализует cipher.Block
+type magma struct {
+	// Итерационные ключи K1..K32
+	keys [32]uint32
+}
+
+// Проверка соответствия интерфейсу на этапе компиляции
+var _ cipher.Block = (*magma)(nil)
+
+// "Конструктор" для шифра "Магма", ключ 32 байта
+func newMagma(key []byte) (*magma, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("неверная длина ключа Магма: %d, должна быть 32", len(key))
+	}
+	m := &magma{}
+	// K1..K24 - три повтора K1..K8, K25..K32 = K8..K1
+	for i := 0; i < 8; i++ {
+		k := binary.BigEndian.Uint32(key[4*i:])
+		m.keys[i], m.keys[8+i], m.keys[16+i] = k, k, k
+		m.keys[31-i] = k
+	}
+	return m, nil
+}
+
+// Преобразование g[k](a) = (t(a + k)) <<< 11
+func magmaG(k, a uint32) uint32 {
+	a += k
+	var t uint32
+	for i := 0; i < 8; i++ {
+		t |= uint32(magmaPi[i][(a>>(4*i))&0x0f]) << (4 * i)
+	}
+	return bits.RotateLeft32(t, 11)
+}
+
+// Размер блока в байтах
+func (m *magma) BlockSize() int {
+	return magmaBlockSize
+}
+
+// 32 раунда сети Фейстеля с ключами в порядке keys(i)
+func (m *magma) crypt(dst, src []byte, key func(i int) uint32) {
+	a1 := binary.BigEndian.Uint32(src[0:4])
+	a0 := binary.BigEndian.Uint32(src[4:8])
+	// G[k](a1, a0) = (a0, g[k](a0) ⊕ a1)
+	for i := 0; i < 31; i++ {
+		a1, a0 = a0, magmaG(key(i), a0)^a1
+	}
+	// G*[k](a1, a0) = (g[k](a0) ⊕ a1) || a0
+	a1 ^= magmaG(key(31), a0)
+	binary.BigEndian.PutUint32(dst[0:4], a1)
+	binary.BigEndian.PutUint32(dst[4:8], a0)
+}
+
+// Зашифрование блока
+func (m *magma) Encrypt(dst, src []byte) {
+	m.crypt(dst, src, func(i int) uint32 { return m.keys[i] })
+}
+
+// Расшифрование блока, итерационные ключи в обратном порядке
+func (m *magma) Decrypt(dst, src []byte) {
+	m.crypt(dst, src, func(i int) uint32 { return m.keys[31-i] })
+}
@@ -0,0 +1,157 @@
+package utils
+
+// Детерминированная выработка k по схеме RFC 6979 с HMAC-Стрибог
+// Вместо bits2octets(h) используется число e, полученное из хеша так же, как при подписи,
+// поэтому k определяется приватным ключом и подписываемым значением
+// В режиме NonceHedged к данным HMAC добавляются случайные байты (RFC 6979 п.3.6),
+// что сохраняет стойкость при плохом источнике случайности и защищает от атак на сбои
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Способ выработки k при подписи
+type NonceMode int
+
+const (
+	// Случайное k из источника случайности (по умолчанию)
+	NonceRandom NonceMode = iota
+	// k вырабатывается из приватного ключа и хеша сообщения
+	NonceDeterministic
+	// Детерминированное k с подмешиванием случайности
+	NonceHedged
+)
+
+// Название режима
+func (m NonceMode) String() string {
+	switch m {
+	case NonceRandom:
+		return "random"
+	case NonceDeterministic:
+		return "deterministic"
+	case NonceHedged:
+		return "hedged"
+	}
+	return fmt.Sprintf("NonceMode(%d)", int(m))
+}
+
+// Получение режима выработки k по названию
+func ParseNonceMode(name string) (NonceMode, error) {
+	for _, m := range []NonceMode{NonceRandom, NonceDeterministic, NonceHedged} {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестный способ выработки k: %s", name)
+}
+
+// Выбор способа выработки k для Signer
+func (sign *Signer) SetNonceMode(mode NonceMode) {
+	sign.nonce = mode
+}
+
+// Генератор k, состояние HMAC_DRBG из RFC 6979 п.3.2
+type nonceGenerator struct {
+	// Длина хеша HMAC 256/512
+	hashSize int
+	// Порядок подгруппы q
+	q *big.Int
+	// Длина q в байтах
+	qLen int
+	// Состояние генератора
+	k, v []byte
+}
+
+// Конкатенация байтовых строк
+func concatBytes(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// Инициализация генератора по приватному ключу d, числу e и дополнительным данным extra
+// int2octets(d) определено только для 0 < d < q, для остальных d возвращается ошибка
+func (sign *Signer) newNonceGenerator(d, e *big.Int, extra []byte) (*nonceGenerator, error) {
+	if d.Sign() <= 0 || d.Cmp(sign.c.Q) >= 0 {
+		return nil, fmt.Errorf("приватный ключ должен удовлетворять 0 < d < q")
+	}
+	g := &nonceGenerator{
+		hashSize: sign.mode,
+		q:        sign.c.Q,
+		qLen:     (sign.c.Q.BitLen() + 7) / 8,
+	}
+	size := g.hashSize / 8
+	g.k = make([]byte, size)
+	g.v = make([]byte, size)
+	for i := range g.v {
+		g.v[i] = 0x01
+	}
+
+	// int2octets(d) || int2octets(e mod q) || extra
+	seed := concatBytes(d.FillBytes(make([]byte, g.qLen)), new(big.Int).Mod(e, g.q).FillBytes(make([]byte, g.qLen)), extra)
+
+	// K = HMAC_K(V || 0x00 || seed), V = HMAC_K(V)
+	g.k = hmacStreebog(g.hashSize, g.k, concatBytes(g.v, []byte{0x00}, seed))
+	g.v = hmacStreebog(g.hashSize, g.k, g.v)
+	// K = HMAC_K(V || 0x01 || seed), V = HMAC_K(V)
+	g.k = hmacStreebog(g.hashSize, g.k, concatBytes(g.v, []byte{0x01}, seed))
+	g.v = hmacStreebog(g.hashSize, g.k, g.v)
+	return g, nil
+}
+
+// Выработка очередного кандидата 0 < k < q
+func (g *nonceGenerator) next() *big.Int {
+	for {
+		var t []byte
+		for len(t) < g.qLen {
+			g.v = hmacStreebog(g.hashSize, g.k, g.v)
+			t = append(t, g.v...)
+		}
+
+		// bits2int: старшие qlen бит
+		k := new(big.Int).SetBytes(t[:g.qLen])
+		if excess := g.qLen*8 - g.q.BitLen(); excess > 0 {
+			k.Rsh(k, uint(excess))
+		}
+		if k.Sign() > 0 && k.Cmp(g.q) < 0 {
+			return k
+		}
+		g.reseed()
+	}
+}
+
+// Обновление состояния после отвергнутого кандидата
+// K = HMAC_K(V || 0x00), V = HMAC_K(V)
+func (g *nonceGenerator) reseed() {
+	g.k = hmacStreebog(g.hashSize, g.k, concatBytes(g.v, []byte{0x00}))
+	g.v = hmacStreebog(g.hashSize, g.k, g.v)
+}
+
+// Выработка подписи (r, s) с детерминированным k
+// В режиме NonceHedged из rnd считывается qlen случайных байт
+func (sign *Signer) signDeterministic(rnd io.Reader, e, d *big.Int) (*big.Int, *big.Int, error) {
+	var extra []byte
+	if sign.nonce == NonceHedged {
+		extra = make([]byte, (sign.c.Q.BitLen()+7)/8)
+		if _, err := io.ReadFull(rnd, extra); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	g, err := sign.newNonceGenerator(d, e, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		k := g.next()
+		// Если r или s равны 0, берется следующий кандидат
+		if r, s, ok := sign.signWithK(e, d, k); ok {
+			return r, s, nil
+		}
+		g.reseed()
+	}
+}
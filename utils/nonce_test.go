@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// Детерминированная подпись одного сообщения одним ключом всегда одинакова и проходит проверку
+func TestDeterministicNonce(t *testing.T) {
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		sign.SetNonceMode(NonceDeterministic)
+		pubKey, privKey, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := []byte("deterministic nonce")
+		first, err := sign.SignBytes(message, privKey)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		second, err := sign.SignBytes(message, privKey)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Fatalf("%s: детерминированные подписи различаются", ps.Name)
+		}
+		if ok, err := sign.VerifySign(message, first, pubKey); !ok {
+			t.Fatalf("%s: подпись не прошла проверку: %v", ps.Name, err)
+		}
+	}
+}
+
+// Приватный ключ вне интервала (0, q) отвергается, а не вызывает панику
+func TestDeterministicNonceKeyRange(t *testing.T) {
+	ps, err := ParamSetByName("id-tc26-gost-3410-2012-256-paramSetB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ps.Curve()
+	for _, mode := range []NonceMode{NonceDeterministic, NonceHedged} {
+		sign := NewSigner(c, ps.HashMode)
+		sign.SetNonceMode(mode)
+		for _, d := range []*big.Int{
+			big.NewInt(0),
+			big.NewInt(-1),
+			new(big.Int).Set(c.Q),
+			new(big.Int).Lsh(i1, uint(c.Q.BitLen()+8)),
+		} {
+			if _, err := sign.SignBytes([]byte("message"), NewPrivateKeyOnCurve(c, d)); err == nil {
+				t.Fatalf("%s: принят приватный ключ %x", mode, d)
+			}
+		}
+	}
+}
@@ -0,0 +1,552 @@
+package utils
+
+// Запросы и ответы OCSP (RFC 6960) для сертификатов с ключами ГОСТ Р 34.10-2012
+// Идентификатор сертификата CertID формируется по хешу Стрибог-256 имени и ключа издателя,
+// при разборе допускаются также SHA-1 и SHA-256, используемые другими клиентами
+// Ответ подписывается ключом сертификата УЦ или выпущенного им сертификата ответчика
+// с назначением OCSPSigning, подпись формируется Signer, подробнее в utils/signed_data.go
+// Подпись запросов не поддерживается: подписанные запросы разбираются без проверки подписи
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+var (
+	// Тип ответа id-pkix-ocsp-basic
+	oidOCSPBasic = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	// Расширение запроса и ответа id-pkix-ocsp-nonce (RFC 8954)
+	oidOCSPNonce = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+	// Хеш-функции CertID других реализаций
+	oidDigestSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidDigestSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+// Статус сертификата в ответе OCSP
+const (
+	OCSPGood    = 0
+	OCSPRevoked = 1
+	OCSPUnknown = 2
+)
+
+// Статус ответа OCSPResponseStatus
+const (
+	OCSPSuccessful       = 0
+	OCSPMalformedRequest = 1
+	OCSPInternalError    = 2
+	OCSPTryLater         = 3
+	OCSPSigRequired      = 5
+	OCSPUnauthorized     = 6
+)
+
+// Имена статусов ответа
+var ocspResponseStatusNames = map[int]string{
+	OCSPSuccessful:       "successful",
+	OCSPMalformedRequest: "malformedRequest",
+	OCSPInternalError:    "internalError",
+	OCSPTryLater:         "tryLater",
+	OCSPSigRequired:      "sigRequired",
+	OCSPUnauthorized:     "unauthorized",
+}
+
+// Длина nonce запроса по умолчанию (RFC 8954 п.2.1)
+const ocspNonceSize = 32
+
+// Ответчик OCSP вернул ответ без статуса сертификата
+var ErrOCSPUnsuccessful = errors.New("ответчик OCSP не сообщил статус сертификата")
+
+// OCSPRequest
+type ocspRequest struct {
+	TBSRequest tbsRequest
+	Signature  asn1.RawValue `asn1:"optional,explicit,tag:0"`
+}
+
+// TBSRequest
+type tbsRequest struct {
+	Version       int           `asn1:"optional,explicit,tag:0,default:0"`
+	RequestorName asn1.RawValue `asn1:"optional,explicit,tag:1"`
+	RequestList   []singleRequest
+	Extensions    []pkix.Extension `asn1:"optional,explicit,tag:2"`
+}
+
+// Request
+type singleRequest struct {
+	CertID     certID
+	Extensions []pkix.Extension `asn1:"optional,explicit,tag:0"`
+}
+
+// CertID
+type certID struct {
+	HashAlgorithm  algorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+// OCSPResponse
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response responseBytes `asn1:"optional,explicit,tag:0"`
+}
+
+// ResponseBytes
+type responseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+// BasicOCSPResponse
+type basicOCSPResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm algorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"optional,explicit,tag:0"`
+}
+
+// ResponseData
+type responseData struct {
+	Version     int `asn1:"optional,explicit,tag:0,default:0"`
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []singleResponse
+	Extensions  []pkix.Extension `asn1:"optional,explicit,tag:1"`
+}
+
+// SingleResponse, статус задается одним из полей Good, Revoked, Unknown
+type singleResponse struct {
+	CertID     certID
+	Good       asn1.Flag        `asn1:"optional,tag:0"`
+	Revoked    revokedInfo      `asn1:"optional,tag:1"`
+	Unknown    asn1.Flag        `asn1:"optional,tag:2"`
+	ThisUpdate time.Time        `asn1:"generalized"`
+	NextUpdate time.Time        `asn1:"optional,generalized,explicit,tag:0"`
+	Extensions []pkix.Extension `asn1:"optional,explicit,tag:1"`
+}
+
+// RevokedInfo
+type revokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"optional,explicit,tag:0"`
+}
+
+// Идентификатор сертификата в запросе и ответе OCSP
+type OCSPCertID struct {
+	// OID хеш-функции
+	HashAlgorithm asn1.ObjectIdentifier
+	// Хеши имени и ключа издателя
+	IssuerNameHash, IssuerKeyHash []byte
+	// Серийный номер сертификата
+	SerialNumber *big.Int
+}
+
+// Хеш-функция CertID по OID
+func ocspHash(oid asn1.ObjectIdentifier) (func([]byte) []byte, error) {
+	switch {
+	case oid.Equal(OIDDigestStreebog256):
+		return func(b []byte) []byte { return streebog(256, b) }, nil
+	case oid.Equal(OIDDigestStreebog512):
+		return func(b []byte) []byte { return streebog(512, b) }, nil
+	case oid.Equal(oidDigestSHA1):
+		return func(b []byte) []byte { h := sha1.Sum(b); return h[:] }, nil
+	case oid.Equal(oidDigestSHA256):
+		return func(b []byte) []byte { h := sha256.Sum256(b); return h[:] }, nil
+	}
+	return nil, fmt.Errorf("неподдерживаемая хеш-функция идентификатора сертификата: %s", oid)
+}
+
+// Значение ключа издателя из SubjectPublicKeyInfo для хеша в CertID
+func issuerKeyBits(issuer *Certificate) ([]byte, error) {
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, err
+	}
+	return spki.PublicKey.RightAlign(), nil
+}
+
+// Формирование идентификатора сертификата с серийным номером serial издателя issuer
+// по хешу Стрибог-256
+func NewOCSPCertID(serial *big.Int, issuer *Certificate) (*OCSPCertID, error) {
+	keyBits, err := issuerKeyBits(issuer)
+	if err != nil {
+		return nil, err
+	}
+	return &OCSPCertID{
+		HashAlgorithm:  OIDDigestStreebog256,
+		IssuerNameHash: streebog(256, issuer.RawSubject),
+		IssuerKeyHash:  streebog(256, keyBits),
+		SerialNumber:   serial,
+	}, nil
+}
+
+// Проверка того, что идентификатор относится к сертификатам издателя issuer
+func (id *OCSPCertID) MatchesIssuer(issuer *Certificate) bool {
+	hash, err := ocspHash(id.HashAlgorithm)
+	if err != nil {
+		return false
+	}
+	keyBits, err := issuerKeyBits(issuer)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(id.IssuerNameHash, hash(issuer.RawSubject)) && bytes.Equal(id.IssuerKeyHash, hash(keyBits))
+}
+
+// Проверка совпадения идентификаторов
+func (id *OCSPCertID) Equal(other *OCSPCertID) bool {
+	return id.HashAlgorithm.Equal(other.HashAlgorithm) && bytes.Equal(id.IssuerNameHash, other.IssuerNameHash) &&
+		bytes.Equal(id.IssuerKeyHash, other.IssuerKeyHash) && id.SerialNumber.Cmp(other.SerialNumber) == 0
+}
+
+// Запись идентификатора в ASN.1 структуру
+func (id *OCSPCertID) raw() certID {
+	return certID{
+		HashAlgorithm:  algorithmIdentifier{Algorithm: id.HashAlgorithm},
+		IssuerNameHash: id.IssuerNameHash,
+		IssuerKeyHash:  id.IssuerKeyHash,
+		SerialNumber:   id.SerialNumber,
+	}
+}
+
+// Разбор идентификатора из ASN.1 структуры
+func parseCertID(raw certID) *OCSPCertID {
+	return &OCSPCertID{
+		HashAlgorithm:  raw.HashAlgorithm.Algorithm,
+		IssuerNameHash: raw.IssuerNameHash,
+		IssuerKeyHash:  raw.IssuerKeyHash,
+		SerialNumber:   raw.SerialNumber,
+	}
+}
+
+// Расширение nonce: OCTET STRING в значении расширения
+func ocspNonceExtension(nonce []byte) (pkix.Extension, error) {
+	value, err := asn1.Marshal(nonce)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: oidOCSPNonce, Value: value}, nil
+}
+
+// Поиск nonce в расширениях, nil - nonce отсутствует
+func ocspNonce(exts []pkix.Extension) ([]byte, error) {
+	for _, ext := range exts {
+		if ext.Id.Equal(oidOCSPNonce) {
+			var nonce []byte
+			if _, err := asn1.Unmarshal(ext.Value, &nonce); err != nil {
+				return nil, fmt.Errorf("расширение nonce: %w", err)
+			}
+			return nonce, nil
+		}
+	}
+	return nil, nil
+}
+
+// Запрос OCSP статуса одного сертификата
+type OCSPRequest struct {
+	// Идентификатор сертификата
+	CertID *OCSPCertID
+	// Nonce для защиты от повтора ответа, nil - не задан
+	Nonce []byte
+}
+
+// Формирование запроса OCSP в DER
+func (req *OCSPRequest) Marshal() ([]byte, error) {
+	tbs := tbsRequest{RequestList: []singleRequest{{CertID: req.CertID.raw()}}}
+	if req.Nonce != nil {
+		ext, err := ocspNonceExtension(req.Nonce)
+		if err != nil {
+			return nil, err
+		}
+		tbs.Extensions = []pkix.Extension{ext}
+	}
+	return asn1.Marshal(ocspRequest{TBSRequest: tbs})
+}
+
+// Разбор запроса OCSP из DER
+// Поддерживаются запросы статуса одного сертификата
+func ParseOCSPRequest(der []byte) (*OCSPRequest, error) {
+	var raw ocspRequest
+	if rest, err := asn1.Unmarshal(der, &raw); err != nil {
+		return nil, fmt.Errorf("неверная структура запроса OCSP: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после запроса OCSP")
+	}
+	if len(raw.TBSRequest.RequestList) != 1 {
+		return nil, fmt.Errorf("запрос OCSP должен содержать один сертификат, содержит %d", len(raw.TBSRequest.RequestList))
+	}
+	nonce, err := ocspNonce(raw.TBSRequest.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	return &OCSPRequest{CertID: parseCertID(raw.TBSRequest.RequestList[0].CertID), Nonce: nonce}, nil
+}
+
+// Шаблон ответа OCSP
+type OCSPResponseTemplate struct {
+	// Идентификатор сертификата из запроса
+	CertID *OCSPCertID
+	// Статус сертификата: OCSPGood, OCSPRevoked или OCSPUnknown
+	Status int
+	// Время и причина отзыва для OCSPRevoked
+	RevokedAt        time.Time
+	RevocationReason int
+	// Время, на которое статус достоверен, и время следующего обновления (нулевое - не указывается)
+	ThisUpdate, NextUpdate time.Time
+	// Nonce из запроса
+	Nonce []byte
+}
+
+// Ответ OCSP о статусе сертификата
+type OCSPResponse struct {
+	// Ответ в DER
+	Raw []byte
+	// Идентификатор сертификата
+	CertID *OCSPCertID
+	// Статус сертификата: OCSPGood, OCSPRevoked или OCSPUnknown
+	Status int
+	// Время и причина отзыва для OCSPRevoked
+	RevokedAt        time.Time
+	RevocationReason int
+	// Время формирования ответа
+	ProducedAt time.Time
+	// Время, на которое статус достоверен, и время следующего обновления (нулевое, если не задано)
+	ThisUpdate, NextUpdate time.Time
+	// Nonce из ответа, nil - не задан
+	Nonce []byte
+	// Сертификат, ключом которого подписан ответ
+	Certificate *Certificate
+}
+
+// Формирование ответа OCSP об ошибке без статуса сертификата, например OCSPMalformedRequest
+func CreateOCSPErrorResponse(status int) []byte {
+	der, _ := asn1.Marshal(ocspResponse{Status: asn1.Enumerated(status)})
+	return der
+}
+
+// Формирование ответа OCSP по шаблону tmpl о сертификате, выпущенном issuer
+// Ответ подписывается ключом privKey сертификата responder: сертификата issuer
+// или выпущенного им сертификата с назначением OCSPSigning, который включается в ответ
+func (sign *Signer) CreateOCSPResponse(tmpl *OCSPResponseTemplate, issuer, responder *Certificate, privKey *PrivateKey) ([]byte, error) {
+	if privKey.Curve == nil {
+		return nil, fmt.Errorf("приватный ключ ответчика не привязан к кривой")
+	}
+	if sign.mode != privKey.Curve.Size() {
+		return nil, fmt.Errorf("режим %d не соответствует ключу ответчика %d бит", sign.mode, privKey.Curve.Size())
+	}
+	if !privKey.Public().(*PublicKey).Equal(responder.PublicKey) {
+		return nil, ErrKeyPairMismatch
+	}
+	if !tmpl.CertID.MatchesIssuer(issuer) {
+		return nil, fmt.Errorf("идентификатор сертификата не относится к издателю %q", issuer.Subject)
+	}
+	var certs []asn1.RawValue
+	if !bytes.Equal(responder.Raw, issuer.Raw) {
+		if err := checkOCSPResponder(responder, issuer); err != nil {
+			return nil, err
+		}
+		certs = []asn1.RawValue{{FullBytes: responder.Raw}}
+	}
+
+	single := singleResponse{
+		CertID:     tmpl.CertID.raw(),
+		ThisUpdate: tmpl.ThisUpdate.UTC().Truncate(time.Second),
+		NextUpdate: tmpl.NextUpdate.UTC().Truncate(time.Second),
+	}
+	switch tmpl.Status {
+	case OCSPGood:
+		single.Good = true
+	case OCSPRevoked:
+		single.Revoked = revokedInfo{RevocationTime: tmpl.RevokedAt.UTC().Truncate(time.Second), Reason: asn1.Enumerated(tmpl.RevocationReason)}
+	case OCSPUnknown:
+		single.Unknown = true
+	default:
+		return nil, fmt.Errorf("неизвестный статус сертификата: %d", tmpl.Status)
+	}
+
+	data := responseData{
+		// byName [1] EXPLICIT Name
+		ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: responder.RawSubject},
+		ProducedAt:  time.Now().UTC().Truncate(time.Second),
+		Responses:   []singleResponse{single},
+	}
+	if tmpl.Nonce != nil {
+		ext, err := ocspNonceExtension(tmpl.Nonce)
+		if err != nil {
+			return nil, err
+		}
+		data.Extensions = []pkix.Extension{ext}
+	}
+	tbs, err := asn1.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	sigAlgo, signature, err := sign.signData(tbs, privKey)
+	if err != nil {
+		return nil, err
+	}
+	basic, err := asn1.Marshal(basicOCSPResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: sigAlgo,
+		Signature:          signature,
+		Certificates:       certs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(ocspResponse{
+		Status:   OCSPSuccessful,
+		Response: responseBytes{ResponseType: oidOCSPBasic, Response: basic},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка сформированного ответа разбором и проверкой подписи
+	if _, err := ParseOCSPResponse(der, issuer); err != nil {
+		return nil, err
+	}
+	return der, nil
+}
+
+// Проверка сертификата ответчика, отличного от издателя: выпущен issuer, назначение OCSPSigning
+func checkOCSPResponder(responder, issuer *Certificate) error {
+	if err := responder.CheckSignatureFrom(issuer); err != nil {
+		return fmt.Errorf("сертификат ответчика OCSP: %w", err)
+	}
+	for _, eku := range responder.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageOCSPSigning {
+			return nil
+		}
+	}
+	return fmt.Errorf("сертификат ответчика OCSP %q не имеет назначения OCSPSigning: %w", responder.Subject, ErrKeyUsage)
+}
+
+// Разбор ответа OCSP из DER и проверка его подписи
+// issuer - сертификат издателя проверяемого сертификата: ответ должен быть подписан его ключом
+// или ключом включенного в ответ сертификата ответчика, выпущенного issuer, с назначением OCSPSigning
+// Для ответа без статуса сертификата возвращается ошибка ErrOCSPUnsuccessful
+func ParseOCSPResponse(der []byte, issuer *Certificate) (*OCSPResponse, error) {
+	var raw ocspResponse
+	if rest, err := asn1.Unmarshal(der, &raw); err != nil {
+		return nil, fmt.Errorf("неверная структура ответа OCSP: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после ответа OCSP")
+	}
+	if status := int(raw.Status); status != OCSPSuccessful {
+		name, ok := ocspResponseStatusNames[status]
+		if !ok {
+			name = fmt.Sprintf("%d", status)
+		}
+		return nil, fmt.Errorf("%w: статус ответа %s", ErrOCSPUnsuccessful, name)
+	}
+	if !raw.Response.ResponseType.Equal(oidOCSPBasic) {
+		return nil, fmt.Errorf("неподдерживаемый тип ответа OCSP: %s", raw.Response.ResponseType)
+	}
+	var basic basicOCSPResponse
+	if rest, err := asn1.Unmarshal(raw.Response.Response, &basic); err != nil {
+		return nil, fmt.Errorf("неверная структура ответа OCSP: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные в ответе OCSP")
+	}
+	var data responseData
+	if rest, err := asn1.Unmarshal(basic.TBSResponseData.FullBytes, &data); err != nil {
+		return nil, fmt.Errorf("неверная структура ответа OCSP: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные в ответе OCSP")
+	}
+	if len(data.Responses) != 1 {
+		return nil, fmt.Errorf("ответ OCSP должен содержать статус одного сертификата, содержит %d", len(data.Responses))
+	}
+
+	// Сертификат подписи ответа
+	responder, err := ocspResponderCertificate(data.ResponderID, basic.Certificates, issuer)
+	if err != nil {
+		return nil, err
+	}
+	sign, err := verifierFor(responder.PublicKey, basic.SignatureAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := sign.verifyData(basic.SignatureAlgorithm, basic.TBSResponseData.FullBytes, basic.Signature, responder.PublicKey); err != nil {
+		return nil, fmt.Errorf("ответ OCSP: %w", err)
+	}
+
+	single := data.Responses[0]
+	resp := &OCSPResponse{
+		Raw:         der,
+		CertID:      parseCertID(single.CertID),
+		ProducedAt:  data.ProducedAt,
+		ThisUpdate:  single.ThisUpdate,
+		NextUpdate:  single.NextUpdate,
+		Certificate: responder,
+	}
+	if responder != issuer && (resp.ProducedAt.Before(responder.NotBefore) || resp.ProducedAt.After(responder.NotAfter)) {
+		return nil, fmt.Errorf("сертификат ответчика OCSP %q: %w", responder.Subject, ErrCertificateValidity)
+	}
+	if !resp.CertID.MatchesIssuer(issuer) {
+		return nil, fmt.Errorf("ответ OCSP относится к сертификату другого издателя")
+	}
+	switch {
+	case bool(single.Good):
+		resp.Status = OCSPGood
+	case bool(single.Unknown):
+		resp.Status = OCSPUnknown
+	case !single.Revoked.RevocationTime.IsZero():
+		resp.Status = OCSPRevoked
+		resp.RevokedAt = single.Revoked.RevocationTime
+		resp.RevocationReason = int(single.Revoked.Reason)
+	default:
+		return nil, fmt.Errorf("ответ OCSP не содержит статус сертификата")
+	}
+	if resp.Nonce, err = ocspNonce(data.Extensions); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Определение сертификата подписи ответа по ResponderID
+func ocspResponderCertificate(id asn1.RawValue, rawCerts []asn1.RawValue, issuer *Certificate) (*Certificate, error) {
+	if id.Class != asn1.ClassContextSpecific || (id.Tag != 1 && id.Tag != 2) {
+		return nil, fmt.Errorf("неверный идентификатор ответчика OCSP")
+	}
+	// byKey [2] EXPLICIT OCTET STRING с SHA-1 ключа ответчика
+	var keyHash []byte
+	if id.Tag == 2 {
+		if _, err := asn1.Unmarshal(id.Bytes, &keyHash); err != nil {
+			return nil, fmt.Errorf("идентификатор ответчика OCSP: %w", err)
+		}
+	}
+	matches := func(cert *Certificate) bool {
+		if id.Tag == 1 {
+			return bytes.Equal(id.Bytes, cert.RawSubject)
+		}
+		keyBits, err := issuerKeyBits(cert)
+		if err != nil {
+			return false
+		}
+		h := sha1.Sum(keyBits)
+		return bytes.Equal(keyHash, h[:])
+	}
+
+	if matches(issuer) {
+		return issuer, nil
+	}
+	for _, rc := range rawCerts {
+		cert, err := ParseCertificate(rc.FullBytes)
+		if err != nil || !matches(cert) {
+			continue
+		}
+		if err := checkOCSPResponder(cert, issuer); err != nil {
+			return nil, err
+		}
+		return cert, nil
+	}
+	return nil, fmt.Errorf("ответ OCSP подписан не издателем %q и не ответчиком, уполномоченным им", issuer.Subject)
+}
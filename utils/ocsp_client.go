@@ -0,0 +1,189 @@
+package utils
+
+// Клиент и ответчик OCSP по HTTP (RFC 6960 приложение A)
+// Клиент отправляет запрос методом POST с nonce и проверяет подпись, nonce и актуальность ответа
+// Ответчик реализует http.Handler, принимает запросы POST и GET и может встраиваться
+// в любой HTTP сервер, например httptest.Server в тестах
+// Статус сертификатов ответчик получает функцией Lookup, например из базы данных УЦ
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Максимальный размер запроса и ответа OCSP
+	maxOCSPSize = 1 << 20
+	// Допустимое расхождение часов клиента и ответчика
+	ocspClockSkew = 5 * time.Minute
+
+	ocspRequestType  = "application/ocsp-request"
+	ocspResponseType = "application/ocsp-response"
+)
+
+// Запрос статуса сертификата cert, выпущенного issuer, у ответчика OCSP по адресу responderURL
+// Если адрес пуст, используется первый адрес OCSP из authorityInfoAccess сертификата
+// client - HTTP клиент, если nil - http.DefaultClient
+// Возвращает ответ с проверенной подписью, совпадающими nonce и идентификатором сертификата,
+// актуальный на текущий момент
+func QueryOCSP(client *http.Client, responderURL string, cert, issuer *Certificate) (*OCSPResponse, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if responderURL == "" {
+		if len(cert.OCSPServer) == 0 {
+			return nil, fmt.Errorf("сертификат %q не содержит адреса ответчика OCSP", cert.Subject)
+		}
+		responderURL = cert.OCSPServer[0]
+	}
+
+	id, err := NewOCSPCertID(cert.SerialNumber, issuer)
+	if err != nil {
+		return nil, err
+	}
+	req := &OCSPRequest{CertID: id, Nonce: make([]byte, ocspNonceSize)}
+	if _, err := io.ReadFull(rand.Reader, req.Nonce); err != nil {
+		return nil, err
+	}
+	body, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := client.Post(responderURL, ocspRequestType, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ответчик OCSP %s: %s", responderURL, httpResp.Status)
+	}
+	der, err := io.ReadAll(io.LimitReader(httpResp.Body, maxOCSPSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(der) > maxOCSPSize {
+		return nil, fmt.Errorf("размер ответа OCSP превышает %d байт", maxOCSPSize)
+	}
+
+	resp, err := ParseOCSPResponse(der, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.CertID.MatchesIssuer(issuer) || resp.CertID.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		return nil, fmt.Errorf("ответ OCSP относится к другому сертификату")
+	}
+	// Ответчик может не поддерживать nonce (RFC 8954 п.2.1), но возвращенный nonce должен совпадать
+	if resp.Nonce != nil && !bytes.Equal(resp.Nonce, req.Nonce) {
+		return nil, fmt.Errorf("nonce ответа OCSP не совпадает с запросом")
+	}
+	now := time.Now()
+	if resp.ThisUpdate.After(now.Add(ocspClockSkew)) {
+		return nil, fmt.Errorf("ответ OCSP сформирован на будущее время %s", resp.ThisUpdate.Format(time.RFC3339))
+	}
+	if !resp.NextUpdate.IsZero() && resp.NextUpdate.Before(now.Add(-ocspClockSkew)) {
+		return nil, fmt.Errorf("ответ OCSP устарел: следующее обновление %s", resp.NextUpdate.Format(time.RFC3339))
+	}
+	return resp, nil
+}
+
+// Ответчик OCSP о сертификатах одного издателя
+type OCSPResponder struct {
+	// Сертификат УЦ, о выпущенных сертификатах которого сообщается статус
+	Issuer *Certificate
+	// Сертификат подписи ответов: сертификат УЦ или выпущенный им сертификат с назначением
+	// OCSPSigning. Если nil - ответы подписываются ключом сертификата УЦ
+	Certificate *Certificate
+	// Приватный ключ сертификата подписи ответов
+	Key *PrivateKey
+	// Signer для подписи ответов, если nil - создается по ключу с хешем его размера
+	Signer *Signer
+	// Определение статуса сертификата по серийному номеру: OCSPGood, OCSPRevoked с данными
+	// отзыва или OCSPUnknown для сертификатов, которые издатель не выпускал
+	Lookup func(serial *big.Int) (int, *RevokedCertificate, error)
+	// Срок актуальности ответа, если 0 - время следующего обновления не указывается
+	Validity time.Duration
+}
+
+// Формирование ответа на запрос OCSP в DER
+// Ошибки сообщаются клиенту статусом ответа: malformedRequest, unauthorized, internalError
+func (r *OCSPResponder) Respond(reqDER []byte) []byte {
+	req, err := ParseOCSPRequest(reqDER)
+	if err != nil {
+		return CreateOCSPErrorResponse(OCSPMalformedRequest)
+	}
+	// Ответчик не уполномочен сообщать статус сертификатов других издателей
+	if !req.CertID.MatchesIssuer(r.Issuer) {
+		return CreateOCSPErrorResponse(OCSPUnauthorized)
+	}
+	status, revoked, err := r.Lookup(req.CertID.SerialNumber)
+	if err != nil {
+		return CreateOCSPErrorResponse(OCSPInternalError)
+	}
+
+	now := time.Now()
+	tmpl := &OCSPResponseTemplate{CertID: req.CertID, Status: status, ThisUpdate: now, Nonce: req.Nonce}
+	if r.Validity > 0 {
+		tmpl.NextUpdate = now.Add(r.Validity)
+	}
+	if status == OCSPRevoked {
+		if revoked == nil {
+			return CreateOCSPErrorResponse(OCSPInternalError)
+		}
+		tmpl.RevokedAt, tmpl.RevocationReason = revoked.RevocationTime, revoked.ReasonCode
+	}
+
+	responder, sign := r.Certificate, r.Signer
+	if responder == nil {
+		responder = r.Issuer
+	}
+	if sign == nil {
+		if r.Key.Curve == nil {
+			return CreateOCSPErrorResponse(OCSPInternalError)
+		}
+		sign = NewSigner(r.Key.Curve, r.Key.Curve.Size())
+	}
+	der, err := sign.CreateOCSPResponse(tmpl, r.Issuer, responder, r.Key)
+	if err != nil {
+		return CreateOCSPErrorResponse(OCSPInternalError)
+	}
+	return der
+}
+
+// Обработка HTTP запроса OCSP
+// POST - запрос в теле, GET - запрос в base64 в последнем сегменте пути (RFC 6960 п.A.1)
+func (r *OCSPResponder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var der []byte
+	var err error
+	switch req.Method {
+	case http.MethodPost:
+		der, err = io.ReadAll(io.LimitReader(req.Body, maxOCSPSize+1))
+		if err == nil && len(der) > maxOCSPSize {
+			err = fmt.Errorf("размер запроса OCSP превышает %d байт", maxOCSPSize)
+		}
+	case http.MethodGet:
+		var encoded string
+		encoded, err = url.PathUnescape(req.URL.EscapedPath()[strings.LastIndex(req.URL.EscapedPath(), "/")+1:])
+		if err == nil {
+			der, err = base64.StdEncoding.DecodeString(encoded)
+		}
+	default:
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := CreateOCSPErrorResponse(OCSPMalformedRequest)
+	if err == nil {
+		resp = r.Respond(der)
+	}
+	w.Header().Set("Content-Type", ocspResponseType)
+	w.Write(resp)
+}
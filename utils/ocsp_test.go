@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// УЦ, ответчик OCSP по его базе и сертификаты со статусами good (1), revoked (2) и unknown (3)
+// Ответ формируется на текущее время, поэтому сертификаты действуют от текущего момента
+type ocspFixture struct {
+	sign      *Signer
+	ca        *chainEntity
+	caCert    *Certificate
+	leaves    []*Certificate
+	notBefore time.Time
+	revokedAt time.Time
+	responder *OCSPResponder
+	srv       *httptest.Server
+}
+
+func (f *ocspFixture) entity(t *testing.T, cn string, isCA bool) *chainEntity {
+	t.Helper()
+	e := newChainEntity(t, f.sign, cn, isCA, -1)
+	e.tmpl.NotBefore = f.notBefore
+	e.tmpl.NotAfter = f.notBefore.AddDate(1, 0, 0)
+	return e
+}
+
+func newOCSPFixture(t *testing.T) *ocspFixture {
+	t.Helper()
+	sign := NewSigner(NewCurve256ParamSetA(), 256)
+	now := time.Now().UTC().Truncate(time.Second)
+	f := &ocspFixture{sign: sign, notBefore: now.Add(-time.Hour), revokedAt: now.Add(-time.Minute)}
+	f.ca = f.entity(t, "УЦ", true)
+	f.caCert = f.ca.issue(t, sign, nil, nil, nil)
+	f.responder = &OCSPResponder{
+		Issuer: f.caCert,
+		Key:    f.ca.priv,
+		Lookup: func(serial *big.Int) (int, *RevokedCertificate, error) {
+			switch serial.Int64() {
+			case 1:
+				return OCSPGood, nil, nil
+			case 2:
+				return OCSPRevoked, &RevokedCertificate{SerialNumber: serial, RevocationTime: f.revokedAt, ReasonCode: ReasonKeyCompromise}, nil
+			}
+			return OCSPUnknown, nil, nil
+		},
+		Validity: time.Hour,
+	}
+	f.srv = httptest.NewServer(f.responder)
+	t.Cleanup(f.srv.Close)
+
+	leaf := f.entity(t, "Пользователь", false)
+	for serial := int64(1); serial <= 3; serial++ {
+		f.leaves = append(f.leaves, leaf.issue(t, sign, f.ca, f.caCert, func(tmpl *CertificateTemplate) {
+			tmpl.SerialNumber = big.NewInt(serial)
+			tmpl.OCSPServer = []string{f.srv.URL + "/ocsp"}
+		}))
+	}
+	return f
+}
+
+// Сертификат ответчика, выпущенный УЦ, с назначением OCSPSigning или без него
+func (f *ocspFixture) delegate(t *testing.T, e *chainEntity, ocspSigning bool) *Certificate {
+	t.Helper()
+	return e.issue(t, f.sign, f.ca, f.caCert, func(tmpl *CertificateTemplate) {
+		if ocspSigning {
+			tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}
+		}
+	})
+}
+
+func TestOCSPStatuses(t *testing.T) {
+	f := newOCSPFixture(t)
+	statuses := []int{OCSPGood, OCSPRevoked, OCSPUnknown}
+	for i, cert := range f.leaves {
+		// Адрес ответчика берется из authorityInfoAccess сертификата
+		resp, err := QueryOCSP(f.srv.Client(), "", cert, f.caCert)
+		if err != nil {
+			t.Fatalf("сертификат %s: %v", cert.SerialNumber, err)
+		}
+		if resp.Status != statuses[i] || resp.CertID.SerialNumber.Cmp(cert.SerialNumber) != 0 || resp.Certificate != f.caCert {
+			t.Fatalf("сертификат %s: статус %d, ожидался %d", cert.SerialNumber, resp.Status, statuses[i])
+		}
+		if len(resp.Nonce) != ocspNonceSize || !resp.NextUpdate.Equal(resp.ThisUpdate.Add(time.Hour)) {
+			t.Fatalf("сертификат %s: nonce %x, обновление %s", cert.SerialNumber, resp.Nonce, resp.NextUpdate)
+		}
+		if resp.Status == OCSPRevoked && (!resp.RevokedAt.Equal(f.revokedAt) || resp.RevocationReason != ReasonKeyCompromise) {
+			t.Fatalf("отозван %s, причина %s", resp.RevokedAt, ReasonName(resp.RevocationReason))
+		}
+	}
+
+	// Сертификат без адреса ответчика
+	if _, err := QueryOCSP(f.srv.Client(), "", f.caCert, f.caCert); err == nil {
+		t.Fatal("запрос без адреса ответчика")
+	}
+}
+
+// Ответчик возвращает ответ с nonce другого запроса
+func TestOCSPNonceMismatch(t *testing.T) {
+	f := newOCSPFixture(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		der, _ := io.ReadAll(r.Body)
+		req, err := ParseOCSPRequest(der)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Nonce = []byte("повтор ранее полученного ответа")
+		if der, err = req.Marshal(); err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write(f.responder.Respond(der))
+	}))
+	defer srv.Close()
+
+	if _, err := QueryOCSP(srv.Client(), srv.URL, f.leaves[0], f.caCert); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("ответ с чужим nonce: %v", err)
+	}
+}
+
+// Ответы, подписанные сертификатом ответчика, выпущенным УЦ
+func TestOCSPDelegatedResponder(t *testing.T) {
+	f := newOCSPFixture(t)
+	e := f.entity(t, "Ответчик OCSP", false)
+	authorized := f.delegate(t, e, true)
+
+	f.responder.Certificate, f.responder.Key = authorized, e.priv
+	resp, err := QueryOCSP(f.srv.Client(), "", f.leaves[0], f.caCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != OCSPGood || resp.Certificate.Subject.CommonName != "Ответчик OCSP" {
+		t.Fatalf("статус %d, ответ подписан %s", resp.Status, resp.Certificate.Subject)
+	}
+
+	// Ответчик без назначения OCSPSigning не формирует ответ
+	unauthorized := f.delegate(t, e, false)
+	f.responder.Certificate = unauthorized
+	if _, err := QueryOCSP(f.srv.Client(), "", f.leaves[0], f.caCert); !errors.Is(err, ErrOCSPUnsuccessful) {
+		t.Fatalf("ответчик без OCSPSigning: %v", err)
+	}
+
+	// Ответ с тем же ключом, но сертификатом ответчика без OCSPSigning, отвергается клиентом
+	var raw ocspResponse
+	if _, err := asn1.Unmarshal(resp.Raw, &raw); err != nil {
+		t.Fatal(err)
+	}
+	var basic basicOCSPResponse
+	if _, err := asn1.Unmarshal(raw.Response.Response, &basic); err != nil {
+		t.Fatal(err)
+	}
+	basic.Certificates = []asn1.RawValue{{FullBytes: unauthorized.Raw}}
+	if raw.Response.Response, err = asn1.Marshal(basic); err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseOCSPResponse(der, f.caCert); !errors.Is(err, ErrKeyUsage) {
+		t.Fatalf("ответ ответчика без OCSPSigning: %v", err)
+	}
+
+	// Ответ не проверяется сертификатом другого УЦ
+	other := f.entity(t, "УЦ", true)
+	if _, err := ParseOCSPResponse(resp.Raw, other.issue(t, f.sign, nil, nil, nil)); err == nil {
+		t.Fatal("ответ принят для другого УЦ")
+	}
+}
+
+// Запрос о сертификате другого УЦ
+func TestOCSPUnauthorized(t *testing.T) {
+	f := newOCSPFixture(t)
+	other := f.entity(t, "Другой УЦ", true)
+	otherCert := other.issue(t, f.sign, nil, nil, nil)
+
+	_, err := QueryOCSP(f.srv.Client(), f.srv.URL, f.leaves[0], otherCert)
+	if !errors.Is(err, ErrOCSPUnsuccessful) || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("запрос о сертификате другого УЦ: %v", err)
+	}
+	id, err := NewOCSPCertID(big.NewInt(1), otherCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := (&OCSPRequest{CertID: id}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw ocspResponse
+	if _, err := asn1.Unmarshal(f.responder.Respond(req), &raw); err != nil || raw.Status != OCSPUnauthorized {
+		t.Fatalf("статус ответа %d: %v", raw.Status, err)
+	}
+}
+
+// Запрос методом GET: base64 в последнем сегменте пути, символ / экранирован как %2F
+func TestOCSPGet(t *testing.T) {
+	f := newOCSPFixture(t)
+	id, err := NewOCSPCertID(f.leaves[1].SerialNumber, f.caCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encoded string
+	for i := 0; !strings.Contains(encoded, "/"); i++ {
+		der, err := (&OCSPRequest{CertID: id, Nonce: []byte{byte(i), byte(i >> 8)}}).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded = base64.StdEncoding.EncodeToString(der)
+	}
+	escaped := url.PathEscape(encoded)
+	if !strings.Contains(escaped, "%2F") {
+		t.Fatalf("base64 не экранирован: %s", escaped)
+	}
+
+	get := func(path string) []byte {
+		resp, err := f.srv.Client().Get(f.srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != ocspResponseType {
+			t.Fatalf("%s: %s, %s", path, resp.Status, resp.Header.Get("Content-Type"))
+		}
+		der, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	resp, err := ParseOCSPResponse(get("/ocsp/"+escaped), f.caCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != OCSPRevoked || resp.CertID.SerialNumber.Cmp(f.leaves[1].SerialNumber) != 0 {
+		t.Fatalf("статус %d сертификата %s", resp.Status, resp.CertID.SerialNumber)
+	}
+
+	// Неверный base64 и неподдерживаемый метод
+	var raw ocspResponse
+	if _, err := asn1.Unmarshal(get("/ocsp/не-base64"), &raw); err != nil || raw.Status != OCSPMalformedRequest {
+		t.Fatalf("неверный запрос GET: статус %d, %v", raw.Status, err)
+	}
+	req, err := http.NewRequest(http.MethodPut, f.srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpResp, err := f.srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("метод PUT: %s", httpResp.Status)
+	}
+}
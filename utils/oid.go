@@ -0,0 +1,73 @@
+package utils
+
+// Идентификаторы алгоритмов ГОСТ для ASN.1 структур
+// RFC 4491 https://datatracker.ietf.org/doc/rfc4491/
+// RFC 9215 https://datatracker.ietf.org/doc/rfc9215/
+// OID наборов параметров кривых находятся в реестре, подробнее в utils/registry.go
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+var (
+	// Ключ ГОСТ Р 34.10-2001, id-GostR3410-2001
+	OIDPublicKeyGost2001 = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 19}
+	// Ключ ГОСТ Р 34.10-2012 256 бит, id-tc26-gost3410-12-256
+	OIDPublicKeyGost2012_256 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 1}
+	// Ключ ГОСТ Р 34.10-2012 512 бит, id-tc26-gost3410-12-512
+	OIDPublicKeyGost2012_512 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 2}
+
+	// Хеш-функция ГОСТ Р 34.11-94 с параметрами CryptoPro, id-GostR3411-94-CryptoProParamSet
+	OIDDigestGost94CryptoPro = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 30, 1}
+	// Стрибог 256, id-tc26-gost3411-12-256
+	OIDDigestStreebog256 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2}
+	// Стрибог 512, id-tc26-gost3411-12-512
+	OIDDigestStreebog512 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 3}
+
+	// Подпись ГОСТ Р 34.10-2012 256 бит с хешем Стрибог 256, id-tc26-signwithdigest-gost3410-12-256
+	OIDSignatureGost2012_256 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 2}
+	// Подпись ГОСТ Р 34.10-2012 512 бит с хешем Стрибог 512, id-tc26-signwithdigest-gost3410-12-512
+	OIDSignatureGost2012_512 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 3}
+
+	// Префикс OID наборов параметров CryptoPro, для них в параметрах ключа указывается хеш
+	oidCryptoPro = asn1.ObjectIdentifier{1, 2, 643, 2, 2}
+)
+
+// OID алгоритма ключа для режима 256/512
+func publicKeyAlgorithm(mode int) (asn1.ObjectIdentifier, error) {
+	switch mode {
+	case 256:
+		return OIDPublicKeyGost2012_256, nil
+	case 512:
+		return OIDPublicKeyGost2012_512, nil
+	}
+	return nil, fmt.Errorf("неподдерживаемый размер ключа: %d", mode)
+}
+
+// OID хеш-функции Стрибог для режима 256/512
+func digestAlgorithm(mode int) (asn1.ObjectIdentifier, error) {
+	switch mode {
+	case 256:
+		return OIDDigestStreebog256, nil
+	case 512:
+		return OIDDigestStreebog512, nil
+	}
+	return nil, fmt.Errorf("неподдерживаемый размер хеша: %d", mode)
+}
+
+// OID алгоритма подписи для режима 256/512
+func signatureAlgorithm(mode int) (asn1.ObjectIdentifier, error) {
+	switch mode {
+	case 256:
+		return OIDSignatureGost2012_256, nil
+	case 512:
+		return OIDSignatureGost2012_512, nil
+	}
+	return nil, fmt.Errorf("неподдерживаемый размер подписи: %d", mode)
+}
+
+// Проверка того, что OID начинается с префикса prefix
+func hasOIDPrefix(oid, prefix asn1.ObjectIdentifier) bool {
+	return len(oid) >= len(prefix) && prefix.Equal(oid[:len(prefix)])
+}
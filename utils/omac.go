@@ -0,0 +1,72 @@
+package utils
+
+// Режим выработки имитовставки ГОСТ Р 34.13-2015 п.5.6 (OMAC, он же CMAC)
+// Вспомогательные ключи: R = E_K(0^n), K1 = R << 1 ⊕ (B_n, если старший бит R равен 1),
+// K2 = K1 << 1 ⊕ (B_n, если старший бит K1 равен 1), B_128 = 0^120 || 10000111, B_64 = 0^59 || 11011
+// Последний полный блок складывается с K1, неполный дополняется 1 0...0 и складывается с K2
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// Сдвиг блока на один бит влево с добавлением B_n при переносе
+func omacShift(dst, src []byte) {
+	var carry byte
+	for i := len(src) - 1; i >= 0; i-- {
+		b := src[i]
+		dst[i] = b<<1 | carry
+		carry = b >> 7
+	}
+	rb := byte(0x87)
+	if len(src) == 8 {
+		rb = 0x1b
+	}
+	// Сложение с B_n за постоянное время
+	dst[len(dst)-1] ^= rb & -carry
+}
+
+// Вычисление имитовставки длиной в блок шифра над сообщением data
+func omac(block cipher.Block, data []byte) ([]byte, error) {
+	n := block.BlockSize()
+	if n != 8 && n != 16 {
+		return nil, fmt.Errorf("неподдерживаемая длина блока для имитовставки: %d", n)
+	}
+
+	k1 := make([]byte, n)
+	block.Encrypt(k1, k1)
+	omacShift(k1, k1)
+
+	// Последний блок: полный складывается с K1, неполный или пустой дополняется и складывается с K2
+	last := make([]byte, n)
+	full := len(data) > 0 && len(data)%n == 0
+	split := len(data) - len(data)%n
+	if full {
+		split = len(data) - n
+	}
+	copy(last, data[split:])
+	if full {
+		xorBytes(last, k1)
+	} else {
+		last[len(data)-split] = 0x80
+		k2 := make([]byte, n)
+		omacShift(k2, k1)
+		xorBytes(last, k2)
+	}
+
+	c := make([]byte, n)
+	for i := 0; i < split; i += n {
+		xorBytes(c, data[i:i+n])
+		block.Encrypt(c, c)
+	}
+	xorBytes(c, last)
+	block.Encrypt(c, c)
+	return c, nil
+}
+
+// Сложение dst ⊕= src
+func xorBytes(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+// Байты из шестнадцатеричной строки
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestOMAC(t *testing.T) {
+	newAES := func(key []byte) (cipher.Block, error) { return aes.NewCipher(key) }
+	newK := func(key []byte) (cipher.Block, error) { return newKuznyechik(key) }
+	newM := func(key []byte) (cipher.Block, error) { return newMagma(key) }
+
+	vectors := []struct {
+		name     string
+		newBlock func([]byte) (cipher.Block, error)
+		key      string
+		message  string
+		mac      string
+	}{
+		// ГОСТ Р 34.13-2015 А.1.6, имитовставка приведена полностью, в стандарте - старшие 64 бита
+		{"Кузнечик", newK, "8899aabbccddeeff0011223344556677fedcba98765432100123456789abcdef",
+			"1122334455667700ffeeddccbbaa998800112233445566778899aabbcceeff0a" +
+				"112233445566778899aabbcceeff0a002233445566778899aabbcceeff0a0011",
+			"336f4d296059fbe34ddeb35b37749c67"},
+		// ГОСТ Р 34.13-2015 А.2.6, в стандарте - старшие 32 бита
+		{"Магма", newM, "ffeeddccbbaa99887766554433221100f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+			"92def06b3c130a59db54c704f8189d204a98fb2e67a8024c8912409b17b57e41",
+			"154e72102030c5bb"},
+		// Дополнение неполного и пустого блока: RFC 4493, тот же алгоритм с AES
+		{"AES, пустое сообщение", newAES, "2b7e151628aed2a6abf7158809cf4f3c", "",
+			"bb1d6929e95937287fa37d129b756746"},
+		{"AES, неполный блок", newAES, "2b7e151628aed2a6abf7158809cf4f3c",
+			"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411",
+			"dfa66747de9ae63030ca32611497c827"},
+	}
+	for _, v := range vectors {
+		block, err := v.newBlock(mustHex(t, v.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mac, err := omac(block, mustHex(t, v.message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(mac, mustHex(t, v.mac)) {
+			t.Fatalf("%s: имитовставка %x, ожидалось %s", v.name, mac, v.mac)
+		}
+	}
+}
+
+// Р 50.1.113-2016, пример KDF_TREE_GOSTR3411_2012_256
+func TestKDFTree256(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	got := kdfTree256(key, mustHex(t, "26bdb878"), mustHex(t, "af21434145656378"), 64)
+	want := mustHex(t, "22b6837845c6bef65ea71672b265831086d3c76aebe6dae91cad51d83f79d16b"+
+		"074c9330599d7f8d712fca54392f4ddde93751206b3584c8f43f9e6dc51531f9")
+	if !bytes.Equal(got, want) {
+		t.Fatalf("KDF_TREE = %x, ожидалось %x", got, want)
+	}
+}
@@ -9,6 +9,27 @@ import (
 // ТК 26 https://www.cryptopro.ru/sites/default/files/blog/cpecc12-tc26.pdf
 // RFC 9215 https://datatracker.ietf.org/doc/rfc9215/
 
+// id-GostR3410-2001-TestParamSet
+// Тестовый набор параметров из ГОСТ Р 34.10-2001 и примера А.1 ГОСТ Р 34.10-2012
+func NewCurve256TestParamSet() *Curve {
+	a, _ := new(big.Int).SetString("07", 16)
+	b, _ := new(big.Int).SetString("5FBFF498AA938CE739B8E022FBAFEF40563F6E6A3472FC2A514C0CE9DAE23B7E", 16)
+	p, _ := new(big.Int).SetString("8000000000000000000000000000000000000000000000000000000000000431", 16)
+	q, _ := new(big.Int).SetString("8000000000000000000000000000000150FE8A1892976154C59CFC193ACCF5B3", 16)
+	x, _ := new(big.Int).SetString("02", 16)
+	y, _ := new(big.Int).SetString("08E2A8A0E65147D4BD6316030E16D19C85C97F0A9CA267122B96ABBCEA7E8FC8", 16)
+	return &Curve{
+		A: a,
+		B: b,
+		P: p,
+		Q: q,
+		X: x,
+		Y: y,
+
+		Cofactor: big.NewInt(1),
+	}
+}
+
 // id-GostR3410-2001-CryptoPro-A-ParamSet
 func NewCurve256CryptoProParamSetA() *Curve {
 	a, _ := new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffd94", 16)
@@ -24,6 +45,8 @@ func NewCurve256CryptoProParamSetA() *Curve {
 		Q: q,
 		X: x,
 		Y: y,
+
+		Cofactor: big.NewInt(1),
 	}
 }
 
@@ -42,6 +65,8 @@ func NewCurve256CryptoProParamSetB() *Curve {
 		Q: q,
 		X: x,
 		Y: y,
+
+		Cofactor: big.NewInt(1),
 	}
 }
 
@@ -60,6 +85,29 @@ func NewCurve256CryptoProParamSetC() *Curve {
 		Q: q,
 		X: x,
 		Y: y,
+
+		Cofactor: big.NewInt(1),
+	}
+}
+
+// id-tc26-gost-3410-12-512-paramSetTest
+// Тестовый набор параметров из примера А.2 ГОСТ Р 34.10-2012
+func NewCurve512TestParamSet() *Curve {
+	a, _ := new(big.Int).SetString("07", 16)
+	b, _ := new(big.Int).SetString("1CFF0806A31116DA29D8CFA54E57EB748BC5F377E49400FDD788B649ECA1AC4361834013B2AD7322480A89CA58E0CF74BC9E540C2ADD6897FAD0A3084F302ADC", 16)
+	p, _ := new(big.Int).SetString("4531ACD1FE0023C7550D267B6B2FEE80922B14B2FFB90F04D4EB7C09B5D2D15DF1D852741AF4704A0458047E80E4546D35B8336FAC224DD81664BBF528BE6373", 16)
+	q, _ := new(big.Int).SetString("4531ACD1FE0023C7550D267B6B2FEE80922B14B2FFB90F04D4EB7C09B5D2D15DA82F2D7ECB1DBAC719905C5EECC423F1D86E25EDBE23C595D644AAF187E6E6DF", 16)
+	x, _ := new(big.Int).SetString("24D19CC64572EE30F396BF6EBBFD7A6C5213B3B3D7057CC825F91093A68CD762FD60611262CD838DC6B60AA7EEE804E28BC849977FAC33B4B530F1B120248A9A", 16)
+	y, _ := new(big.Int).SetString("2BB312A43BD2CE6E0D020613C857ACDDCFBF061E91E5F2C3F32447C259F39B2C83AB156D77F1496BF7EB3351E1EE4E43DC1A18B91B24640B6DBB92CB1ADD371E", 16)
+	return &Curve{
+		A: a,
+		B: b,
+		P: p,
+		Q: q,
+		X: x,
+		Y: y,
+
+		Cofactor: big.NewInt(1),
 	}
 }
 
@@ -78,6 +126,8 @@ func NewCurve512ParamSetA() *Curve {
 		Q: q,
 		X: x,
 		Y: y,
+
+		Cofactor: big.NewInt(1),
 	}
 }
 
@@ -96,5 +146,59 @@ func NewCurve512ParamSetB() *Curve {
 		Q: q,
 		X: x,
 		Y: y,
+
+		Cofactor: big.NewInt(1),
+	}
+}
+
+// id-tc26-gost-3410-2012-256-paramSetA
+// Кривая в форме Вейерштрасса, имеющая представление в виде скрученной кривой Эдвардса
+// RFC 7836 https://datatracker.ietf.org/doc/rfc7836/
+func NewCurve256ParamSetA() *Curve {
+	a, _ := new(big.Int).SetString("C2173F1513981673AF4892C23035A27CE25E2013BF95AA33B22C656F277E7335", 16)
+	b, _ := new(big.Int).SetString("295F9BAE7428ED9CCC20E7C359A9D41A22FCCD9108E17BF7BA9337A6F8AE9513", 16)
+	p, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFD97", 16)
+	q, _ := new(big.Int).SetString("400000000000000000000000000000000FD8CDDFC87B6635C115AF556C360C67", 16)
+	x, _ := new(big.Int).SetString("91E38443A5E82C0D880923425712B2BB658B9196932E02C78B2582FE742DAA28", 16)
+	y, _ := new(big.Int).SetString("32879423AB1A0375895786C4BB46E9565FDE0B5344766740AF268ADB32322E5C", 16)
+	e, _ := new(big.Int).SetString("01", 16)
+	d, _ := new(big.Int).SetString("0605F6B7C183FA81578BC39CFAD518132B9DF62897009AF7E522C32D6DC7BFFB", 16)
+	return &Curve{
+		A: a,
+		B: b,
+		P: p,
+		Q: q,
+		X: x,
+		Y: y,
+
+		Cofactor: big.NewInt(4),
+		E:        e,
+		D:        d,
+	}
+}
+
+// id-tc26-gost-3410-2012-512-paramSetC
+// Кривая в форме Вейерштрасса, имеющая представление в виде скрученной кривой Эдвардса
+// RFC 7836 https://datatracker.ietf.org/doc/rfc7836/
+func NewCurve512ParamSetC() *Curve {
+	a, _ := new(big.Int).SetString("DC9203E514A721875485A529D2C722FB187BC8980EB866644DE41C68E143064546E861C0E2C9EDD92ADE71F46FCF50FF2AD97F951FDA9F2A2EB6546F39689BD3", 16)
+	b, _ := new(big.Int).SetString("B4C4EE28CEBC6C2C8AC12952CF37F16AC7EFB6A9F69F4B57FFDA2E4F0DE5ADE038CBC2FFF719D2C18DE0284B8BFEF3B52B8CC7A5F5BF0A3C8D2319A5312557E1", 16)
+	p, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFDC7", 16)
+	q, _ := new(big.Int).SetString("3FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFC98CDBA46506AB004C33A9FF5147502CC8EDA9E7A769A12694623CEF47F023ED", 16)
+	x, _ := new(big.Int).SetString("E2E31EDFC23DE7BDEBE241CE593EF5DE2295B7A9CBAEF021D385F7074CEA043AA27272A7AE602BF2A7B9033DB9ED3610C6FB85487EAE97AAC5BC7928C1950148", 16)
+	y, _ := new(big.Int).SetString("F5CE40D95B5EB899ABBCCFF5911CB8577939804D6527378B8C108C3D2090FF9BE18E2D33E3021ED2EF32D85822423B6304F726AA854BAE07D0396E9A9ADDC40F", 16)
+	e, _ := new(big.Int).SetString("01", 16)
+	d, _ := new(big.Int).SetString("9E4F5D8C017D8D9F13A5CF3CDF5BFE4DAB402D54198E31EBDE28A0621050439CA6B39E0A515C06B304E2CE43E79E369E91A0CFC2BC2A22B4CA302DBB33EE7550", 16)
+	return &Curve{
+		A: a,
+		B: b,
+		P: p,
+		Q: q,
+		X: x,
+		Y: y,
+
+		Cofactor: big.NewInt(4),
+		E:        e,
+		D:        d,
 	}
 }
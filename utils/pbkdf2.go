@@ -0,0 +1,32 @@
+package utils
+
+// Выработка ключа из пароля PBKDF2 с HMAC_GOSTR3411_2012_512
+// Р 50.1.111-2016, RFC 8018 п.5.2, RFC 9337 https://datatracker.ietf.org/doc/rfc9337/
+
+import "encoding/binary"
+
+// Длина выхода HMAC_GOSTR3411_2012_512 в байтах
+const pbkdf2HashSize = 64
+
+// Выработка ключа длиной keyLen байт из пароля и соли за iter итераций
+// DK = T_1 || T_2 || ..., T_i = U_1 ⊕ ... ⊕ U_c,
+// U_1 = HMAC(P, S || INT(i)), U_j = HMAC(P, U_{j-1})
+func pbkdf2Streebog(password, salt []byte, iter, keyLen int) []byte {
+	dk := make([]byte, 0, keyLen+pbkdf2HashSize)
+	for i := uint32(1); len(dk) < keyLen; i++ {
+		block := make([]byte, len(salt)+4)
+		copy(block, salt)
+		binary.BigEndian.PutUint32(block[len(salt):], i)
+
+		u := hmacStreebog(512, password, block)
+		t := append([]byte(nil), u...)
+		for j := 1; j < iter; j++ {
+			u = hmacStreebog(512, password, u)
+			for k := range t {
+				t[k] ^= u[k]
+			}
+		}
+		dk = append(dk, t...)
+	}
+	return dk[:keyLen]
+}
@@ -0,0 +1,319 @@
+package utils
+
+// Кодирование ключей в стандартных ASN.1 структурах
+// - публичный ключ: SubjectPublicKeyInfo (RFC 5280), значение ключа - OCTET STRING с X || Y в little-endian
+// - приватный ключ: PrivateKeyInfo PKCS#8 (RFC 5208), значение ключа - OCTET STRING с d в little-endian
+// Параметры алгоритма: GostR3410-2012-PublicKeyParameters (RFC 9215 п.4)
+//   SEQUENCE { publicKeyParamSet OID, digestParamSet OID OPTIONAL }
+// digestParamSet указывается только для 256-битных ключей с наборами параметров CryptoPro
+// Разбор приватного ключа совместим с OpenSSL gost-engine: принимаются OCTET STRING в little-endian,
+// INTEGER и маскированный ключ CryptoPro
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// Тип PEM блока публичного ключа
+	pemPublicKey = "PUBLIC KEY"
+	// Тип PEM блока приватного ключа
+	pemPrivateKey = "PRIVATE KEY"
+)
+
+// Идентификатор алгоритма AlgorithmIdentifier
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+// Параметры ключа ГОСТ Р 34.10
+// Для ключей ГОСТ Р 34.10-2001 после digestParamSet может следовать encryptionParamSet
+type gostKeyParameters struct {
+	PublicKeyParamSet  asn1.ObjectIdentifier
+	DigestParamSet     asn1.ObjectIdentifier `asn1:"optional"`
+	EncryptionParamSet asn1.ObjectIdentifier `asn1:"optional"`
+}
+
+// SubjectPublicKeyInfo
+type subjectPublicKeyInfo struct {
+	Algorithm algorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// PrivateKeyInfo PKCS#8
+type privateKeyInfo struct {
+	Version    int
+	Algorithm  algorithmIdentifier
+	PrivateKey []byte
+}
+
+// Маскированный приватный ключ CryptoPro
+type maskedPrivateKey struct {
+	MaskedKey []byte
+	PublicKey []byte `asn1:"optional"`
+}
+
+// Получение набора параметров ключа для кривой
+func keyParamSet(c *Curve) (*ParamSet, error) {
+	if c == nil {
+		return nil, fmt.Errorf("ключ не привязан к кривой")
+	}
+	ps, err := ParamSetByCurve(c)
+	if err != nil {
+		return nil, fmt.Errorf("ключ можно закодировать только для стандартного набора параметров: %w", err)
+	}
+	return ps, nil
+}
+
+// Формирование AlgorithmIdentifier ключа для набора параметров
+func keyAlgorithm(ps *ParamSet) (algorithmIdentifier, error) {
+	algo, err := publicKeyAlgorithm(ps.KeySize)
+	if err != nil {
+		return algorithmIdentifier{}, err
+	}
+	params := gostKeyParameters{PublicKeyParamSet: ps.OID}
+	if ps.KeySize == 256 && hasOIDPrefix(ps.OID, oidCryptoPro) {
+		params.DigestParamSet = OIDDigestStreebog256
+	}
+	der, err := asn1.Marshal(params)
+	if err != nil {
+		return algorithmIdentifier{}, err
+	}
+	return algorithmIdentifier{Algorithm: algo, Parameters: asn1.RawValue{FullBytes: der}}, nil
+}
+
+// Разбор AlgorithmIdentifier ключа, возвращает набор параметров
+func parseKeyAlgorithm(ai algorithmIdentifier) (*ParamSet, error) {
+	var keySize int
+	switch {
+	case ai.Algorithm.Equal(OIDPublicKeyGost2012_256), ai.Algorithm.Equal(OIDPublicKeyGost2001):
+		keySize = 256
+	case ai.Algorithm.Equal(OIDPublicKeyGost2012_512):
+		keySize = 512
+	default:
+		return nil, fmt.Errorf("неподдерживаемый алгоритм ключа: %s", ai.Algorithm)
+	}
+
+	var params gostKeyParameters
+	if rest, err := asn1.Unmarshal(ai.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("неверные параметры ключа: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после параметров ключа")
+	}
+	ps, err := ParamSetByOID(params.PublicKeyParamSet)
+	if err != nil {
+		return nil, err
+	}
+	if ps.KeySize != keySize {
+		return nil, fmt.Errorf("набор параметров %s не соответствует алгоритму ключа %s", ps.Name, ai.Algorithm)
+	}
+	return ps, nil
+}
+
+// Кодирование публичного ключа в SubjectPublicKeyInfo DER
+func MarshalPKIXPublicKey(pub *PublicKey) ([]byte, error) {
+	ps, err := keyParamSet(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	algo, err := keyAlgorithm(ps)
+	if err != nil {
+		return nil, err
+	}
+	value, err := asn1.Marshal(pub.Curve.MarshalGOST(pub.X, pub.Y))
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: algo,
+		PublicKey: asn1.BitString{Bytes: value, BitLength: 8 * len(value)},
+	})
+}
+
+// Разбор публичного ключа из SubjectPublicKeyInfo DER
+// Ключ проверяется ValidatePublicKey, подробнее в utils/pubkey_validate.go
+func ParsePKIXPublicKey(der []byte) (*PublicKey, error) {
+	var spki subjectPublicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, fmt.Errorf("неверная структура SubjectPublicKeyInfo: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после SubjectPublicKeyInfo")
+	}
+	return parsePublicKeyInfo(spki)
+}
+
+// Разбор публичного ключа из разобранной структуры SubjectPublicKeyInfo
+func parsePublicKeyInfo(spki subjectPublicKeyInfo) (*PublicKey, error) {
+	ps, err := parseKeyAlgorithm(spki.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	var value []byte
+	if rest, err := asn1.Unmarshal(spki.PublicKey.RightAlign(), &value); err != nil {
+		return nil, fmt.Errorf("неверное значение публичного ключа: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после значения публичного ключа")
+	}
+
+	c := ps.Curve()
+	x, y, err := c.UnmarshalGOST(value)
+	if err != nil {
+		return nil, err
+	}
+	pub := NewPublicKeyOnCurve(c, x, y)
+	if err := ValidatePublicKey(c, pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// Кодирование приватного ключа в PKCS#8 DER
+func MarshalPKCS8PrivateKey(priv *PrivateKey) ([]byte, error) {
+	ps, err := keyParamSet(priv.Curve)
+	if err != nil {
+		return nil, err
+	}
+	algo, err := keyAlgorithm(ps)
+	if err != nil {
+		return nil, err
+	}
+	size := ps.KeySize / 8
+	if priv.D.Sign() <= 0 || priv.D.Cmp(priv.Curve.Q) >= 0 {
+		return nil, fmt.Errorf("приватный ключ должен удовлетворять 0 < d < q")
+	}
+	value, err := asn1.Marshal(reverseBytes(priv.D.FillBytes(make([]byte, size))))
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(privateKeyInfo{
+		Version:    0,
+		Algorithm:  algo,
+		PrivateKey: value,
+	})
+}
+
+// Разбор приватного ключа из PKCS#8 DER
+func ParsePKCS8PrivateKey(der []byte) (*PrivateKey, error) {
+	var info privateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("неверная структура PKCS#8: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после PKCS#8")
+	}
+	if info.Version != 0 && info.Version != 1 {
+		return nil, fmt.Errorf("неподдерживаемая версия PKCS#8: %d", info.Version)
+	}
+	ps, err := parseKeyAlgorithm(info.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	c := ps.Curve()
+	d, err := parsePrivateKeyValue(c, ps.KeySize/8, info.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if d.Sign() <= 0 || d.Cmp(c.Q) >= 0 {
+		return nil, fmt.Errorf("приватный ключ должен удовлетворять 0 < d < q")
+	}
+	return NewPrivateKeyOnCurve(c, d), nil
+}
+
+// Разбор значения приватного ключа длиной size байт
+// - OCTET STRING: d в little-endian
+// - INTEGER: d
+// - SEQUENCE: маскированный ключ CryptoPro k || m1 || ... || mn, d = k·m1·...·mn (mod q)
+func parsePrivateKeyValue(c *Curve, size int, value []byte) (*big.Int, error) {
+	var raw asn1.RawValue
+	if rest, err := asn1.Unmarshal(value, &raw); err != nil {
+		return nil, fmt.Errorf("неверное значение приватного ключа: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после значения приватного ключа")
+	}
+
+	switch {
+	case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagOctetString:
+		if len(raw.Bytes) != size {
+			return nil, fmt.Errorf("неверная длина приватного ключа: %d, должна быть %d", len(raw.Bytes), size)
+		}
+		return new(big.Int).SetBytes(reverseBytes(raw.Bytes)), nil
+
+	case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagInteger:
+		var d *big.Int
+		if _, err := asn1.Unmarshal(value, &d); err != nil {
+			return nil, fmt.Errorf("неверное значение приватного ключа: %w", err)
+		}
+		return d, nil
+
+	case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagSequence:
+		var masked maskedPrivateKey
+		if _, err := asn1.Unmarshal(value, &masked); err != nil {
+			return nil, fmt.Errorf("неверный маскированный приватный ключ: %w", err)
+		}
+		n := len(masked.MaskedKey)
+		if n == 0 || n%size != 0 {
+			return nil, fmt.Errorf("неверная длина маскированного приватного ключа: %d", n)
+		}
+		d := new(big.Int).SetBytes(reverseBytes(masked.MaskedKey[:size]))
+		for i := size; i < n; i += size {
+			mask := new(big.Int).SetBytes(reverseBytes(masked.MaskedKey[i : i+size]))
+			d.Mul(d, mask)
+			d.Mod(d, c.Q)
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("неподдерживаемый формат значения приватного ключа")
+}
+
+// Кодирование публичного ключа в PEM с заголовком PUBLIC KEY
+func MarshalPublicKeyPEM(pub *PublicKey) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPublicKey, Bytes: der}), nil
+}
+
+// Разбор публичного ключа из PEM с заголовком PUBLIC KEY
+func ParsePublicKeyPEM(data []byte) (*PublicKey, error) {
+	der, err := decodePEM(data, pemPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePKIXPublicKey(der)
+}
+
+// Кодирование приватного ключа в PEM с заголовком PRIVATE KEY
+func MarshalPrivateKeyPEM(priv *PrivateKey) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPrivateKey, Bytes: der}), nil
+}
+
+// Разбор приватного ключа из PEM с заголовком PRIVATE KEY
+func ParsePrivateKeyPEM(data []byte) (*PrivateKey, error) {
+	der, err := decodePEM(data, pemPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePKCS8PrivateKey(der)
+}
+
+// Получение содержимого первого PEM блока с типом blockType
+func decodePEM(data []byte, blockType string) ([]byte, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("не найден PEM блок %s", blockType)
+		}
+		if block.Type == blockType {
+			return block.Bytes, nil
+		}
+	}
+}
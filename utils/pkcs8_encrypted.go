@@ -0,0 +1,378 @@
+package utils
+
+// Зашифрованный приватный ключ EncryptedPrivateKeyInfo PKCS#8
+// Схема PBES2 (RFC 8018) с алгоритмами ГОСТ по Р 50.1.111-2016 и RFC 9337:
+// - выработка ключа: PBKDF2 с HMAC_GOSTR3411_2012_512, ключ 32 байта
+// - шифрование: "Кузнечик" или "Магма" в режиме CTR-ACPKM-OMAC,
+//   параметры Gost3412-15-Encryption-Parameters ::= SEQUENCE { ukm OCTET STRING },
+//   ukm = IV || seed, IV длиной в половину блока - синхропосылка, seed длиной 8 байт
+// В режиме CTR-ACPKM-OMAC из ключа PBKDF2 K вырабатываются ключ шифрования и ключ имитовставки:
+// K_enc || K_mac = KDF_TREE_GOSTR3411_2012_256(K, "kdf tree", seed, R = 1),
+// зашифровывается PKCS#8 вместе с имитовставкой: CTR-ACPKM(K_enc, IV, M || OMAC(K_mac, M))
+// Неверный пароль и повреждение данных обнаруживаются по несовпадению имитовставки
+// Ключи в режиме CTR-ACPKM без имитовставки (ukm = IV) принимаются при разборе
+// для совместимости, но не записываются
+// Режим CFB определен для PBES2 только с шифром ГОСТ 28147-89 (RFC 4357) и не поддерживается
+// Размер секции CTR-ACPKM: 256 КБ для "Кузнечика", 8 КБ для "Магмы"
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// Схема PBES2, id-PBES2
+	oidPBES2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	// Выработка ключа PBKDF2, id-PBKDF2
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	// HMAC_GOSTR3411_2012_512, id-tc26-hmac-gost-3411-12-512
+	oidHMACStreebog512 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 4, 2}
+	// "Магма" в режиме CTR-ACPKM, id-gostr3412-2015-magma-ctracpkm
+	oidMagmaCTRACPKM = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 5, 1, 1}
+	// "Магма" в режиме CTR-ACPKM-OMAC, id-gostr3412-2015-magma-ctracpkm-omac
+	oidMagmaCTRACPKMOMAC = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 5, 1, 2}
+	// "Кузнечик" в режиме CTR-ACPKM, id-gostr3412-2015-kuznyechik-ctracpkm
+	oidKuznyechikCTRACPKM = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 5, 2, 1}
+	// "Кузнечик" в режиме CTR-ACPKM-OMAC, id-gostr3412-2015-kuznyechik-ctracpkm-omac
+	oidKuznyechikCTRACPKMOMAC = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 5, 2, 2}
+)
+
+const (
+	// Тип PEM блока зашифрованного приватного ключа
+	pemEncryptedPrivateKey = "ENCRYPTED PRIVATE KEY"
+	// Число итераций PBKDF2 при шифровании ключа
+	pbeIterations = 2000
+	// Максимальное число итераций при разборе, защищает от файлов с огромным значением
+	pbeMaxIterations = 1 << 24
+	// Длина соли в байтах
+	pbeSaltSize = 32
+	// Длина ключа шифрования в байтах
+	pbeKeySize = 32
+	// Длина seed для KDF_TREE в ukm режима CTR-ACPKM-OMAC
+	pbeKDFSeedSize = 8
+)
+
+// Метка KDF_TREE для выработки ключей шифрования и имитовставки
+var pbeKDFLabel = []byte("kdf tree")
+
+// Пароль неверен или зашифрованный ключ поврежден
+var ErrIncorrectPassword = errors.New("неверный пароль или поврежденный зашифрованный ключ")
+
+// Блочный шифр для шифрования приватного ключа
+type PBECipher int
+
+const (
+	// "Кузнечик" в режиме CTR-ACPKM-OMAC (по умолчанию)
+	PBEKuznyechik PBECipher = iota
+	// "Магма" в режиме CTR-ACPKM-OMAC
+	PBEMagma
+)
+
+// Название шифра
+func (pc PBECipher) String() string {
+	switch pc {
+	case PBEKuznyechik:
+		return "kuznyechik"
+	case PBEMagma:
+		return "magma"
+	}
+	return fmt.Sprintf("PBECipher(%d)", int(pc))
+}
+
+// Получение шифра по названию
+func ParsePBECipher(name string) (PBECipher, error) {
+	for _, pc := range []PBECipher{PBEKuznyechik, PBEMagma} {
+		if pc.String() == name {
+			return pc, nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестный шифр для защиты ключа: %s", name)
+}
+
+// Параметры шифра для защиты ключа
+type pbeCipherParams struct {
+	// OID режима CTR-ACPKM-OMAC и режима CTR-ACPKM без имитовставки
+	oid, oidNoMAC asn1.ObjectIdentifier
+	// Конструктор блочного шифра
+	newBlock func([]byte) (cipher.Block, error)
+	// Длина блока
+	blockSize int
+	// Размер секции CTR-ACPKM
+	sectionSize int
+}
+
+// Параметры шифра
+func (pc PBECipher) params() (*pbeCipherParams, error) {
+	switch pc {
+	case PBEKuznyechik:
+		return &pbeCipherParams{
+			oid:         oidKuznyechikCTRACPKMOMAC,
+			oidNoMAC:    oidKuznyechikCTRACPKM,
+			newBlock:    func(key []byte) (cipher.Block, error) { return newKuznyechik(key) },
+			blockSize:   kuznyechikBlockSize,
+			sectionSize: 256 * 1024,
+		}, nil
+	case PBEMagma:
+		return &pbeCipherParams{
+			oid:         oidMagmaCTRACPKMOMAC,
+			oidNoMAC:    oidMagmaCTRACPKM,
+			newBlock:    func(key []byte) (cipher.Block, error) { return newMagma(key) },
+			blockSize:   magmaBlockSize,
+			sectionSize: 8 * 1024,
+		}, nil
+	}
+	return nil, fmt.Errorf("неизвестный шифр для защиты ключа: %d", int(pc))
+}
+
+// EncryptedPrivateKeyInfo
+type encryptedPrivateKeyInfo struct {
+	Algorithm     algorithmIdentifier
+	EncryptedData []byte
+}
+
+// PBES2-params
+type pbes2Params struct {
+	KeyDerivationFunc algorithmIdentifier
+	EncryptionScheme  algorithmIdentifier
+}
+
+// PBKDF2-params
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                 `asn1:"optional"`
+	PRF            algorithmIdentifier `asn1:"optional"`
+}
+
+// Gost3412-15-Encryption-Parameters
+type gostEncryptionParams struct {
+	UKM []byte
+}
+
+// Наложение гаммы CTR-ACPKM на данные, зашифрование и расшифрование совпадают
+func (p *pbeCipherParams) crypt(key, iv, data []byte) ([]byte, error) {
+	stream, err := newCTRACPKM(p.newBlock, key, iv, p.sectionSize)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	stream.XORKeyStream(out, data)
+	return out, nil
+}
+
+// Выработка ключей шифрования и имитовставки из ключа PBKDF2 и seed из ukm
+func (p *pbeCipherParams) keys(key, seed []byte) ([]byte, cipher.Block, error) {
+	keys := kdfTree256(key, pbeKDFLabel, seed, 2*pbeKeySize)
+	mac, err := p.newBlock(keys[pbeKeySize:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return keys[:pbeKeySize], mac, nil
+}
+
+// Зашифрование в режиме CTR-ACPKM-OMAC: CTR-ACPKM(K_enc, IV, M || OMAC(K_mac, M))
+func (p *pbeCipherParams) seal(key, ukm, plain []byte) ([]byte, error) {
+	iv, seed := ukm[:p.blockSize/2], ukm[p.blockSize/2:]
+	encKey, mac, err := p.keys(key, seed)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := omac(mac, plain)
+	if err != nil {
+		return nil, err
+	}
+	return p.crypt(encKey, iv, concatBytes(plain, tag))
+}
+
+// Расшифрование в режиме CTR-ACPKM-OMAC с проверкой имитовставки
+// При несовпадении имитовставки возвращается ErrIncorrectPassword
+func (p *pbeCipherParams) open(key, ukm, encrypted []byte) ([]byte, error) {
+	if len(ukm) != p.blockSize/2+pbeKDFSeedSize {
+		return nil, fmt.Errorf("неверная длина ukm: %d, должна быть %d", len(ukm), p.blockSize/2+pbeKDFSeedSize)
+	}
+	if len(encrypted) < p.blockSize {
+		return nil, ErrIncorrectPassword
+	}
+	iv, seed := ukm[:p.blockSize/2], ukm[p.blockSize/2:]
+	encKey, mac, err := p.keys(key, seed)
+	if err != nil {
+		return nil, err
+	}
+	data, err := p.crypt(encKey, iv, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	plain, tag := data[:len(data)-p.blockSize], data[len(data)-p.blockSize:]
+	expected, err := omac(mac, plain)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(tag, expected) != 1 {
+		return nil, ErrIncorrectPassword
+	}
+	return plain, nil
+}
+
+// Шифрование приватного ключа паролем в EncryptedPrivateKeyInfo DER
+// Соль и синхропосылка берутся из rnd, если nil - используется crypto/rand
+func EncryptPKCS8PrivateKey(rnd io.Reader, priv *PrivateKey, password []byte, pc PBECipher) ([]byte, error) {
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	p, err := pc.params()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, pbeSaltSize)
+	ukm := make([]byte, p.blockSize/2+pbeKDFSeedSize)
+	if _, err := io.ReadFull(rnd, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rnd, ukm); err != nil {
+		return nil, err
+	}
+
+	key := pbkdf2Streebog(password, salt, pbeIterations, pbeKeySize)
+	encrypted, err := p.seal(key, ukm, plain)
+	if err != nil {
+		return nil, err
+	}
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbeIterations,
+		KeyLength:      pbeKeySize,
+		PRF:            algorithmIdentifier{Algorithm: oidHMACStreebog512, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encParams, err := asn1.Marshal(gostEncryptionParams{UKM: ukm})
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: algorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  algorithmIdentifier{Algorithm: p.oid, Parameters: asn1.RawValue{FullBytes: encParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     algorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+}
+
+// Расшифрование приватного ключа из EncryptedPrivateKeyInfo DER
+// При неверном пароле или поврежденных данных возвращается ErrIncorrectPassword
+func DecryptPKCS8PrivateKey(der []byte, password []byte) (*PrivateKey, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("неверная структура EncryptedPrivateKeyInfo: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после EncryptedPrivateKeyInfo")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("неподдерживаемая схема шифрования ключа: %s", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("неверные параметры PBES2: %w", err)
+	}
+
+	// Выработка ключа
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("неподдерживаемая функция выработки ключа: %s", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("неверные параметры PBKDF2: %w", err)
+	}
+	if !kdf.PRF.Algorithm.Equal(oidHMACStreebog512) {
+		return nil, fmt.Errorf("неподдерживаемая псевдослучайная функция PBKDF2: %s", kdf.PRF.Algorithm)
+	}
+	if kdf.IterationCount <= 0 || kdf.IterationCount > pbeMaxIterations {
+		return nil, fmt.Errorf("недопустимое число итераций PBKDF2: %d", kdf.IterationCount)
+	}
+	if kdf.KeyLength != 0 && kdf.KeyLength != pbeKeySize {
+		return nil, fmt.Errorf("неверная длина ключа шифрования: %d, должна быть %d", kdf.KeyLength, pbeKeySize)
+	}
+
+	// Шифр и режим
+	var p *pbeCipherParams
+	withMAC := false
+	alg := params.EncryptionScheme.Algorithm
+	for _, pc := range []PBECipher{PBEKuznyechik, PBEMagma} {
+		cp, err := pc.params()
+		if err != nil {
+			return nil, err
+		}
+		if alg.Equal(cp.oid) || alg.Equal(cp.oidNoMAC) {
+			p, withMAC = cp, alg.Equal(cp.oid)
+		}
+	}
+	if p == nil {
+		return nil, fmt.Errorf("неподдерживаемый шифр ключа: %s", alg)
+	}
+	var enc gostEncryptionParams
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &enc); err != nil {
+		return nil, fmt.Errorf("неверные параметры шифра ключа: %w", err)
+	}
+
+	key := pbkdf2Streebog(password, kdf.Salt, kdf.IterationCount, pbeKeySize)
+	var plain []byte
+	var err error
+	if withMAC {
+		plain, err = p.open(key, enc.UKM, info.EncryptedData)
+	} else {
+		plain, err = p.crypt(key, enc.UKM, info.EncryptedData)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// В режиме CTR-ACPKM без имитовставки неверный пароль обнаруживается
+	// только по неверной структуре расшифрованного PKCS#8
+	priv, err := ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		return nil, ErrIncorrectPassword
+	}
+	return priv, nil
+}
+
+// Шифрование приватного ключа в PEM с заголовком ENCRYPTED PRIVATE KEY
+func MarshalEncryptedPrivateKeyPEM(rnd io.Reader, priv *PrivateKey, password []byte, pc PBECipher) ([]byte, error) {
+	der, err := EncryptPKCS8PrivateKey(rnd, priv, password, pc)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemEncryptedPrivateKey, Bytes: der}), nil
+}
+
+// Расшифрование приватного ключа из PEM с заголовком ENCRYPTED PRIVATE KEY
+func ParseEncryptedPrivateKeyPEM(data []byte, password []byte) (*PrivateKey, error) {
+	der, err := decodePEM(data, pemEncryptedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptPKCS8PrivateKey(der, password)
+}
+
+// Проверка наличия PEM блока ENCRYPTED PRIVATE KEY
+func IsEncryptedPrivateKeyPEM(data []byte) bool {
+	_, err := decodePEM(data, pemEncryptedPrivateKey)
+	return err == nil
+}
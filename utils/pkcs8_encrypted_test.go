@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+// Ключевая пара для тестов шифрования ключа
+func pbeTestKey(t *testing.T) *PrivateKey {
+	t.Helper()
+	ps, err := ParamSetByName("id-tc26-gost-3410-2012-256-paramSetB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, priv, err := NewSigner(ps.Curve(), ps.HashMode).GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+// Изменение EncryptedPrivateKeyInfo функцией modify
+func modifyEncryptedKey(t *testing.T, der []byte, modify func(info *encryptedPrivateKeyInfo, params *pbes2Params, enc *gostEncryptionParams)) []byte {
+	t.Helper()
+	var info encryptedPrivateKeyInfo
+	var params pbes2Params
+	var enc gostEncryptionParams
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &enc); err != nil {
+		t.Fatal(err)
+	}
+	modify(&info, &params, &enc)
+
+	encParams, err := asn1.Marshal(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.EncryptionScheme.Parameters = asn1.RawValue{FullBytes: encParams}
+	raw, err := asn1.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.Algorithm.Parameters = asn1.RawValue{FullBytes: raw}
+	out, err := asn1.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestEncryptedPrivateKey(t *testing.T) {
+	priv := pbeTestKey(t)
+	password := []byte("пароль")
+
+	for _, pc := range []PBECipher{PBEKuznyechik, PBEMagma} {
+		der, err := EncryptPKCS8PrivateKey(nil, priv, password, pc)
+		if err != nil {
+			t.Fatalf("%s: %v", pc, err)
+		}
+		got, err := DecryptPKCS8PrivateKey(der, password)
+		if err != nil {
+			t.Fatalf("%s: %v", pc, err)
+		}
+		if !got.Equal(priv) {
+			t.Fatalf("%s: расшифрованный ключ не совпадает с исходным", pc)
+		}
+
+		// Неверный пароль
+		for _, wrong := range [][]byte{nil, []byte("Пароль"), []byte("пароль1")} {
+			if _, err := DecryptPKCS8PrivateKey(der, wrong); !errors.Is(err, ErrIncorrectPassword) {
+				t.Fatalf("%s: пароль %q: %v, ожидалось %v", pc, wrong, err, ErrIncorrectPassword)
+			}
+		}
+
+		// Изменение зашифрованных данных, включая имитовставку, и синхропосылки
+		tampered := map[string][]byte{
+			"первый байт": modifyEncryptedKey(t, der, func(info *encryptedPrivateKeyInfo, _ *pbes2Params, _ *gostEncryptionParams) {
+				info.EncryptedData[0] ^= 1
+			}),
+			"последний байт имитовставки": modifyEncryptedKey(t, der, func(info *encryptedPrivateKeyInfo, _ *pbes2Params, _ *gostEncryptionParams) {
+				info.EncryptedData[len(info.EncryptedData)-1] ^= 0x80
+			}),
+			"усечение": modifyEncryptedKey(t, der, func(info *encryptedPrivateKeyInfo, _ *pbes2Params, _ *gostEncryptionParams) {
+				info.EncryptedData = info.EncryptedData[:len(info.EncryptedData)-1]
+			}),
+			"пустые данные": modifyEncryptedKey(t, der, func(info *encryptedPrivateKeyInfo, _ *pbes2Params, _ *gostEncryptionParams) {
+				info.EncryptedData = []byte{}
+			}),
+			"синхропосылка": modifyEncryptedKey(t, der, func(_ *encryptedPrivateKeyInfo, _ *pbes2Params, enc *gostEncryptionParams) {
+				enc.UKM[0] ^= 1
+			}),
+			"seed": modifyEncryptedKey(t, der, func(_ *encryptedPrivateKeyInfo, _ *pbes2Params, enc *gostEncryptionParams) {
+				enc.UKM[len(enc.UKM)-1] ^= 1
+			}),
+		}
+		for name, data := range tampered {
+			if _, err := DecryptPKCS8PrivateKey(data, password); !errors.Is(err, ErrIncorrectPassword) {
+				t.Fatalf("%s, %s: %v, ожидалось %v", pc, name, err, ErrIncorrectPassword)
+			}
+		}
+
+		// ukm неверной длины
+		short := modifyEncryptedKey(t, der, func(_ *encryptedPrivateKeyInfo, _ *pbes2Params, enc *gostEncryptionParams) {
+			enc.UKM = enc.UKM[1:]
+		})
+		if _, err := DecryptPKCS8PrivateKey(short, password); err == nil {
+			t.Fatalf("%s: принят ukm неверной длины", pc)
+		}
+	}
+}
+
+// Ключи в режиме CTR-ACPKM без имитовставки принимаются для совместимости
+func TestEncryptedPrivateKeyNoMAC(t *testing.T) {
+	priv := pbeTestKey(t)
+	password := []byte("пароль")
+
+	for _, pc := range []PBECipher{PBEKuznyechik, PBEMagma} {
+		p, err := pc.params()
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, err := MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := EncryptPKCS8PrivateKey(nil, priv, password, pc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		legacy := modifyEncryptedKey(t, der, func(info *encryptedPrivateKeyInfo, params *pbes2Params, enc *gostEncryptionParams) {
+			var kdf pbkdf2Params
+			if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+				t.Fatal(err)
+			}
+			enc.UKM = enc.UKM[:p.blockSize/2]
+			params.EncryptionScheme.Algorithm = p.oidNoMAC
+			key := pbkdf2Streebog(password, kdf.Salt, kdf.IterationCount, pbeKeySize)
+			if info.EncryptedData, err = p.crypt(key, enc.UKM, plain); err != nil {
+				t.Fatal(err)
+			}
+		})
+		got, err := DecryptPKCS8PrivateKey(legacy, password)
+		if err != nil {
+			t.Fatalf("%s: %v", pc, err)
+		}
+		if !got.Equal(priv) {
+			t.Fatalf("%s: расшифрованный ключ не совпадает с исходным", pc)
+		}
+	}
+}
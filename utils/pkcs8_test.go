@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"testing"
+)
+
+// Ключи в структурах, которые выдает OpenSSL gost-engine (genpkey -algorithm gost2012_256 -pkeyopt paramset:A
+// и gost2012_512 -pkeyopt paramset:A): DER сформирован openssl asn1parse -genconf независимо от MarshalPKCS8PrivateKey,
+// d взят из приложения А ГОСТ Р 34.10-2012, Q = dP вычислен отдельной реализацией арифметики
+var pkcs8Fixtures = []struct {
+	key, pub string
+	paramSet string
+	d, x, y  string
+}{
+	{
+		key:      "testdata/gost2012-256-key.pem",
+		pub:      "testdata/gost2012-256-pub.pem",
+		paramSet: "id-GostR3410-2001-CryptoPro-A-ParamSet",
+		d:        "7A929ADE789BB9BE10ED359DD39A72C11B60961F49397EEE1D19CE9891EC3B28",
+		x:        "FD21C21AB0DC84C154F3D218E9040BEE64FFF48BDFF814B232295B09D0DF72E4",
+		y:        "5026DEC9AC4F07061A2A01D7A2307E0659239A82A95862DF86041D1458E45049",
+	},
+	{
+		key:      "testdata/gost2012-512-key.pem",
+		pub:      "testdata/gost2012-512-pub.pem",
+		paramSet: "id-tc26-gost-3410-2012-512-paramSetA",
+		d: "0BA6048AADAE241BA40936D47756D7C93091A0E8514669700EE7508E508B1020" +
+			"72E8123B2200A0563322DAD2827E2714A2636B7BFD18AADFC62967821FA18DD4",
+		x: "DCEF181EEF936EBF949F4E966976548125067EF9B6DC5940144EC99F7610063C" +
+			"FEEA097A6F5064093794957863A240E048882A25A9D0DB185B8175CFE61C66AA",
+		y: "670F9BED03D6AE001E9637B68154861AC4F68717ACBBFC8A78F528CE7CB00144" +
+			"7FE9E975EACC805EBF708DA139D2D7490EAAF6AB2476C5E3D79BB63D0F8047D6",
+	},
+}
+
+// Содержимое PEM файла и DER из его первого блока
+func readPEMFixture(t *testing.T, path string) ([]byte, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("%s: нет PEM блока", path)
+	}
+	return data, block.Bytes
+}
+
+func hexInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		t.Fatalf("неверное число %s", s)
+	}
+	return v
+}
+
+func TestPKCS8Fixtures(t *testing.T) {
+	for _, f := range pkcs8Fixtures {
+		ps, err := ParamSetByName(f.paramSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keyPEM, keyDER := readPEMFixture(t, f.key)
+		priv, err := ParsePrivateKeyPEM(keyPEM)
+		if err != nil {
+			t.Fatalf("%s: %v", f.key, err)
+		}
+		if priv.D.Cmp(hexInt(t, f.d)) != 0 || !sameCurve(priv.Curve, ps.Curve()) {
+			t.Fatalf("%s: d = %x, ожидалось %s на кривой %s", f.key, priv.D, f.d, f.paramSet)
+		}
+		der, err := MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(der, keyDER) {
+			t.Fatalf("%s: MarshalPKCS8PrivateKey\n%x\nожидалось\n%x", f.key, der, keyDER)
+		}
+		if data, err := MarshalPrivateKeyPEM(priv); err != nil || !bytes.Equal(data, keyPEM) {
+			t.Fatalf("%s: MarshalPrivateKeyPEM не совпадает с файлом: %v", f.key, err)
+		}
+
+		pubPEM, pubDER := readPEMFixture(t, f.pub)
+		pub, err := ParsePublicKeyPEM(pubPEM)
+		if err != nil {
+			t.Fatalf("%s: %v", f.pub, err)
+		}
+		if pub.X.Cmp(hexInt(t, f.x)) != 0 || pub.Y.Cmp(hexInt(t, f.y)) != 0 {
+			t.Fatalf("%s: Q = (%x, %x)", f.pub, pub.X, pub.Y)
+		}
+		der, err = MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(der, pubDER) {
+			t.Fatalf("%s: MarshalPKIXPublicKey\n%x\nожидалось\n%x", f.pub, der, pubDER)
+		}
+		if data, err := MarshalPublicKeyPEM(pub); err != nil || !bytes.Equal(data, pubPEM) {
+			t.Fatalf("%s: MarshalPublicKeyPEM не совпадает с файлом: %v", f.pub, err)
+		}
+
+		if !priv.Public().(*PublicKey).Equal(pub) {
+			t.Fatalf("%s: публичный ключ не соответствует приватному", f.key)
+		}
+	}
+}
+
+func TestPKCS8RoundTrip(t *testing.T) {
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		pub, priv, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		der, err := MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		parsedPriv, err := ParsePKCS8PrivateKey(der)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		if !parsedPriv.Equal(priv) {
+			t.Fatalf("%s: приватный ключ не совпадает после разбора", ps.Name)
+		}
+
+		der, err = MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		parsedPub, err := ParsePKIXPublicKey(der)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		if !parsedPub.Equal(pub) {
+			t.Fatalf("%s: публичный ключ не совпадает после разбора", ps.Name)
+		}
+	}
+}
+
+// Значения приватного ключа в виде INTEGER и маскированного ключа CryptoPro
+func TestPKCS8PrivateKeyValues(t *testing.T) {
+	f := pkcs8Fixtures[0]
+	_, keyDER := readPEMFixture(t, f.key)
+	var info privateKeyInfo
+	if _, err := asn1.Unmarshal(keyDER, &info); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := ParamSetByName(f.paramSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := ps.Curve().Q
+	d := hexInt(t, f.d)
+
+	integer, err := asn1.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// d = k·m (mod q)
+	mask := big.NewInt(0x1234567)
+	k := new(big.Int).ModInverse(mask, q)
+	k.Mul(k, d).Mod(k, q)
+	masked, err := asn1.Marshal(maskedPrivateKey{
+		MaskedKey: append(reverseBytes(k.FillBytes(make([]byte, 32))), reverseBytes(mask.FillBytes(make([]byte, 32)))...),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string][]byte{"INTEGER": integer, "маскированный ключ": masked} {
+		info.PrivateKey = value
+		der, err := asn1.Marshal(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		priv, err := ParsePKCS8PrivateKey(der)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if priv.D.Cmp(d) != 0 {
+			t.Fatalf("%s: d = %x, ожидалось %x", name, priv.D, d)
+		}
+	}
+}
+
+func TestPKCS8Rejects(t *testing.T) {
+	f := pkcs8Fixtures[0]
+	_, keyDER := readPEMFixture(t, f.key)
+	_, pubDER := readPEMFixture(t, f.pub)
+
+	// Ключ 256 бит с алгоритмом 512 бит
+	oid256, _ := asn1.Marshal(OIDPublicKeyGost2012_256)
+	oid512, _ := asn1.Marshal(OIDPublicKeyGost2012_512)
+	wrongSize := bytes.Replace(keyDER, oid256, oid512, 1)
+	// d = 0
+	zero := append([]byte(nil), keyDER...)
+	for i := len(zero) - 32; i < len(zero); i++ {
+		zero[i] = 0
+	}
+	// Y + 1: точка не на кривой
+	offCurve := append([]byte(nil), pubDER...)
+	offCurve[len(offCurve)-32]++
+
+	rejects := map[string][]byte{
+		"лишние данные":          append(append([]byte(nil), keyDER...), 0),
+		"размер ключа":           wrongSize,
+		"нулевой приватный ключ": zero,
+		"обрезанный DER":         keyDER[:len(keyDER)-1],
+	}
+	for name, der := range rejects {
+		if _, err := ParsePKCS8PrivateKey(der); err == nil {
+			t.Fatalf("%s: принят приватный ключ", name)
+		}
+	}
+	if _, err := ParsePKIXPublicKey(offCurve); err == nil {
+		t.Fatal("принят публичный ключ не на кривой")
+	}
+	if _, err := ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pubDER})); err == nil {
+		t.Fatal("принят PEM блок неверного типа")
+	}
+
+	// Ключ без кривой и ключ собственной кривой не кодируются
+	if _, err := MarshalPKCS8PrivateKey(NewPrivateKey(i2)); err == nil {
+		t.Fatal("закодирован ключ без кривой")
+	}
+	c := copyCurve(NewCurve256ParamSetA())
+	c.X, c.Y = c.Exp(i2, c.X, c.Y)
+	if _, err := MarshalPKIXPublicKey(NewPublicKeyOnCurve(c, c.X, c.Y)); err == nil {
+		t.Fatal("закодирован ключ нестандартной кривой")
+	}
+}
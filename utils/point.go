@@ -0,0 +1,190 @@
+package utils
+
+// Арифметика точек эллиптической кривой в проективных координатах
+// Используются полные формулы сложения (Renes, Costello, Batina, 2016)
+// для кривой y^2 = x^3 + ax + b: одна формула обрабатывает сложение,
+// удвоение и точку на бесконечности без ветвлений,
+// что позволяет выполнять умножение на скаляр за постоянное время.
+
+import (
+	"math/big"
+)
+
+// Точка в проективных координатах (X : Y : Z), x = X/Z, y = Y/Z
+// Точка на бесконечности - (0 : 1 : 0)
+type point struct {
+	x, y, z fieldElement
+}
+
+// Параметры кривой во внутреннем представлении
+type curveArith struct {
+	f *field
+	// Коэффициент a
+	a fieldElement
+	// 3b
+	b3 fieldElement
+	// Длина скаляра в байтах, достаточная для чисел меньше q
+	scalarLen int
+	// Кратные базовой точки P, общие для всех умножений на P
+	base pointTable
+}
+
+// Таблица кратных точки table[i] = i·p для умножения с окном 4 бита
+type pointTable [16]point
+
+// "Конструктор" для типа curveArith
+func newCurveArith(c *Curve) *curveArith {
+	f := newField(c.P)
+	ca := &curveArith{
+		f:         f,
+		scalarLen: (c.Q.BitLen() + 7) / 8,
+	}
+	f.fromBig(&ca.a, c.A)
+	f.fromBig(&ca.b3, new(big.Int).Mul(c.B, i3))
+
+	var g point
+	ca.fromAffine(&g, c.X, c.Y)
+	ca.precompute(&ca.base, &g)
+	return ca
+}
+
+// Точка на бесконечности
+func (ca *curveArith) identity(r *point) {
+	*r = point{}
+	r.y = ca.f.one
+}
+
+// Перевод точки из аффинных координат
+// Пара (0, 0) не лежит на кривых с b != 0 и обозначает точку на бесконечности
+func (ca *curveArith) fromAffine(r *point, x, y *big.Int) {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		ca.identity(r)
+		return
+	}
+	ca.f.fromBig(&r.x, x)
+	ca.f.fromBig(&r.y, y)
+	r.z = ca.f.one
+}
+
+// Перевод точки в аффинные координаты
+// Для точки на бесконечности возвращается (0, 0)
+func (ca *curveArith) toAffine(p *point) (*big.Int, *big.Int) {
+	var zInv, x, y fieldElement
+	ca.f.inv(&zInv, &p.z)
+	ca.f.mul(&x, &p.x, &zInv)
+	ca.f.mul(&y, &p.y, &zInv)
+	return ca.f.toBig(&x), ca.f.toBig(&y)
+}
+
+// Сложение точек r = p + q
+// Алгоритм 1 из статьи "Complete addition formulas for prime order elliptic curves"
+func (ca *curveArith) add(r, p, q *point) {
+	f := ca.f
+	var t0, t1, t2, t3, t4, t5, x3, y3, z3 fieldElement
+
+	f.mul(&t0, &p.x, &q.x)
+	f.mul(&t1, &p.y, &q.y)
+	f.mul(&t2, &p.z, &q.z)
+	f.add(&t3, &p.x, &p.y)
+	f.add(&t4, &q.x, &q.y)
+	f.mul(&t3, &t3, &t4)
+	f.add(&t4, &t0, &t1)
+	f.sub(&t3, &t3, &t4)
+	f.add(&t4, &p.x, &p.z)
+	f.add(&t5, &q.x, &q.z)
+	f.mul(&t4, &t4, &t5)
+	f.add(&t5, &t0, &t2)
+	f.sub(&t4, &t4, &t5)
+	f.add(&t5, &p.y, &p.z)
+	f.add(&x3, &q.y, &q.z)
+	f.mul(&t5, &t5, &x3)
+	f.add(&x3, &t1, &t2)
+	f.sub(&t5, &t5, &x3)
+	f.mul(&z3, &ca.a, &t4)
+	f.mul(&x3, &ca.b3, &t2)
+	f.add(&z3, &x3, &z3)
+	f.sub(&x3, &t1, &z3)
+	f.add(&z3, &t1, &z3)
+	f.mul(&y3, &x3, &z3)
+	f.add(&t1, &t0, &t0)
+	f.add(&t1, &t1, &t0)
+	f.mul(&t2, &ca.a, &t2)
+	f.mul(&t4, &ca.b3, &t4)
+	f.add(&t1, &t1, &t2)
+	f.sub(&t2, &t0, &t2)
+	f.mul(&t2, &ca.a, &t2)
+	f.add(&t4, &t4, &t2)
+	f.mul(&t0, &t1, &t4)
+	f.add(&y3, &y3, &t0)
+	f.mul(&t0, &t5, &t4)
+	f.mul(&x3, &t3, &x3)
+	f.sub(&x3, &x3, &t0)
+	f.mul(&t0, &t3, &t1)
+	f.mul(&z3, &t5, &z3)
+	f.add(&z3, &z3, &t0)
+
+	r.x, r.y, r.z = x3, y3, z3
+}
+
+// Условный выбор точки r = cond ? p : q
+func (ca *curveArith) selectPoint(r, p, q *point, cond uint64) {
+	ca.f.selectElement(&r.x, &p.x, &q.x, cond)
+	ca.f.selectElement(&r.y, &p.y, &q.y, cond)
+	ca.f.selectElement(&r.z, &p.z, &q.z, cond)
+}
+
+// Умножение точки на скаляр r = k·p
+// k - число в big-endian представлении фиксированной длины
+// Окно шириной 4 бита, выбор из таблицы и сложение выполняются за постоянное время
+func (ca *curveArith) scalarMult(r *point, k []byte, p *point) {
+	var table pointTable
+	ca.precompute(&table, p)
+	ca.scalarMultTable(r, k, &table)
+}
+
+// Вычисление таблицы кратных table[i] = i·p
+func (ca *curveArith) precompute(table *pointTable, p *point) {
+	ca.identity(&table[0])
+	table[1] = *p
+	for i := 2; i < 16; i++ {
+		ca.add(&table[i], &table[i-1], p)
+	}
+}
+
+// Умножение на скаляр по заранее вычисленной таблице кратных точки
+// Таблица только читается, поэтому может использоваться из нескольких горутин
+func (ca *curveArith) scalarMultTable(r *point, k []byte, table *pointTable) {
+	var acc, t point
+	ca.identity(&acc)
+	for _, b := range k {
+		for _, w := range [2]uint64{uint64(b >> 4), uint64(b & 0x0f)} {
+			for i := 0; i < 4; i++ {
+				ca.add(&acc, &acc, &acc)
+			}
+			t = table[0]
+			for i := 1; i < 16; i++ {
+				// eq = 1 если i == w
+				d := uint64(i) ^ w
+				eq := 1 ^ ((d | -d) >> 63)
+				ca.selectPoint(&t, &table[i], &t, eq)
+			}
+			ca.add(&acc, &acc, &t)
+		}
+	}
+	*r = acc
+}
+
+// Перевод скаляра в big-endian представление фиксированной длины
+// Длина определяется порядком подгруппы q, поэтому не зависит от значения скаляра меньше q
+func (ca *curveArith) scalarBytes(k *big.Int) []byte {
+	size := ca.scalarLen
+	if l := (k.BitLen() + 7) / 8; l > size {
+		size = l
+	}
+	return new(big.Int).Abs(k).FillBytes(make([]byte, size))
+}
+
+// Проверка равенства точки на бесконечности, возвращает 1 если p = (0 : Y : 0), Y != 0
+func (ca *curveArith) isIdentity(p *point) uint64 {
+	return ca.f.isZero(&p.x) & ca.f.isZero(&p.z) & (ca.f.isZero(&p.y) ^ 1)
+}
@@ -0,0 +1,138 @@
+package utils
+
+// Кодирование точек эллиптической кривой
+// - форма ГОСТ (RFC 4491): X || Y, каждая координата в little-endian
+// - SEC 1 несжатая форма: 04 || X || Y в big-endian
+// - SEC 1 сжатая форма: 02/03 || X в big-endian, младший бит Y в префиксе
+// При разборе любой формы проверяется принадлежность точки кривой
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Длина координаты в байтах
+func (c *Curve) coordSize() int {
+	return (c.P.BitLen() + 7) / 8
+}
+
+// Кодирование точки в форме ГОСТ: X || Y в little-endian
+func (c *Curve) MarshalGOST(x, y *big.Int) []byte {
+	size := c.coordSize()
+	out := make([]byte, 2*size)
+	copy(out[:size], reverseBytes(x.FillBytes(make([]byte, size))))
+	copy(out[size:], reverseBytes(y.FillBytes(make([]byte, size))))
+	return out
+}
+
+// Разбор точки в форме ГОСТ: X || Y в little-endian
+func (c *Curve) UnmarshalGOST(data []byte) (*big.Int, *big.Int, error) {
+	size := c.coordSize()
+	if len(data) != 2*size {
+		return nil, nil, fmt.Errorf("неверная длина точки: %d, должна быть %d", len(data), 2*size)
+	}
+	x := new(big.Int).SetBytes(reverseBytes(data[:size]))
+	y := new(big.Int).SetBytes(reverseBytes(data[size:]))
+	if !c.IsOnCurve(x, y) {
+		return nil, nil, fmt.Errorf("точка не лежит на кривой")
+	}
+	return x, y, nil
+}
+
+// Кодирование точки в несжатой форме SEC 1: 04 || X || Y
+// Точка на бесконечности (0, 0) кодируется одним нулевым байтом
+func (c *Curve) MarshalUncompressed(x, y *big.Int) []byte {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return []byte{0}
+	}
+	size := c.coordSize()
+	out := make([]byte, 1+2*size)
+	out[0] = 4
+	x.FillBytes(out[1 : 1+size])
+	y.FillBytes(out[1+size:])
+	return out
+}
+
+// Кодирование точки в сжатой форме SEC 1: 02 || X для четного Y, 03 || X для нечетного
+// Точка на бесконечности (0, 0) кодируется одним нулевым байтом
+func (c *Curve) MarshalCompressed(x, y *big.Int) []byte {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return []byte{0}
+	}
+	size := c.coordSize()
+	out := make([]byte, 1+size)
+	out[0] = 2 | byte(y.Bit(0))
+	x.FillBytes(out[1:])
+	return out
+}
+
+// Разбор точки в форме SEC 1, сжатой или несжатой
+// Точка на бесконечности не может быть открытым ключом и отвергается
+func (c *Curve) Unmarshal(data []byte) (*big.Int, *big.Int, error) {
+	size := c.coordSize()
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("пустое представление точки")
+	}
+
+	switch data[0] {
+	case 4:
+		if len(data) != 1+2*size {
+			return nil, nil, fmt.Errorf("неверная длина несжатой точки: %d, должна быть %d", len(data), 1+2*size)
+		}
+		x := new(big.Int).SetBytes(data[1 : 1+size])
+		y := new(big.Int).SetBytes(data[1+size:])
+		if !c.IsOnCurve(x, y) {
+			return nil, nil, fmt.Errorf("точка не лежит на кривой")
+		}
+		return x, y, nil
+	case 2, 3:
+		if len(data) != 1+size {
+			return nil, nil, fmt.Errorf("неверная длина сжатой точки: %d, должна быть %d", len(data), 1+size)
+		}
+		x := new(big.Int).SetBytes(data[1:])
+		y, err := c.Decompress(x, uint(data[0]&1))
+		if err != nil {
+			return nil, nil, err
+		}
+		return x, y, nil
+	case 0:
+		return nil, nil, fmt.Errorf("точка на бесконечности не допускается")
+	default:
+		return nil, nil, fmt.Errorf("неизвестный префикс представления точки: %#02x", data[0])
+	}
+}
+
+// Восстановление координаты Y по координате X и младшему биту Y
+// y = sqrt(x^3 + ax + b) (mod p)
+func (c *Curve) Decompress(x *big.Int, yBit uint) (*big.Int, error) {
+	if x.Sign() < 0 || x.Cmp(c.P) >= 0 {
+		return nil, fmt.Errorf("координата X должна лежать в интервале [0, p)")
+	}
+
+	// x^3 + ax + b
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Add(rhs, c.A)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, c.B)
+	rhs.Mod(rhs, c.P)
+
+	// Квадратный корень существует только для квадратичного вычета
+	y := new(big.Int).ModSqrt(rhs, c.P)
+	if y == nil {
+		return nil, fmt.Errorf("точка с такой координатой X не лежит на кривой")
+	}
+
+	// Выбор корня с нужным младшим битом
+	if y.Bit(0) != yBit&1 {
+		y.Sub(c.P, y)
+	}
+	// Для y = 0 второго корня нет
+	if y.Bit(0) != yBit&1 {
+		return nil, fmt.Errorf("неверный бит четности координаты Y")
+	}
+
+	if !c.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("точка не лежит на кривой")
+	}
+	return y, nil
+}
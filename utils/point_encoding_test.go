@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestPointEncoding(t *testing.T) {
+	rnd := rand.New(rand.NewSource(6))
+	for _, ps := range ParamSets() {
+		c := ps.Curve()
+		size := c.coordSize()
+
+		points := [][2]*big.Int{{c.X, c.Y}}
+		for i := 0; i < 8; i++ {
+			x, y := c.Exp(new(big.Int).Rand(rnd, c.Q), c.X, c.Y)
+			points = append(points, [2]*big.Int{x, y})
+		}
+		// Точка с противоположной четностью Y
+		points = append(points, [2]*big.Int{c.X, new(big.Int).Sub(c.P, c.Y)})
+
+		for _, pt := range points {
+			x, y := pt[0], pt[1]
+
+			gost := c.MarshalGOST(x, y)
+			if len(gost) != 2*size || gost[0] != byte(x.Uint64()) || gost[size] != byte(y.Uint64()) {
+				t.Fatalf("%s: неверное представление ГОСТ %x", ps.Name, gost)
+			}
+			if gx, gy, err := c.UnmarshalGOST(gost); err != nil || gx.Cmp(x) != 0 || gy.Cmp(y) != 0 {
+				t.Fatalf("%s: разбор представления ГОСТ: %v", ps.Name, err)
+			}
+
+			uncompressed := c.MarshalUncompressed(x, y)
+			if len(uncompressed) != 1+2*size || uncompressed[0] != 4 {
+				t.Fatalf("%s: неверная несжатая форма %x", ps.Name, uncompressed)
+			}
+			if ux, uy, err := c.Unmarshal(uncompressed); err != nil || ux.Cmp(x) != 0 || uy.Cmp(y) != 0 {
+				t.Fatalf("%s: разбор несжатой формы: %v", ps.Name, err)
+			}
+
+			compressed := c.MarshalCompressed(x, y)
+			if len(compressed) != 1+size || compressed[0] != 2|byte(y.Bit(0)) {
+				t.Fatalf("%s: неверная сжатая форма %x", ps.Name, compressed)
+			}
+			if cx, cy, err := c.Unmarshal(compressed); err != nil || cx.Cmp(x) != 0 || cy.Cmp(y) != 0 {
+				t.Fatalf("%s: разбор сжатой формы: %v", ps.Name, err)
+			}
+		}
+
+		zero := big.NewInt(0)
+		if !bytes.Equal(c.MarshalUncompressed(zero, zero), []byte{0}) || !bytes.Equal(c.MarshalCompressed(zero, zero), []byte{0}) {
+			t.Fatalf("%s: неверное представление точки на бесконечности", ps.Name)
+		}
+	}
+}
+
+// Наименьшее x, для которого x^3 + ax + b не является квадратичным вычетом
+func nonResidueX(c *Curve) *big.Int {
+	for x := big.NewInt(0); ; x.Add(x, i1) {
+		rhs := new(big.Int).Mul(x, x)
+		rhs.Add(rhs, c.A).Mul(rhs, x).Add(rhs, c.B).Mod(rhs, c.P)
+		if big.Jacobi(rhs, c.P) == -1 {
+			return x
+		}
+	}
+}
+
+func TestPointEncodingRejects(t *testing.T) {
+	for _, ps := range ParamSets() {
+		c := ps.Curve()
+		size := c.coordSize()
+		offY := new(big.Int).Add(c.Y, i1)
+
+		noRoot := make([]byte, 1+size)
+		noRoot[0] = 2
+		nonResidueX(c).FillBytes(noRoot[1:])
+
+		bigX := make([]byte, 1+size)
+		bigX[0] = 3
+		c.P.FillBytes(bigX[1:])
+
+		badPrefix := c.MarshalUncompressed(c.X, c.Y)
+		badPrefix[0] = 6
+
+		rejects := map[string][]byte{
+			"точка не на кривой":         c.MarshalUncompressed(c.X, offY),
+			"неверный префикс":           badPrefix,
+			"нет квадратного корня":      noRoot,
+			"X не меньше p":              bigX,
+			"точка на бесконечности":     {0},
+			"пустые данные":              nil,
+			"длина несжатой точки":       c.MarshalUncompressed(c.X, c.Y)[:size],
+			"длина сжатой точки":         append(c.MarshalCompressed(c.X, c.Y), 0),
+			"префикс без данных":         {4},
+			"сжатая точка без X":         {2},
+			"лишний байт после несжатой": append(c.MarshalUncompressed(c.X, c.Y), 0),
+		}
+		for name, data := range rejects {
+			if _, _, err := c.Unmarshal(data); err == nil {
+				t.Fatalf("%s: принято представление %q: %x", ps.Name, name, data)
+			}
+		}
+
+		if _, _, err := c.UnmarshalGOST(c.MarshalGOST(c.X, offY)); err == nil {
+			t.Fatalf("%s: принята точка не на кривой в представлении ГОСТ", ps.Name)
+		}
+		if _, _, err := c.UnmarshalGOST(c.MarshalGOST(c.X, c.Y)[1:]); err == nil {
+			t.Fatalf("%s: принято представление ГОСТ неверной длины", ps.Name)
+		}
+		if _, err := c.Decompress(nonResidueX(c), 0); err == nil {
+			t.Fatalf("%s: восстановлена точка без квадратного корня", ps.Name)
+		}
+	}
+}
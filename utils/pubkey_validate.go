@@ -0,0 +1,56 @@
+package utils
+
+// Проверка публичного ключа перед проверкой подписи и выработкой общего ключа
+// Защищает от атак с точками на другой кривой (invalid-curve)
+// и точками малого порядка (small-subgroup)
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Ошибка проверки публичного ключа
+type InvalidPublicKeyError struct {
+	// Причина, по которой ключ отвергнут
+	Reason string
+}
+
+// Текст ошибки
+func (e *InvalidPublicKeyError) Error() string {
+	return fmt.Sprintf("неверный публичный ключ: %s", e.Reason)
+}
+
+// Проверка публичного ключа Q = (x, y) для кривой c:
+// - ключ относится к кривой c, если его кривая задана
+// - 0 <= x, y < p
+// - Q лежит на кривой
+// - Q не является точкой на бесконечности
+// - q·Q = O, то есть Q лежит в подгруппе порядка q
+func ValidatePublicKey(c *Curve, pubKey *PublicKey) error {
+	if pubKey == nil || pubKey.X == nil || pubKey.Y == nil {
+		return &InvalidPublicKeyError{Reason: "координаты не заданы"}
+	}
+	if pubKey.Curve != nil && !sameCurve(c, pubKey.Curve) {
+		return &InvalidPublicKeyError{Reason: "ключ относится к другой кривой"}
+	}
+	if pubKey.X.Sign() == 0 && pubKey.Y.Sign() == 0 {
+		return &InvalidPublicKeyError{Reason: "точка на бесконечности"}
+	}
+	for _, v := range []*big.Int{pubKey.X, pubKey.Y} {
+		if v.Sign() < 0 || v.Cmp(c.P) >= 0 {
+			return &InvalidPublicKeyError{Reason: "координаты должны лежать в интервале [0, p)"}
+		}
+	}
+	if !c.IsOnCurve(pubKey.X, pubKey.Y) {
+		return &InvalidPublicKeyError{Reason: "точка не лежит на кривой"}
+	}
+	if !c.hasOrder(pubKey.X, pubKey.Y, c.Q) {
+		return &InvalidPublicKeyError{Reason: "порядок точки не равен q"}
+	}
+	return nil
+}
+
+// Проверка публичного ключа для кривой Signer
+func (sign *Signer) ValidatePublicKey(pubKey *PublicKey) error {
+	return ValidatePublicKey(sign.c, pubKey)
+}
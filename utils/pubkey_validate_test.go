@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestValidatePublicKey(t *testing.T) {
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		pub, _, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sign.ValidatePublicKey(pub); err != nil {
+			t.Fatalf("%s: верный ключ отвергнут: %v", ps.Name, err)
+		}
+	}
+}
+
+func TestValidatePublicKeyRejects(t *testing.T) {
+	c := NewCurve256ParamSetA()
+	sign := NewSigner(c, 256)
+	pub, priv, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Точка четвертого порядка и ключ с составляющей четвертого порядка
+	t4x, t4y, err := c.EdwardsToWeierstrass(big.NewInt(1), big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mx, my := c.Add(pub.X, pub.Y, t4x, t4y)
+
+	rejects := []struct {
+		name   string
+		key    *PublicKey
+		reason string
+	}{
+		{"ключ не задан", nil, "не заданы"},
+		{"координата не задана", &PublicKey{X: pub.X}, "не заданы"},
+		{"x вне [0, p)", NewPublicKey(new(big.Int).Add(pub.X, c.P), pub.Y), "[0, p)"},
+		{"y < 0", NewPublicKey(pub.X, new(big.Int).Sub(pub.Y, c.P)), "[0, p)"},
+		{"точка не на кривой", NewPublicKey(pub.X, new(big.Int).Add(pub.Y, i1)), "не лежит на кривой"},
+		{"точка на бесконечности", NewPublicKey(big.NewInt(0), big.NewInt(0)), "бесконечности"},
+		{"точка малого порядка", NewPublicKey(t4x, t4y), "порядок"},
+		{"составляющая малого порядка", NewPublicKey(mx, my), "порядок"},
+	}
+	for _, r := range rejects {
+		var keyErr *InvalidPublicKeyError
+		err := ValidatePublicKey(c, r.key)
+		if !errors.As(err, &keyErr) || !strings.Contains(keyErr.Reason, r.reason) {
+			t.Fatalf("%s: %v, ожидалась ошибка InvalidPublicKeyError с %q", r.name, err, r.reason)
+		}
+
+		// Проверка подписи сообщения и хеша и выработка общего ключа возвращают ту же ошибку
+		if r.key == nil {
+			continue
+		}
+		signature := make([]byte, 64)
+		signature[31], signature[63] = 1, 1
+		if ok, err := sign.VerifySign([]byte("сообщение"), signature, r.key); ok || !errors.As(err, &keyErr) {
+			t.Fatalf("%s: VerifySign: %t, %v, ожидалась ошибка InvalidPublicKeyError", r.name, ok, err)
+		}
+		if ok, err := sign.VerifyDigest(sign.Digest([]byte("сообщение")), signature, r.key); ok || !errors.As(err, &keyErr) {
+			t.Fatalf("%s: VerifyDigest: %t, %v, ожидалась ошибка InvalidPublicKeyError", r.name, ok, err)
+		}
+		if _, err := sign.VKO(priv, r.key, big.NewInt(1), 256); !errors.As(err, &keyErr) {
+			t.Fatalf("%s: VKO: %v, ожидалась ошибка InvalidPublicKeyError", r.name, err)
+		}
+	}
+}
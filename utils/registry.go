@@ -0,0 +1,232 @@
+package utils
+
+// Реестр стандартизованных наборов параметров эллиптических кривых
+// RFC 4357 https://datatracker.ietf.org/doc/rfc4357/
+// RFC 9215 https://datatracker.ietf.org/doc/rfc9215/
+// Каждому OID соответствует отдельная запись, даже если кривая совпадает с другим набором
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Описание набора параметров эллиптической кривой
+type ParamSet struct {
+	// Каноническое имя набора
+	Name string
+	// Альтернативные имена
+	Aliases []string
+	// OID набора параметров
+	OID asn1.ObjectIdentifier
+	// Размер ключа в битах 256/512
+	KeySize int
+	// Режим хеширования 256/512, он же режим работы Signer
+	HashMode int
+
+	// Конструктор кривой
+	curve func() *Curve
+}
+
+// Перечень наборов параметров в порядке вывода в справке
+var paramSets = []*ParamSet{
+	{
+		Name:     "id-GostR3410-2001-TestParamSet",
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 2, 2, 35, 0},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256TestParamSet,
+	},
+	{
+		Name:     "id-GostR3410-2001-CryptoPro-A-ParamSet",
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 2, 2, 35, 1},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256CryptoProParamSetA,
+	},
+	{
+		Name:     "id-GostR3410-2001-CryptoPro-B-ParamSet",
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 2, 2, 35, 2},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256CryptoProParamSetB,
+	},
+	{
+		Name:     "id-GostR3410-2001-CryptoPro-C-ParamSet",
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 2, 2, 35, 3},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256CryptoProParamSetC,
+	},
+	{
+		// Совпадает с CryptoPro-A
+		Name:     "id-GostR3410-2001-CryptoPro-XchA-ParamSet",
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 2, 2, 36, 0},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256CryptoProParamSetA,
+	},
+	{
+		// Совпадает с CryptoPro-C
+		Name:     "id-GostR3410-2001-CryptoPro-XchB-ParamSet",
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 2, 2, 36, 1},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256CryptoProParamSetC,
+	},
+	{
+		Name:     "id-tc26-gost-3410-2012-256-paramSetA",
+		Aliases:  []string{"id-tc26-gost-3410-12-256-paramSetA"},
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 1, 1},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256ParamSetA,
+	},
+	{
+		// Совпадает с CryptoPro-A
+		Name:     "id-tc26-gost-3410-2012-256-paramSetB",
+		Aliases:  []string{"id-tc26-gost-3410-12-256-paramSetB"},
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 1, 2},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256CryptoProParamSetA,
+	},
+	{
+		// Совпадает с CryptoPro-B
+		Name:     "id-tc26-gost-3410-2012-256-paramSetC",
+		Aliases:  []string{"id-tc26-gost-3410-12-256-paramSetC"},
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 1, 3},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256CryptoProParamSetB,
+	},
+	{
+		// Совпадает с CryptoPro-C
+		Name:     "id-tc26-gost-3410-2012-256-paramSetD",
+		Aliases:  []string{"id-tc26-gost-3410-12-256-paramSetD"},
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 1, 4},
+		KeySize:  256,
+		HashMode: 256,
+		curve:    NewCurve256CryptoProParamSetC,
+	},
+	{
+		Name:     "id-tc26-gost-3410-2012-512-paramSetTest",
+		Aliases:  []string{"id-tc26-gost-3410-12-512-paramSetTest"},
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 2, 0},
+		KeySize:  512,
+		HashMode: 512,
+		curve:    NewCurve512TestParamSet,
+	},
+	{
+		Name:     "id-tc26-gost-3410-2012-512-paramSetA",
+		Aliases:  []string{"id-tc26-gost-3410-12-512-paramSetA"},
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 2, 1},
+		KeySize:  512,
+		HashMode: 512,
+		curve:    NewCurve512ParamSetA,
+	},
+	{
+		Name:     "id-tc26-gost-3410-2012-512-paramSetB",
+		Aliases:  []string{"id-tc26-gost-3410-12-512-paramSetB"},
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 2, 2},
+		KeySize:  512,
+		HashMode: 512,
+		curve:    NewCurve512ParamSetB,
+	},
+	{
+		Name:     "id-tc26-gost-3410-2012-512-paramSetC",
+		Aliases:  []string{"id-tc26-gost-3410-12-512-paramSetC"},
+		OID:      asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 2, 3},
+		KeySize:  512,
+		HashMode: 512,
+		curve:    NewCurve512ParamSetC,
+	},
+}
+
+// Получение новой кривой набора параметров с заполненными именем и OID
+func (ps *ParamSet) Curve() *Curve {
+	c := ps.curve()
+	c.Name = ps.Name
+	c.OID = ps.OID
+	return c
+}
+
+// Проверка соответствия имени набора параметров
+// Регистр букв не учитывается
+func (ps *ParamSet) hasName(name string) bool {
+	if strings.EqualFold(ps.Name, name) {
+		return true
+	}
+	for _, alias := range ps.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Перечень всех известных наборов параметров
+func ParamSets() []*ParamSet {
+	return append([]*ParamSet(nil), paramSets...)
+}
+
+// Поиск набора параметров по имени или псевдониму
+func ParamSetByName(name string) (*ParamSet, error) {
+	for _, ps := range paramSets {
+		if ps.hasName(name) {
+			return ps, nil
+		}
+	}
+	return nil, fmt.Errorf("неизвестный набор параметров эллиптической кривой: %s", name)
+}
+
+// Поиск набора параметров по OID
+func ParamSetByOID(oid asn1.ObjectIdentifier) (*ParamSet, error) {
+	for _, ps := range paramSets {
+		if ps.OID.Equal(oid) {
+			return ps, nil
+		}
+	}
+	return nil, fmt.Errorf("неизвестный OID набора параметров эллиптической кривой: %s", oid)
+}
+
+// Поиск набора параметров по строке с именем или OID в точечной записи
+func LookupParamSet(s string) (*ParamSet, error) {
+	if oid, ok := parseOID(s); ok {
+		return ParamSetByOID(oid)
+	}
+	return ParamSetByName(s)
+}
+
+// Поиск набора параметров, которому соответствует кривая
+// Если кривая получена из реестра - по OID, иначе по совпадению параметров,
+// при этом возвращается первый подходящий набор
+func ParamSetByCurve(c *Curve) (*ParamSet, error) {
+	if c.OID != nil {
+		return ParamSetByOID(c.OID)
+	}
+	for _, ps := range paramSets {
+		if ps.curve().equalParams(c) {
+			return ps, nil
+		}
+	}
+	return nil, fmt.Errorf("кривая не соответствует ни одному известному набору параметров")
+}
+
+// Разбор OID в точечной записи, например 1.2.643.7.1.2.1.2.1
+func parseOID(s string) (asn1.ObjectIdentifier, bool) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, false
+	}
+	oid := make(asn1.ObjectIdentifier, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		oid[i] = n
+	}
+	return oid, true
+}
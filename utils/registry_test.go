@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParamSetRegistry(t *testing.T) {
+	vectors := []struct {
+		name    string
+		aliases []string
+		oid     string
+		keySize int
+		curve   func() *Curve
+	}{
+		{"id-GostR3410-2001-TestParamSet", nil, "1.2.643.2.2.35.0", 256, NewCurve256TestParamSet},
+		{"id-GostR3410-2001-CryptoPro-A-ParamSet", nil, "1.2.643.2.2.35.1", 256, NewCurve256CryptoProParamSetA},
+		{"id-GostR3410-2001-CryptoPro-B-ParamSet", nil, "1.2.643.2.2.35.2", 256, NewCurve256CryptoProParamSetB},
+		{"id-GostR3410-2001-CryptoPro-C-ParamSet", nil, "1.2.643.2.2.35.3", 256, NewCurve256CryptoProParamSetC},
+		{"id-GostR3410-2001-CryptoPro-XchA-ParamSet", nil, "1.2.643.2.2.36.0", 256, NewCurve256CryptoProParamSetA},
+		{"id-GostR3410-2001-CryptoPro-XchB-ParamSet", nil, "1.2.643.2.2.36.1", 256, NewCurve256CryptoProParamSetC},
+		{"id-tc26-gost-3410-2012-256-paramSetA", []string{"id-tc26-gost-3410-12-256-paramSetA"}, "1.2.643.7.1.2.1.1.1", 256, NewCurve256ParamSetA},
+		{"id-tc26-gost-3410-2012-256-paramSetB", []string{"id-tc26-gost-3410-12-256-paramSetB"}, "1.2.643.7.1.2.1.1.2", 256, NewCurve256CryptoProParamSetA},
+		{"id-tc26-gost-3410-2012-256-paramSetC", []string{"id-tc26-gost-3410-12-256-paramSetC"}, "1.2.643.7.1.2.1.1.3", 256, NewCurve256CryptoProParamSetB},
+		{"id-tc26-gost-3410-2012-256-paramSetD", []string{"id-tc26-gost-3410-12-256-paramSetD"}, "1.2.643.7.1.2.1.1.4", 256, NewCurve256CryptoProParamSetC},
+		{"id-tc26-gost-3410-2012-512-paramSetTest", []string{"id-tc26-gost-3410-12-512-paramSetTest"}, "1.2.643.7.1.2.1.2.0", 512, NewCurve512TestParamSet},
+		{"id-tc26-gost-3410-2012-512-paramSetA", []string{"id-tc26-gost-3410-12-512-paramSetA"}, "1.2.643.7.1.2.1.2.1", 512, NewCurve512ParamSetA},
+		{"id-tc26-gost-3410-2012-512-paramSetB", []string{"id-tc26-gost-3410-12-512-paramSetB"}, "1.2.643.7.1.2.1.2.2", 512, NewCurve512ParamSetB},
+		{"id-tc26-gost-3410-2012-512-paramSetC", []string{"id-tc26-gost-3410-12-512-paramSetC"}, "1.2.643.7.1.2.1.2.3", 512, NewCurve512ParamSetC},
+	}
+
+	// Перечень полон и не содержит лишних наборов
+	all := ParamSets()
+	if len(all) != len(vectors) {
+		t.Fatalf("в реестре %d наборов, ожидалось %d", len(all), len(vectors))
+	}
+	for i, v := range vectors {
+		if all[i].Name != v.name {
+			t.Fatalf("набор %d: %s, ожидалось %s", i, all[i].Name, v.name)
+		}
+	}
+	all[0] = nil
+	if ParamSets()[0] == nil {
+		t.Fatalf("изменение результата ParamSets изменило реестр")
+	}
+
+	for _, v := range vectors {
+		ps, err := ParamSetByName(v.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ps.OID.String() != v.oid || ps.KeySize != v.keySize || ps.HashMode != v.keySize {
+			t.Fatalf("%s: OID %s, размер ключа %d, хеш %d", v.name, ps.OID, ps.KeySize, ps.HashMode)
+		}
+
+		// Имя без учета регистра, псевдонимы, OID
+		lookups := append([]string{strings.ToUpper(v.name), v.oid}, v.aliases...)
+		for _, s := range lookups {
+			found, err := LookupParamSet(s)
+			if err != nil {
+				t.Fatalf("%s: %v", s, err)
+			}
+			if found != ps {
+				t.Fatalf("%s: найден %s, ожидался %s", s, found.Name, v.name)
+			}
+		}
+		if found, err := ParamSetByOID(ps.OID); err != nil || found != ps {
+			t.Fatalf("%s: поиск по OID: %v", v.name, err)
+		}
+
+		c := ps.Curve()
+		if c.Name != v.name || !c.OID.Equal(ps.OID) {
+			t.Fatalf("%s: кривая с именем %s и OID %s", v.name, c.Name, c.OID)
+		}
+		if !c.equalParams(v.curve()) {
+			t.Fatalf("%s: параметры кривой не совпадают с ожидаемыми", v.name)
+		}
+		if found, err := ParamSetByCurve(c); err != nil || found != ps {
+			t.Fatalf("%s: поиск по кривой: %v", v.name, err)
+		}
+	}
+
+	// Кривая без OID сопоставляется первому набору с теми же параметрами
+	if ps, err := ParamSetByCurve(NewCurve256CryptoProParamSetA()); err != nil || ps.Name != "id-GostR3410-2001-CryptoPro-A-ParamSet" {
+		t.Fatalf("поиск по параметрам кривой: %v, %v", ps, err)
+	}
+
+	for _, s := range []string{"id-tc26-gost-3410-2012-512-paramSetD", "1.2.643.7.1.2.1.2.9", "1.2.x", ""} {
+		if _, err := LookupParamSet(s); err == nil {
+			t.Fatalf("найден несуществующий набор %q", s)
+		}
+	}
+}
@@ -16,16 +16,28 @@ type Signer struct {
 	c *Curve
 	// Режим работы 256/512
 	mode int
+	// Строгий режим проверки подписи
+	strict bool
+	// Способ выработки k, подробнее в utils/nonce.go
+	nonce NonceMode
+	// Источник случайности для ключей и k, если nil - используется crypto/rand
+	rand io.Reader
+	// Проверка подписи перед возвратом, подробнее в utils/fault.go
+	verifyAfterSign bool
 }
 
 // Приватный ключ
 type PrivateKey struct {
+	// Элептическая кривая ключа
+	Curve *Curve
 	// Параметр d
 	D *big.Int
 }
 
 // Публичный ключ
 type PublicKey struct {
+	// Элептическая кривая ключа
+	Curve *Curve
 	// C.x
 	X *big.Int
 	// C.y
@@ -41,6 +53,7 @@ func NewSigner(c *Curve, mode int) *Signer {
 }
 
 // "Конструктор" для типа PrivateKey
+// Ключ не привязан к кривой, для crypto.Signer используется NewPrivateKeyOnCurve
 func NewPrivateKey(d *big.Int) *PrivateKey {
 	return &PrivateKey{
 		D: d,
@@ -48,6 +61,7 @@ func NewPrivateKey(d *big.Int) *PrivateKey {
 }
 
 // "Конструктор" для типа PublicKey
+// Ключ не привязан к кривой, для crypto.PublicKey используется NewPublicKeyOnCurve
 func NewPublicKey(x, y *big.Int) *PublicKey {
 	return &PublicKey{
 		X: x,
@@ -55,6 +69,43 @@ func NewPublicKey(x, y *big.Int) *PublicKey {
 	}
 }
 
+// "Конструктор" для типа PrivateKey, привязанного к кривой c
+func NewPrivateKeyOnCurve(c *Curve, d *big.Int) *PrivateKey {
+	return &PrivateKey{
+		Curve: c,
+		D:     d,
+	}
+}
+
+// "Конструктор" для типа PublicKey, привязанного к кривой c
+func NewPublicKeyOnCurve(c *Curve, x, y *big.Int) *PublicKey {
+	return &PublicKey{
+		Curve: c,
+		X:     x,
+		Y:     y,
+	}
+}
+
+// Получение элептической кривой Signer
+func (sign *Signer) Curve() *Curve {
+	return sign.c
+}
+
+// Установка источника случайности для генерации ключей и подписи
+// Например, аппаратный ДСЧ или фиксированные данные для тестов с известным ответом
+// nil возвращает источник по умолчанию crypto/rand
+func (sign *Signer) SetRand(rnd io.Reader) {
+	sign.rand = rnd
+}
+
+// Получение источника случайности Signer
+func (sign *Signer) random() io.Reader {
+	if sign.rand == nil {
+		return rand.Reader
+	}
+	return sign.rand
+}
+
 // Генерация ключевой пары пользователя
 func (sign *Signer) GenerateKeyPair() (*PublicKey, *PrivateKey, error) {
 
@@ -63,7 +114,7 @@ func (sign *Signer) GenerateKeyPair() (*PublicKey, *PrivateKey, error) {
 
 Generate:
 	// Заполнение слайса рандомными байтами
-	if _, err := io.ReadFull(rand.Reader, raw); err != nil {
+	if _, err := io.ReadFull(sign.random(), raw); err != nil {
 		return nil, nil, err
 	}
 
@@ -83,17 +134,12 @@ Generate:
 	// Рассчет точки эллептической кривой (Q = dP)
 	x, y := sign.c.Exp(d, sign.c.X, sign.c.Y)
 
-	return NewPublicKey(x, y), NewPrivateKey(d), nil
+	return NewPublicKeyOnCurve(sign.c, x, y), NewPrivateKeyOnCurve(sign.c, d), nil
 }
 
-// Подпись потока байт приватным ключом пользователя
-func (sign *Signer) SignBytes(message []byte, privKey *PrivateKey) ([]byte, error) {
-	// Инициализация типа Hasher с режимом работы 256/512
-	hasher := NewHasher(sign.mode)
-
-	// Выработка хеша потока байт (ħ = h(M))
-	hash := hasher.GetHashBytes(message)
-
+// Вычисление e по хешу сообщения
+// a = ħ, e = a (mod q), если e == 0, то e = 1
+func (sign *Signer) hashToE(hash []byte) *big.Int {
 	// Приведение хеша в целочисленное значение
 	// a = ħ
 	a := new(big.Int).SetBytes(hash)
@@ -103,26 +149,12 @@ func (sign *Signer) SignBytes(message []byte, privKey *PrivateKey) ([]byte, erro
 	if e.Cmp(i0) == 0 {
 		e = new(big.Int).Set(i1)
 	}
+	return e
+}
 
-	// Слайс для хранения сгенерированных случайных данных для рассчета k
-	kBytes := make([]byte, int(64))
-
-Start:
-	// Заполнение слайса рандомными байтами
-	if _, err := io.ReadFull(rand.Reader, kBytes); err != nil {
-		return nil, err
-	}
-
-	// приведение к целочисленному значению
-	k := new(big.Int).SetBytes(kBytes)
-	// k = k (mod q)
-	// гарантирует что k < q
-	k = new(big.Int).Mod(k, sign.c.Q)
-	// если k == 0, начинаем сначала
-	if k.Cmp(i0) == 0 {
-		goto Start
-	}
-
+// Вычисление пары (r, s) при заданном k, 0 < k < q
+// Возвращает false, если r или s равны 0 и нужно выбрать другое k
+func (sign *Signer) signWithK(e, d, k *big.Int) (*big.Int, *big.Int, bool) {
 	// Рассчет точки С = kP
 	// r = Cx, сразу берем Cx, потому что Cy не участвует в дальнейших рассчетах
 	r, _ := sign.c.Exp(k, sign.c.X, sign.c.Y)
@@ -132,12 +164,12 @@ Start:
 
 	// Если r == 0, начинаем сначала
 	if r.Cmp(i0) == 0 {
-		goto Start
+		return nil, nil, false
 	}
 
 	// Вычисление s = (rd + ke) (mod q)
 	// r * d
-	rd := new(big.Int).Mul(privKey.D, r)
+	rd := new(big.Int).Mul(d, r)
 	// k * e
 	ke := new(big.Int).Mul(k, e)
 	// (rd + ke)
@@ -147,45 +179,154 @@ Start:
 
 	// Если s == 0, начинаем сначала
 	if s.Cmp(i0) == 0 {
+		return nil, nil, false
+	}
+	return r, s, true
+}
+
+// Выработка подписи (r, s) для числа e со случайным k из источника rnd
+// Для детерминированных режимов k вырабатывается по RFC 6979, подробнее в utils/nonce.go
+func (sign *Signer) signE(rnd io.Reader, e, d *big.Int) (*big.Int, *big.Int, error) {
+	if sign.nonce != NonceRandom {
+		return sign.signDeterministic(rnd, e, d)
+	}
+
+	// Слайс для хранения сгенерированных случайных данных для рассчета k
+	kBytes := make([]byte, int(64))
+
+Start:
+	// Заполнение слайса рандомными байтами
+	if _, err := io.ReadFull(rnd, kBytes); err != nil {
+		return nil, nil, err
+	}
+
+	// приведение к целочисленному значению
+	k := new(big.Int).SetBytes(kBytes)
+	// k = k (mod q)
+	// гарантирует что k < q
+	k = new(big.Int).Mod(k, sign.c.Q)
+	// если k == 0, начинаем сначала
+	if k.Cmp(i0) == 0 {
 		goto Start
 	}
 
-	// конкантенация s и r в байтовом паредставлении
-	// ζ = r || s
-	signature := append(r.Bytes(), s.Bytes()...)
+	// Вычисление r и s, если одно из них равно 0, начинаем сначала
+	r, s, ok := sign.signWithK(e, d, k)
+	if !ok {
+		goto Start
+	}
+	return r, s, nil
+}
+
+// Хеш потока байт, который подписывается SignBytes и проверяется VerifySign
+func (sign *Signer) Digest(message []byte) []byte {
+	// Инициализация типа Hasher с режимом работы 256/512
+	hasher := NewHasher(sign.mode)
+
+	// Выработка хеша потока байт (ħ = h(M))
+	return hasher.GetHashBytes(message)
+}
+
+// Подпись потока байт приватным ключом пользователя
+func (sign *Signer) SignBytes(message []byte, privKey *PrivateKey) ([]byte, error) {
+	return sign.SignDigest(sign.Digest(message), privKey)
+}
+
+// Проверка длины хеша: должна быть равна mode/8 байт
+func (sign *Signer) checkDigest(digest []byte) error {
+	if len(digest) != sign.mode/8 {
+		return fmt.Errorf("неверный размер хеша: %d, для режима %d должен быть %d", len(digest), sign.mode, sign.mode/8)
+	}
+	return nil
+}
+
+// Подпись заранее вычисленного хеша сообщения
+// digest - результат Hasher.GetHashBytes с тем же режимом 256/512, что и у Signer,
+// подпись совпадает по формату с SignBytes и проверяется VerifySign для исходного сообщения
+// Для хеша в порядке байт RFC 6986 используется PrivateKey.Sign, подробнее в utils/crypto_signer.go
+func (sign *Signer) SignDigest(digest []byte, privKey *PrivateKey) ([]byte, error) {
+	if err := sign.checkDigest(digest); err != nil {
+		return nil, err
+	}
+	e := sign.hashToE(digest)
+
+	// Вычисление r и s
+	r, s, err := sign.signE(sign.random(), e, privKey.D)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка подписи для защиты от сбоев
+	if sign.verifyAfterSign {
+		if err := sign.checkSignature(e, r, s, privKey.D); err != nil {
+			return nil, err
+		}
+	}
+
+	// конкантенация r и s в байтовом паредставлении фиксированной длины
+	// ζ = r || s, подробнее в utils/signature_encoding.go
+	signature := sign.EncodeSignature(r, s)
 
 	return signature, nil
 }
 
 // Проверка подписи
+// В строгом режиме (SetStrictVerification) причина отказа возвращается ошибкой
 func (sign *Signer) VerifySign(message []byte, signature []byte, pubKey *PublicKey) (bool, error) {
-	// Если подпись не равна mode * 2, вернуть ошибку
-	if len(signature) != (sign.mode/8)*2 {
-		return false, fmt.Errorf("неверный размер подписи: %d, должен быть %d", len(signature), sign.mode/8)
+	return sign.VerifyDigest(sign.Digest(message), signature, pubKey)
+}
+
+// Проверка подписи заранее вычисленного хеша сообщения
+// digest - результат Hasher.GetHashBytes с тем же режимом 256/512, что и у Signer
+// Неверная длина хеша всегда возвращается ошибкой
+func (sign *Signer) VerifyDigest(digest []byte, signature []byte, pubKey *PublicKey) (bool, error) {
+	if err := sign.checkDigest(digest); err != nil {
+		return false, err
+	}
+
+	// Проверка публичного ключа, подробнее в utils/pubkey_validate.go
+	if err := sign.ValidatePublicKey(pubKey); err != nil {
+		return false, err
 	}
 
+	return sign.verifyDigestTable(digest, signature, sign.c.newTable(pubKey.X, pubKey.Y))
+}
+
+// Проверка подписи хеша по таблице кратных проверенного публичного ключа
+func (sign *Signer) verifyDigestTable(digest []byte, signature []byte, keyTable *pointTable) (bool, error) {
 	// вычисление целочисленных значений r и s из подписи
-	r := new(big.Int).SetBytes(signature[:(sign.mode / 8)])
-	s := new(big.Int).SetBytes(signature[(sign.mode / 8):])
+	// Если подпись не равна mode/8 * 2, вернуть ошибку
+	r, s, err := sign.DecodeSignature(signature)
+	if err != nil {
+		if sign.strict {
+			return false, ErrSignatureEncoding
+		}
+		return false, err
+	}
 
-	// Проверка 0 < r < q и 0 < s < q
-	// Если не пройдена - подпись не верна
-	if r.Cmp(i0) <= 0 || s.Cmp(i0) <= 0 || r.Cmp(sign.c.Q) > 0 || s.Cmp(sign.c.Q) > 0 {
-		return false, nil
+	e := sign.hashToE(digest)
+
+	ok, err := sign.verifyRSTable(e, r, s, keyTable)
+	if !sign.strict {
+		// В обычном режиме нарушение правил означает неверную подпись
+		return ok, nil
 	}
+	return ok, err
+}
 
-	// Инициализация типа Hasher с режимом работы 256/512
-	hasher := NewHasher(sign.mode)
-	// Выработка хеша потока байт (ħ = h(M))
-	hash := hasher.GetHashBytes(message)
-	// Приведение хеша в целочисленное значение
-	// a = ħ
-	a := new(big.Int).SetBytes(hash)
-	// e = a (mod q)
-	e := new(big.Int).Mod(a, sign.c.Q)
-	// если e == 0, то e = 1
-	if e.Cmp(i0) == 0 {
-		e = new(big.Int).Set(i1)
+// Проверка пары (r, s) для числа e
+// Возвращает ошибку с нарушенным правилом строгой проверки, подробнее в utils/verify_strict.go
+func (sign *Signer) verifyRS(e, r, s *big.Int, pubKey *PublicKey) (bool, error) {
+	return sign.verifyRSTable(e, r, s, sign.c.newTable(pubKey.X, pubKey.Y))
+}
+
+// Проверка пары (r, s) для числа e по таблице кратных публичного ключа
+// Таблица позволяет не пересчитывать кратные Q при проверке нескольких подписей, подробнее в utils/batch.go
+func (sign *Signer) verifyRSTable(e, r, s *big.Int, keyTable *pointTable) (bool, error) {
+	// Проверка 0 < r < q и 0 < s < q
+	// Если не пройдена - подпись не верна
+	if r.Cmp(i0) <= 0 || s.Cmp(i0) <= 0 || r.Cmp(sign.c.Q) >= 0 || s.Cmp(sign.c.Q) >= 0 {
+		return false, ErrSignatureRange
 	}
 
 	// Вычисление v, обратного элемента для e
@@ -203,12 +344,20 @@ func (sign *Signer) VerifySign(message []byte, signature []byte, pubKey *PublicK
 
 	// Вычисление точки С = z1P + z2Q
 	// z1P
-	pX, pY := sign.c.Exp(z1, sign.c.X, sign.c.Y)
+	pX, pY := sign.c.expBase(z1)
 	// z2Q
-	qX, qY := sign.c.Exp(z2, pubKey.X, pubKey.Y)
+	qX, qY := sign.c.expTable(z2, keyTable)
 
 	// R = Cx, С = z1P + z2Q
-	R, _ := sign.c.Add(qX, qY, pX, pY)
+	R, RY := sign.c.Add(qX, qY, pX, pY)
+
+	// Промежуточные точки не должны быть точкой на бесконечности (0, 0)
+	if isInfinity(pX, pY) || isInfinity(qX, qY) || isInfinity(R, RY) {
+		return false, ErrSignatureIdentity
+	}
+
+	// R = Cx (mod q), для кривых с кофактором больше 1 модуль p заметно больше q
+	R = new(big.Int).Mod(R, sign.c.Q)
 
 	// Сравнение R и r
 	return R.Cmp(r) == 0, nil
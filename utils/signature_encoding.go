@@ -0,0 +1,76 @@
+package utils
+
+// Кодирование подписи в байтовое представление фиксированной длины
+// - внутренний формат: r || s, используется SignBytes и VerifySign
+// - стандартный формат: s || r, RFC 4491 п.2.2.2, CMS, CryptoPro
+// В обоих форматах каждое число записывается в big-endian длиной mode/8 байт,
+// ведущие нулевые байты сохраняются
+// Порядок байт big-endian выбран намеренно вместо little-endian: так подпись записывают
+// RFC 4491 и gost-engine (OpenSSL), и подписи совместимы с ними без преобразования
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Длина подписи в байтах: 2 * mode/8
+func (sign *Signer) SignatureSize() int {
+	return 2 * (sign.mode / 8)
+}
+
+// Запись пары чисел a || b фиксированной длины
+func (sign *Signer) encodePair(a, b *big.Int) []byte {
+	size := sign.mode / 8
+	out := make([]byte, 2*size)
+	a.FillBytes(out[:size])
+	b.FillBytes(out[size:])
+	return out
+}
+
+// Разбор пары чисел a || b фиксированной длины
+func (sign *Signer) decodePair(signature []byte) (*big.Int, *big.Int, error) {
+	if len(signature) != sign.SignatureSize() {
+		return nil, nil, fmt.Errorf("неверный размер подписи: %d, должен быть %d", len(signature), sign.SignatureSize())
+	}
+	size := sign.mode / 8
+	return new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:]), nil
+}
+
+// Кодирование подписи во внутреннем формате r || s
+func (sign *Signer) EncodeSignature(r, s *big.Int) []byte {
+	return sign.encodePair(r, s)
+}
+
+// Разбор подписи во внутреннем формате r || s
+func (sign *Signer) DecodeSignature(signature []byte) (*big.Int, *big.Int, error) {
+	return sign.decodePair(signature)
+}
+
+// Кодирование подписи в стандартном формате s || r
+func (sign *Signer) EncodeSignatureSR(r, s *big.Int) []byte {
+	return sign.encodePair(s, r)
+}
+
+// Разбор подписи в стандартном формате s || r
+func (sign *Signer) DecodeSignatureSR(signature []byte) (*big.Int, *big.Int, error) {
+	s, r, err := sign.decodePair(signature)
+	return r, s, err
+}
+
+// Перевод подписи из внутреннего формата r || s в стандартный s || r
+func (sign *Signer) SignatureToSR(signature []byte) ([]byte, error) {
+	r, s, err := sign.DecodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	return sign.EncodeSignatureSR(r, s), nil
+}
+
+// Перевод подписи из стандартного формата s || r во внутренний r || s
+func (sign *Signer) SignatureFromSR(signature []byte) ([]byte, error) {
+	r, s, err := sign.DecodeSignatureSR(signature)
+	if err != nil {
+		return nil, err
+	}
+	return sign.EncodeSignature(r, s), nil
+}
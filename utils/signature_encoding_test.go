@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// Кодирование и разбор случайных пар (r, s), включая числа с ведущими нулевыми байтами
+func TestSignatureEncodingRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		q := sign.c.Q
+		for i := 0; i < 1000; i++ {
+			r := new(big.Int).Rand(rnd, q)
+			s := new(big.Int).Rand(rnd, q)
+			// Короткие числа проверяют сохранение ведущих нулей
+			if i%10 == 0 {
+				r.Rsh(r, uint(rnd.Intn(q.BitLen())))
+			}
+			if i%10 == 1 {
+				s.Rsh(s, uint(rnd.Intn(q.BitLen())))
+			}
+
+			rs := sign.EncodeSignature(r, s)
+			sr := sign.EncodeSignatureSR(r, s)
+			if len(rs) != sign.SignatureSize() || len(sr) != sign.SignatureSize() {
+				t.Fatalf("%s: неверная длина подписи %d, %d", ps.Name, len(rs), len(sr))
+			}
+			// s || r - это r || s с переставленными половинами
+			size := sign.SignatureSize() / 2
+			if !bytes.Equal(rs[:size], sr[size:]) || !bytes.Equal(rs[size:], sr[:size]) {
+				t.Fatalf("%s: форматы r || s и s || r не совпадают", ps.Name)
+			}
+
+			if r2, s2, err := sign.DecodeSignature(rs); err != nil || r2.Cmp(r) != 0 || s2.Cmp(s) != 0 {
+				t.Fatalf("%s: DecodeSignature(EncodeSignature(%x, %x)) = %x, %x, %v", ps.Name, r, s, r2, s2, err)
+			}
+			if r2, s2, err := sign.DecodeSignatureSR(sr); err != nil || r2.Cmp(r) != 0 || s2.Cmp(s) != 0 {
+				t.Fatalf("%s: DecodeSignatureSR(EncodeSignatureSR(%x, %x)) = %x, %x, %v", ps.Name, r, s, r2, s2, err)
+			}
+			if got, err := sign.SignatureToSR(rs); err != nil || !bytes.Equal(got, sr) {
+				t.Fatalf("%s: SignatureToSR: %x, %v", ps.Name, got, err)
+			}
+			if got, err := sign.SignatureFromSR(sr); err != nil || !bytes.Equal(got, rs) {
+				t.Fatalf("%s: SignatureFromSR: %x, %v", ps.Name, got, err)
+			}
+		}
+	}
+}
+
+// Подписи случайных сообщений после перекодирования проходят проверку
+func TestSignatureEncodingSignVerify(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		pubKey, privKey, err := sign.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 20; i++ {
+			message := make([]byte, rnd.Intn(256))
+			rnd.Read(message)
+
+			signature, err := sign.SignBytes(message, privKey)
+			if err != nil {
+				t.Fatalf("%s: %v", ps.Name, err)
+			}
+			sr, err := sign.SignatureToSR(signature)
+			if err != nil {
+				t.Fatalf("%s: %v", ps.Name, err)
+			}
+			rs, err := sign.SignatureFromSR(sr)
+			if err != nil {
+				t.Fatalf("%s: %v", ps.Name, err)
+			}
+			if !bytes.Equal(rs, signature) {
+				t.Fatalf("%s: подпись изменилась после перекодирования", ps.Name)
+			}
+			if ok, err := sign.VerifySign(message, rs, pubKey); !ok {
+				t.Fatalf("%s: подпись не прошла проверку: %v", ps.Name, err)
+			}
+		}
+	}
+}
+
+// Подпись неверной длины отвергается
+func TestSignatureEncodingLength(t *testing.T) {
+	for _, ps := range ParamSets() {
+		sign := NewSigner(ps.Curve(), ps.HashMode)
+		for _, n := range []int{0, 1, sign.SignatureSize() - 1, sign.SignatureSize() + 1} {
+			if _, _, err := sign.DecodeSignature(make([]byte, n)); err == nil {
+				t.Fatalf("%s: принята подпись длиной %d", ps.Name, n)
+			}
+			if _, _, err := sign.DecodeSignatureSR(make([]byte, n)); err == nil {
+				t.Fatalf("%s: принята подпись длиной %d", ps.Name, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package utils
+
+// Самоописывающий формат файла подписи
+// Файл - PEM блок GOST SIGNATURE с подписью во внутреннем формате r || s и заголовками:
+//   Version: версия формата
+//   Algorithm: OID алгоритма подписи id-tc26-signwithdigest-gost3410-12-256/512
+//   Param-Set: OID набора параметров кривой
+//   Param-Set-Name: имя набора параметров, справочно
+//   Key-Id: идентификатор ключа подписи, подробнее в PublicKey.KeyID
+//   Signed: время подписи в RFC 3339
+//   File-Name: имя подписанного файла
+//   Digest: подписанный хеш файла в hex, результат Hasher.GetHashBytes
+// Заголовки позволяют выбрать кривую и ключ для проверки и обнаружить изменение файла до проверки подписи
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Текущая версия формата файла подписи
+const SignatureFileVersion = 1
+
+const (
+	// Тип PEM блока файла подписи
+	pemSignatureFile = "GOST SIGNATURE"
+
+	// Заголовки
+	signatureFileHeaderVersion      = "Version"
+	signatureFileHeaderAlgorithm    = "Algorithm"
+	signatureFileHeaderParamSet     = "Param-Set"
+	signatureFileHeaderParamSetName = "Param-Set-Name"
+	signatureFileHeaderKeyID        = "Key-Id"
+	signatureFileHeaderSigned       = "Signed"
+	signatureFileHeaderFileName     = "File-Name"
+	signatureFileHeaderDigest       = "Digest"
+)
+
+// Разобранный файл подписи
+type SignatureFile struct {
+	// Версия формата
+	Version int
+	// OID алгоритма подписи
+	Algorithm asn1.ObjectIdentifier
+	// Набор параметров кривой
+	ParamSet *ParamSet
+	// Идентификатор ключа подписи
+	KeyID []byte
+	// Время подписи
+	Signed time.Time
+	// Имя подписанного файла
+	FileName string
+	// Подписанный хеш
+	Digest []byte
+	// Подпись во внутреннем формате r || s
+	Signature []byte
+}
+
+// Формирование файла подписи для подписи signature хеша digest ключом, публичная часть которого pub
+func NewSignatureFile(pub *PublicKey, digest, signature []byte, fileName string, signed time.Time) (*SignatureFile, error) {
+	ps, err := keyParamSet(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	algo, err := signatureAlgorithm(ps.HashMode)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := pub.KeyID()
+	if err != nil {
+		return nil, err
+	}
+	return &SignatureFile{
+		Version:   SignatureFileVersion,
+		Algorithm: algo,
+		ParamSet:  ps,
+		KeyID:     keyID,
+		Signed:    signed,
+		FileName:  fileName,
+		Digest:    digest,
+		Signature: signature,
+	}, nil
+}
+
+// Запись файла подписи в PEM
+func MarshalSignatureFile(sf *SignatureFile) ([]byte, error) {
+	if strings.ContainsAny(sf.FileName, "\r\n") {
+		return nil, fmt.Errorf("имя файла не должно содержать перевод строки")
+	}
+	if err := sf.check(); err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type: pemSignatureFile,
+		Headers: map[string]string{
+			signatureFileHeaderVersion:      strconv.Itoa(SignatureFileVersion),
+			signatureFileHeaderAlgorithm:    sf.Algorithm.String(),
+			signatureFileHeaderParamSet:     sf.ParamSet.OID.String(),
+			signatureFileHeaderParamSetName: sf.ParamSet.Name,
+			signatureFileHeaderKeyID:        hex.EncodeToString(sf.KeyID),
+			signatureFileHeaderSigned:       sf.Signed.UTC().Format(time.RFC3339),
+			signatureFileHeaderFileName:     sf.FileName,
+			signatureFileHeaderDigest:       hex.EncodeToString(sf.Digest),
+		},
+		Bytes: sf.Signature,
+	}), nil
+}
+
+// Проверка того, что данные являются файлом подписи в самоописывающем формате
+func IsSignatureFile(data []byte) bool {
+	block, _ := pem.Decode(bytes.TrimSpace(data))
+	return block != nil && block.Type == pemSignatureFile
+}
+
+// Разбор файла подписи
+func ParseSignatureFile(data []byte) (*SignatureFile, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(data))
+	if block == nil || block.Type != pemSignatureFile {
+		return nil, fmt.Errorf("файл подписи не содержит PEM блок %s", pemSignatureFile)
+	}
+	h := block.Headers
+
+	sf := &SignatureFile{
+		FileName:  h[signatureFileHeaderFileName],
+		Signature: block.Bytes,
+	}
+	var err error
+	if sf.Version, err = strconv.Atoi(h[signatureFileHeaderVersion]); err != nil {
+		return nil, fmt.Errorf("неверная версия формата файла подписи: %q", h[signatureFileHeaderVersion])
+	}
+	if sf.Version < 1 || sf.Version > SignatureFileVersion {
+		return nil, fmt.Errorf("неподдерживаемая версия формата файла подписи: %d", sf.Version)
+	}
+	var ok bool
+	if sf.Algorithm, ok = parseOID(h[signatureFileHeaderAlgorithm]); !ok {
+		return nil, fmt.Errorf("неверный OID алгоритма подписи: %q", h[signatureFileHeaderAlgorithm])
+	}
+	oid, ok := parseOID(h[signatureFileHeaderParamSet])
+	if !ok {
+		return nil, fmt.Errorf("неверный OID набора параметров в файле подписи: %q", h[signatureFileHeaderParamSet])
+	}
+	if sf.ParamSet, err = ParamSetByOID(oid); err != nil {
+		return nil, err
+	}
+	if sf.KeyID, err = hex.DecodeString(h[signatureFileHeaderKeyID]); err != nil || len(sf.KeyID) == 0 {
+		return nil, fmt.Errorf("неверный идентификатор ключа в файле подписи: %q", h[signatureFileHeaderKeyID])
+	}
+	if sf.Signed, err = time.Parse(time.RFC3339, h[signatureFileHeaderSigned]); err != nil {
+		return nil, fmt.Errorf("неверное время подписи: %q", h[signatureFileHeaderSigned])
+	}
+	if sf.Digest, err = hex.DecodeString(h[signatureFileHeaderDigest]); err != nil {
+		return nil, fmt.Errorf("неверный хеш в файле подписи: %q", h[signatureFileHeaderDigest])
+	}
+	if err := sf.check(); err != nil {
+		return nil, err
+	}
+	return sf, nil
+}
+
+// Проверка согласованности алгоритма, набора параметров и длин хеша и подписи
+func (sf *SignatureFile) check() error {
+	mode := sf.ParamSet.HashMode
+	algo, err := signatureAlgorithm(mode)
+	if err != nil {
+		return err
+	}
+	if !sf.Algorithm.Equal(algo) {
+		return fmt.Errorf("алгоритм подписи %s не соответствует набору параметров %s", sf.Algorithm, sf.ParamSet.Name)
+	}
+	if len(sf.Digest) != mode/8 {
+		return fmt.Errorf("неверная длина хеша в файле подписи: %d, должна быть %d", len(sf.Digest), mode/8)
+	}
+	if len(sf.Signature) != 2*(mode/8) {
+		return fmt.Errorf("неверная длина подписи: %d, должна быть %d", len(sf.Signature), 2*(mode/8))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Подпись сообщения и файл подписи для набора параметров ps
+func signatureFileFixture(t *testing.T, ps *ParamSet, message []byte) (*PublicKey, *SignatureFile) {
+	t.Helper()
+	sign := NewSigner(ps.Curve(), ps.HashMode)
+	pub, priv, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sign.Digest(message)
+	signature, err := sign.SignDigest(digest, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := NewSignatureFile(pub, digest, signature, "документ.txt", time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("%s: %v", ps.Name, err)
+	}
+	return pub, sf
+}
+
+func TestSignatureFileRoundTrip(t *testing.T) {
+	message := []byte("подписываемый файл")
+	for _, ps := range ParamSets() {
+		pub, sf := signatureFileFixture(t, ps, message)
+		data, err := MarshalSignatureFile(sf)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		if !IsSignatureFile(data) || IsKeyFile(data) {
+			t.Fatalf("%s: файл подписи не распознан", ps.Name)
+		}
+
+		got, err := ParseSignatureFile(data)
+		if err != nil {
+			t.Fatalf("%s: %v", ps.Name, err)
+		}
+		// Кривая и алгоритм определяются по заголовкам файла
+		if got.ParamSet != ps || got.Version != SignatureFileVersion || !got.Algorithm.Equal(sf.Algorithm) {
+			t.Fatalf("%s: заголовки %s, %d, %s", ps.Name, got.ParamSet.Name, got.Version, got.Algorithm)
+		}
+		if !got.Signed.Equal(sf.Signed) || got.FileName != sf.FileName || !bytes.Equal(got.KeyID, sf.KeyID) ||
+			!bytes.Equal(got.Digest, sf.Digest) || !bytes.Equal(got.Signature, sf.Signature) {
+			t.Fatalf("%s: файл подписи не совпадает после разбора", ps.Name)
+		}
+
+		sign := NewSigner(got.ParamSet.Curve(), got.ParamSet.HashMode)
+		if ok, err := sign.VerifyDigest(got.Digest, got.Signature, pub); !ok {
+			t.Fatalf("%s: подпись из файла не прошла проверку: %v", ps.Name, err)
+		}
+		keyID, err := pub.KeyID()
+		if err != nil || !bytes.Equal(keyID, got.KeyID) {
+			t.Fatalf("%s: идентификатор ключа не совпадает: %v", ps.Name, err)
+		}
+	}
+}
+
+func TestSignatureFileRejects(t *testing.T) {
+	ps, err := ParamSetByName("id-tc26-gost-3410-2012-512-paramSetB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sf := signatureFileFixture(t, ps, []byte("файл"))
+	data, err := MarshalSignatureFile(sf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := func(name, value string) func(b *pem.Block) {
+		return func(b *pem.Block) { b.Headers[name] = value }
+	}
+	rejects := []struct {
+		name   string
+		modify func(b *pem.Block)
+		err    string
+	}{
+		{"следующая версия формата", header("Version", "2"), "версия"},
+		{"версия не число", header("Version", ""), "версия"},
+		{"неизвестный OID набора параметров", header("Param-Set", "1.2.643.7.1.2.1.2.9"), "1.2.643.7.1.2.1.2.9"},
+		{"неверный OID набора параметров", header("Param-Set", "x"), "OID"},
+		{"неверный OID алгоритма", header("Algorithm", "1..2"), "OID"},
+		{"алгоритм другого размера", header("Algorithm", OIDSignatureGost2012_256.String()), "не соответствует"},
+		{"набор параметров другого размера", header("Param-Set", "1.2.643.7.1.2.1.1.1"), "не соответствует"},
+		{"неверный идентификатор ключа", header("Key-Id", ""), "идентификатор"},
+		{"неверное время подписи", header("Signed", "2024-05-10"), "время"},
+		{"неверный хеш", header("Digest", "zz"), "хеш"},
+		{"длина хеша", header("Digest", hex.EncodeToString(sf.Digest[:32])), "длина хеша"},
+		{"длина подписи", func(b *pem.Block) { b.Bytes = b.Bytes[1:] }, "длина подписи"},
+		{"тип блока", func(b *pem.Block) { b.Type = "GOST PUBLIC KEY" }, "GOST SIGNATURE"},
+	}
+	for _, r := range rejects {
+		modified := rewriteKeyFile(t, data, r.modify)
+		if _, err := ParseSignatureFile(modified); err == nil || !strings.Contains(err.Error(), r.err) {
+			t.Fatalf("%s: %v, ожидалась ошибка с %q", r.name, err, r.err)
+		}
+	}
+
+	if IsSignatureFile([]byte("123456789\n")) {
+		t.Fatal("подпись в десятичном формате распознана как файл подписи")
+	}
+	bad := *sf
+	bad.FileName = "имя\nVersion: 2"
+	if _, err := MarshalSignatureFile(&bad); err == nil {
+		t.Fatal("записано имя файла с переводом строки")
+	}
+	bad = *sf
+	bad.Signature = bad.Signature[:10]
+	if _, err := MarshalSignatureFile(&bad); err == nil {
+		t.Fatal("записана подпись неверной длины")
+	}
+}
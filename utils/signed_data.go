@@ -0,0 +1,109 @@
+package utils
+
+// Подпись и проверка подписанных ASN.1 структур: сертификатов, запросов на сертификат и т.п.
+// Алгоритм id-tc26-signwithdigest-gost3410-12-256/512 без параметров (RFC 9215 п.3),
+// хеш Стрибог от DER подписываемой структуры в порядке байт RFC 6986 интерпретируется
+// как число в little-endian, подпись записывается в BIT STRING в стандартном формате s || r (RFC 4491 п.2.2.2)
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// Подпись подписанной структуры неверна
+var ErrInvalidSignature = errors.New("подпись неверна")
+
+// Режим 256/512 по OID алгоритма подписи
+func signatureMode(oid asn1.ObjectIdentifier) (int, error) {
+	switch {
+	case oid.Equal(OIDSignatureGost2012_256):
+		return 256, nil
+	case oid.Equal(OIDSignatureGost2012_512):
+		return 512, nil
+	}
+	return 0, fmt.Errorf("неподдерживаемый алгоритм подписи: %s", oid)
+}
+
+// Signer для проверки подписи ключом pub алгоритмом algo
+// Размер хеша алгоритма должен соответствовать размеру ключа
+// Проверка выполняется в строгом режиме: DER допускает только каноническую запись подписи
+func verifierFor(pub *PublicKey, algo asn1.ObjectIdentifier) (*Signer, error) {
+	mode, err := signatureMode(algo)
+	if err != nil {
+		return nil, err
+	}
+	if pub.Curve.Size() != mode {
+		return nil, fmt.Errorf("алгоритм подписи %s не соответствует ключу %d бит", algo, pub.Curve.Size())
+	}
+	sign := NewSigner(pub.Curve, mode)
+	sign.SetStrictVerification(true)
+	return sign, nil
+}
+
+// Подпись DER структуры data приватным ключом
+// Возвращает идентификатор алгоритма и значение подписи для записи в подписанную структуру
+func (sign *Signer) signData(data []byte, privKey *PrivateKey) (algorithmIdentifier, asn1.BitString, error) {
+	if !sign.c.Equal(privKey.Curve) {
+		return algorithmIdentifier{}, asn1.BitString{}, fmt.Errorf("ключ подписи не относится к кривой %s", sign.c.Name)
+	}
+	algo, err := signatureAlgorithm(sign.mode)
+	if err != nil {
+		return algorithmIdentifier{}, asn1.BitString{}, err
+	}
+	e := sign.hashToE(reverseBytes(streebog(sign.mode, data)))
+
+	r, s, err := sign.signE(sign.random(), e, privKey.D)
+	if err != nil {
+		return algorithmIdentifier{}, asn1.BitString{}, err
+	}
+	// Проверка подписи для защиты от сбоев, подробнее в utils/fault.go
+	if sign.verifyAfterSign {
+		if err := sign.checkSignature(e, r, s, privKey.D); err != nil {
+			return algorithmIdentifier{}, asn1.BitString{}, err
+		}
+	}
+
+	value := sign.EncodeSignatureSR(r, s)
+	return algorithmIdentifier{Algorithm: algo}, asn1.BitString{Bytes: value, BitLength: 8 * len(value)}, nil
+}
+
+// Проверка подписи signature DER структуры data публичным ключом
+// При неверной подписи возвращается ErrInvalidSignature или ошибка строгой проверки
+func (sign *Signer) verifyData(algo algorithmIdentifier, data []byte, signature asn1.BitString, pubKey *PublicKey) error {
+	if !sign.c.Equal(pubKey.Curve) {
+		return fmt.Errorf("ключ проверки не относится к кривой %s", sign.c.Name)
+	}
+	mode, err := signatureMode(algo.Algorithm)
+	if err != nil {
+		return err
+	}
+	if mode != sign.mode {
+		return fmt.Errorf("алгоритм подписи %s не соответствует режиму %d", algo.Algorithm, sign.mode)
+	}
+	// Параметры алгоритма должны отсутствовать, NULL допускается для совместимости
+	if len(algo.Parameters.FullBytes) != 0 && !bytes.Equal(algo.Parameters.FullBytes, asn1.NullBytes) {
+		return fmt.Errorf("алгоритм подписи %s не должен иметь параметров", algo.Algorithm)
+	}
+	if signature.BitLength%8 != 0 {
+		return ErrSignatureEncoding
+	}
+	if err := sign.ValidatePublicKey(pubKey); err != nil {
+		return err
+	}
+
+	r, s, err := sign.DecodeSignatureSR(signature.Bytes)
+	if err != nil {
+		return ErrSignatureEncoding
+	}
+	e := sign.hashToE(reverseBytes(streebog(sign.mode, data)))
+	ok, err := sign.verifyRS(e, r, s, pubKey)
+	if err != nil && sign.strict {
+		return err
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
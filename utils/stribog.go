@@ -460,3 +460,19 @@ func (hash *Hash) GetHashBytes(b []byte) []byte {
 		return hash.hash[:BLOCK_SIZE/2]
 	}
 }
+
+// Получение хеша строки байт в порядке, принятом в RFC 6986
+// GetHashBytes рассматривает сообщение и хеш как числа, записанные от старшего байта к младшему,
+// здесь же первый байт сообщения и хеша является младшим
+func streebog(hash_size int, b []byte) []byte {
+	return reverseBytes(NewHasher(hash_size).GetHashBytes(reverseBytes(b)))
+}
+
+// Получение копии слайса с обратным порядком байт
+func reverseBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[i] = b[len(b)-1-i]
+	}
+	return r
+}
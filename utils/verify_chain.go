@@ -0,0 +1,243 @@
+package utils
+
+// Построение и проверка цепочки сертификатов (RFC 5280 п.6) до доверенного сертификата
+// Проверяются:
+// - совпадение издателя сертификата с субъектом следующего в цепочке и идентификаторов ключей (AKI/SKI)
+// - подписи сертификатов ключами издателей, подробнее в utils/x509.go
+// - сроки действия всех сертификатов цепочки на момент проверки
+// - basicConstraints и ограничение длины цепочки у сертификатов УЦ
+// - keyUsage: keyCertSign у сертификатов УЦ, требуемое использование у конечного сертификата
+// - отсутствие необработанных критичных расширений
+// - статус отзыва по спискам отзыва, если они заданы, подробнее в utils/crl_store.go
+// Доверенные сертификаты (якоря доверия) задаются хранилищем TrustStore
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Максимальная длина цепочки, защищает от циклов и чрезмерного перебора
+const maxChainLength = 10
+
+var (
+	// Не удалось построить цепочку до доверенного сертификата
+	ErrUnknownIssuer = errors.New("не удалось построить цепочку до доверенного сертификата")
+	// Сертификат недействителен на момент проверки
+	ErrCertificateValidity = errors.New("сертификат недействителен на момент проверки")
+	// Сертификат издателя не является сертификатом УЦ
+	ErrNotCA = errors.New("сертификат издателя не является сертификатом УЦ")
+	// Использование ключа не допускает требуемую операцию
+	ErrKeyUsage = errors.New("использование ключа не допускает операцию")
+	// Превышено ограничение длины цепочки
+	ErrPathLength = errors.New("превышено ограничение длины цепочки")
+	// Сертификат содержит необработанное критичное расширение
+	ErrUnhandledCriticalExtension = errors.New("сертификат содержит необработанное критичное расширение")
+)
+
+// Хранилище доверенных сертификатов
+type TrustStore struct {
+	certs []*Certificate
+}
+
+// Конструктор пустого хранилища доверенных сертификатов
+func NewTrustStore() *TrustStore {
+	return &TrustStore{}
+}
+
+// Добавление доверенного сертификата
+// Повторное добавление того же сертификата игнорируется
+func (ts *TrustStore) Add(cert *Certificate) {
+	if !ts.contains(cert) {
+		ts.certs = append(ts.certs, cert)
+	}
+}
+
+// Добавление доверенных сертификатов из PEM
+func (ts *TrustStore) AddPEM(data []byte) error {
+	certs, err := ParseCertificatesPEM(data)
+	if err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		ts.Add(cert)
+	}
+	return nil
+}
+
+// Добавление доверенных сертификатов из файла PEM или из всех файлов .pem, .crt и .cer каталога
+func (ts *TrustStore) AddFile(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	files := []string{path}
+	if fi.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.pem", "*.crt", "*.cer"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return err
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("каталог %s не содержит сертификатов", path)
+		}
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := ts.AddPEM(data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Доверенные сертификаты хранилища
+func (ts *TrustStore) Certificates() []*Certificate {
+	return append([]*Certificate(nil), ts.certs...)
+}
+
+// Проверка наличия сертификата в хранилище
+func (ts *TrustStore) contains(cert *Certificate) bool {
+	return containsCertificate(ts.certs, cert)
+}
+
+// Проверка наличия сертификата в списке
+func containsCertificate(certs []*Certificate, cert *Certificate) bool {
+	for _, c := range certs {
+		if bytes.Equal(c.Raw, cert.Raw) {
+			return true
+		}
+	}
+	return false
+}
+
+// Параметры проверки цепочки
+type VerifyOptions struct {
+	// Хранилище доверенных сертификатов
+	Roots *TrustStore
+	// Промежуточные сертификаты для построения цепочки
+	Intermediates []*Certificate
+	// Момент проверки, если не задан - текущее время
+	CurrentTime time.Time
+	// Требуемое использование ключа конечного сертификата: если задано, keyUsage сертификата
+	// должен допускать хотя бы одно из указанных использований
+	KeyUsage x509.KeyUsage
+	// Списки отзыва: если заданы, каждый сертификат цепочки, кроме доверенного, проверяется
+	// по спискам отзыва своего издателя, при отсутствии действующего списка цепочка отвергается
+	CRLs *CRLStore
+}
+
+// Построение и проверка цепочки от сертификата до доверенного сертификата
+// Возвращает цепочку, начинающуюся с проверяемого сертификата и заканчивающуюся доверенным
+// Если цепочку построить не удалось, возвращается ошибка для последнего рассмотренного варианта
+func (cert *Certificate) Verify(opts VerifyOptions) ([]*Certificate, error) {
+	if opts.Roots == nil {
+		return nil, fmt.Errorf("не задано хранилище доверенных сертификатов")
+	}
+	if opts.CurrentTime.IsZero() {
+		opts.CurrentTime = time.Now()
+	}
+	if opts.KeyUsage != 0 && cert.KeyUsage != 0 && cert.KeyUsage&opts.KeyUsage == 0 {
+		return nil, fmt.Errorf("сертификат %q: %w", cert.Subject, ErrKeyUsage)
+	}
+	return opts.buildChain([]*Certificate{cert})
+}
+
+// Построение цепочки перебором издателей последнего сертификата chain
+func (opts *VerifyOptions) buildChain(chain []*Certificate) ([]*Certificate, error) {
+	cert := chain[len(chain)-1]
+
+	// Сертификат сам является доверенным
+	if opts.Roots.contains(cert) {
+		if err := opts.checkChain(chain); err != nil {
+			return nil, err
+		}
+		return chain, nil
+	}
+	if len(chain) >= maxChainLength {
+		return nil, fmt.Errorf("сертификат %q: %w", chain[0].Subject, ErrPathLength)
+	}
+
+	lastErr := fmt.Errorf("сертификат %q: %w", cert.Subject, ErrUnknownIssuer)
+	candidates := append(opts.Roots.Certificates(), opts.Intermediates...)
+	for _, issuer := range candidates {
+		if !isIssuerCandidate(cert, issuer) || containsCertificate(chain, issuer) {
+			continue
+		}
+		if err := cert.CheckSignatureFrom(issuer); err != nil {
+			lastErr = err
+			continue
+		}
+		next := append(append([]*Certificate(nil), chain...), issuer)
+		result, err := opts.buildChain(next)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Проверка того, что issuer может быть издателем cert: совпадение имени и идентификаторов ключа
+func isIssuerCandidate(cert, issuer *Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	if len(cert.AuthorityKeyId) != 0 && len(issuer.SubjectKeyId) != 0 {
+		return bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId)
+	}
+	return true
+}
+
+// Проверка ограничений построенной цепочки: chain[0] - проверяемый сертификат, последний - доверенный
+func (opts *VerifyOptions) checkChain(chain []*Certificate) error {
+	// Число промежуточных сертификатов УЦ ниже текущего, кроме самовыпущенных (RFC 5280 п.6.1.4)
+	intermediates := 0
+	for i, cert := range chain {
+		if opts.CurrentTime.Before(cert.NotBefore) || opts.CurrentTime.After(cert.NotAfter) {
+			return fmt.Errorf("сертификат %q действителен с %s по %s: %w", cert.Subject,
+				cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339), ErrCertificateValidity)
+		}
+		if len(cert.UnhandledCriticalExtensions) != 0 {
+			return fmt.Errorf("сертификат %q, расширение %s: %w", cert.Subject, cert.UnhandledCriticalExtensions[0], ErrUnhandledCriticalExtension)
+		}
+		if i == 0 {
+			continue
+		}
+
+		// Сертификат издателя
+		if !cert.BasicConstraintsValid || !cert.IsCA {
+			// Доверенный сертификат версии 1 допускается как якорь доверия без расширений
+			if !(i == len(chain)-1 && cert.Version < 3) {
+				return fmt.Errorf("сертификат %q: %w", cert.Subject, ErrNotCA)
+			}
+		}
+		if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+			return fmt.Errorf("сертификат %q не допускает подпись сертификатов: %w", cert.Subject, ErrKeyUsage)
+		}
+		if cert.BasicConstraintsValid && cert.MaxPathLen >= 0 && !(cert.MaxPathLen == 0 && !cert.MaxPathLenZero) {
+			if intermediates > cert.MaxPathLen {
+				return fmt.Errorf("сертификат %q допускает %d промежуточных УЦ: %w", cert.Subject, cert.MaxPathLen, ErrPathLength)
+			}
+		}
+		if !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+			intermediates++
+		}
+		if opts.CRLs != nil {
+			if err := opts.CRLs.Check(chain[i-1], cert, opts.CurrentTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
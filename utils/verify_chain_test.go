@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Начало срока действия тестовых сертификатов и момент проверки цепочек
+var (
+	chainNotBefore = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	chainNow       = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Участник тестовой цепочки: ключевая пара и шаблон сертификата
+type chainEntity struct {
+	pub  *PublicKey
+	priv *PrivateKey
+	tmpl CertificateTemplate
+}
+
+func newChainEntity(t *testing.T, sign *Signer, cn string, isCA bool, maxPathLen int) *chainEntity {
+	t.Helper()
+	pub, priv, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := CertificateTemplate{
+		Subject:    pkix.Name{CommonName: cn},
+		NotBefore:  chainNotBefore,
+		NotAfter:   chainNotBefore.AddDate(5, 0, 0),
+		KeyUsage:   x509.KeyUsageDigitalSignature,
+		IsCA:       isCA,
+		MaxPathLen: maxPathLen,
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	return &chainEntity{pub, priv, tmpl}
+}
+
+// Выпуск сертификата участника e издателем parent (nil - самоподписанный)
+// modify изменяет копию шаблона, так выпускаются сертификаты того же ключа с другими ограничениями,
+// которые CreateCertificate не выпустил бы по сертификату издателя с этими ограничениями
+func (e *chainEntity) issue(t *testing.T, sign *Signer, parent *chainEntity, parentCert *Certificate, modify func(tmpl *CertificateTemplate)) *Certificate {
+	t.Helper()
+	tmpl := e.tmpl
+	if modify != nil {
+		modify(&tmpl)
+	}
+	key := e.priv
+	if parent != nil {
+		key = parent.priv
+	}
+	der, err := sign.CreateCertificate(&tmpl, e.pub, parentCert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestVerifyChain(t *testing.T) {
+	sign := NewSigner(NewCurve256ParamSetA(), 256)
+	root := newChainEntity(t, sign, "Корневой УЦ", true, -1)
+	inter := newChainEntity(t, sign, "Промежуточный УЦ", true, 0)
+	leaf := newChainEntity(t, sign, "Пользователь", false, -1)
+
+	rootCert := root.issue(t, sign, nil, nil, nil)
+	interCert := inter.issue(t, sign, root, rootCert, nil)
+	leafCert := leaf.issue(t, sign, inter, interCert, nil)
+
+	roots := NewTrustStore()
+	roots.Add(rootCert)
+	roots.Add(rootCert)
+	if len(roots.Certificates()) != 1 {
+		t.Fatal("повторно добавлен доверенный сертификат")
+	}
+	opts := VerifyOptions{Roots: roots, Intermediates: []*Certificate{interCert}, CurrentTime: chainNow, KeyUsage: x509.KeyUsageDigitalSignature}
+	chain, err := leafCert.Verify(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 3 || chain[0] != leafCert || chain[1] != interCert || chain[2] != rootCert {
+		t.Fatalf("цепочка из %d сертификатов", len(chain))
+	}
+	// Доверенный сертификат образует цепочку из одного сертификата
+	if chain, err := rootCert.Verify(VerifyOptions{Roots: roots, CurrentTime: chainNow}); err != nil || len(chain) != 1 {
+		t.Fatalf("доверенный сертификат: %d, %v", len(chain), err)
+	}
+
+	// Без промежуточного сертификата и без доверенного корневого цепочка не строится
+	if _, err := leafCert.Verify(VerifyOptions{Roots: roots, CurrentTime: chainNow}); !errors.Is(err, ErrUnknownIssuer) {
+		t.Fatalf("без промежуточного сертификата: %v", err)
+	}
+	other := newChainEntity(t, sign, "Другой УЦ", true, -1)
+	otherRoots := NewTrustStore()
+	otherRoots.Add(other.issue(t, sign, nil, nil, nil))
+	if _, err := leafCert.Verify(VerifyOptions{Roots: otherRoots, Intermediates: []*Certificate{interCert}, CurrentTime: chainNow}); !errors.Is(err, ErrUnknownIssuer) {
+		t.Fatalf("другой корневой УЦ: %v", err)
+	}
+
+	// Момент проверки вне срока действия
+	expired := opts
+	expired.CurrentTime = chainNotBefore.AddDate(6, 0, 0)
+	if _, err := leafCert.Verify(expired); !errors.Is(err, ErrCertificateValidity) {
+		t.Fatalf("истекший срок действия: %v", err)
+	}
+
+	// Использование ключа конечного сертификата не допускает требуемую операцию
+	wrongUsage := opts
+	wrongUsage.KeyUsage = x509.KeyUsageKeyEncipherment
+	if _, err := leafCert.Verify(wrongUsage); !errors.Is(err, ErrKeyUsage) {
+		t.Fatalf("использование ключа конечного сертификата: %v", err)
+	}
+}
+
+// Нарушения ограничений промежуточного сертификата: сертификат пользователя выпущен промежуточным УЦ,
+// а в цепочку передается другой сертификат того же ключа промежуточного УЦ с нарушением
+func TestVerifyChainRejects(t *testing.T) {
+	sign := NewSigner(NewCurve256ParamSetA(), 256)
+	root := newChainEntity(t, sign, "Корневой УЦ", true, -1)
+	inter := newChainEntity(t, sign, "Промежуточный УЦ", true, -1)
+	leaf := newChainEntity(t, sign, "Пользователь", false, -1)
+
+	rootCert := root.issue(t, sign, nil, nil, nil)
+	interCert := inter.issue(t, sign, root, rootCert, nil)
+	roots := NewTrustStore()
+	roots.Add(rootCert)
+
+	rejects := []struct {
+		name string
+		// Изменение сертификата промежуточного УЦ в цепочке
+		inter func(tmpl *CertificateTemplate)
+		// Изменение сертификата пользователя
+		leaf func(tmpl *CertificateTemplate)
+		err  error
+	}{
+		{"истек срок действия промежуточного УЦ", func(tmpl *CertificateTemplate) { tmpl.NotAfter = chainNow.Add(-time.Hour) }, nil, ErrCertificateValidity},
+		{"промежуточный сертификат не УЦ", func(tmpl *CertificateTemplate) { tmpl.IsCA = false }, nil, ErrNotCA},
+		{"нет keyCertSign", func(tmpl *CertificateTemplate) { tmpl.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageCRLSign }, nil, ErrKeyUsage},
+		{"необработанное критичное расширение", nil, func(tmpl *CertificateTemplate) {
+			tmpl.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 643, 2, 2, 99}, Critical: true, Value: []byte{5, 0}}}
+		}, ErrUnhandledCriticalExtension},
+	}
+	for _, r := range rejects {
+		leafCert := leaf.issue(t, sign, inter, interCert, r.leaf)
+		chainInter := interCert
+		if r.inter != nil {
+			chainInter = inter.issue(t, sign, root, rootCert, r.inter)
+		}
+		_, err := leafCert.Verify(VerifyOptions{Roots: roots, Intermediates: []*Certificate{chainInter}, CurrentTime: chainNow})
+		if !errors.Is(err, r.err) {
+			t.Fatalf("%s: %v, ожидалась %v", r.name, err, r.err)
+		}
+	}
+
+	// Идентификатор ключа издателя в сертификате не совпадает с идентификатором ключа промежуточного УЦ
+	wrongKeyID := *interCert.Certificate
+	wrongKeyID.SubjectKeyId = []byte{1, 2, 3, 4}
+	leafCert := leaf.issue(t, sign, inter, &Certificate{Certificate: &wrongKeyID, PublicKey: interCert.PublicKey}, nil)
+	if _, err := leafCert.Verify(VerifyOptions{Roots: roots, Intermediates: []*Certificate{interCert}, CurrentTime: chainNow}); !errors.Is(err, ErrUnknownIssuer) {
+		t.Fatalf("несовпадение AKI и SKI: %v", err)
+	}
+}
+
+// pathLen = 0 у корневого сертификата и два промежуточных УЦ
+// Промежуточные сертификаты выпущены сертификатом того же корневого ключа без ограничения длины цепочки
+func TestVerifyChainPathLength(t *testing.T) {
+	sign := NewSigner(NewCurve256ParamSetA(), 256)
+	root := newChainEntity(t, sign, "Корневой УЦ", true, -1)
+	inter1 := newChainEntity(t, sign, "Промежуточный УЦ 1", true, -1)
+	inter2 := newChainEntity(t, sign, "Промежуточный УЦ 2", true, -1)
+	leaf := newChainEntity(t, sign, "Пользователь", false, -1)
+
+	rootCert := root.issue(t, sign, nil, nil, nil)
+	inter1Cert := inter1.issue(t, sign, root, rootCert, nil)
+	inter2Cert := inter2.issue(t, sign, inter1, inter1Cert, nil)
+	leafCert := leaf.issue(t, sign, inter2, inter2Cert, nil)
+	intermediates := []*Certificate{inter1Cert, inter2Cert}
+
+	for _, pathLen := range []int{0, 1, 2} {
+		rootLimited := root.issue(t, sign, nil, nil, func(tmpl *CertificateTemplate) { tmpl.MaxPathLen = pathLen })
+		roots := NewTrustStore()
+		roots.Add(rootLimited)
+		_, err := leafCert.Verify(VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: chainNow})
+		if pathLen < 2 && !errors.Is(err, ErrPathLength) || pathLen == 2 && err != nil {
+			t.Fatalf("pathLen %d: %v", pathLen, err)
+		}
+	}
+}
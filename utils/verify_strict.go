@@ -0,0 +1,26 @@
+package utils
+
+// Строгий режим проверки подписи
+// В строгом режиме VerifySign возвращает ошибку с нарушенным правилом:
+// - подпись имеет длину, отличную от 2 * mode/8 (ErrSignatureEncoding)
+// - r или s вне интервала (0, q) (ErrSignatureRange)
+// - z1·P, z2·Q или C равны точке на бесконечности (ErrSignatureIdentity)
+// Координата C.x всегда приводится по модулю q перед сравнением с r
+
+import (
+	"errors"
+)
+
+var (
+	// Неканоническое представление подписи
+	ErrSignatureEncoding = errors.New("неканоническое представление подписи")
+	// r или s вне интервала (0, q)
+	ErrSignatureRange = errors.New("r и s подписи должны удовлетворять 0 < r, s < q")
+	// Промежуточная точка проверки равна точке на бесконечности
+	ErrSignatureIdentity = errors.New("промежуточная точка проверки подписи равна точке на бесконечности")
+)
+
+// Включение или выключение строгого режима проверки подписи
+func (sign *Signer) SetStrictVerification(strict bool) {
+	sign.strict = strict
+}
@@ -0,0 +1,139 @@
+package utils
+
+// Граничные векторы проверки подписи в строгом и обычном режимах для всех наборов параметров
+// Ключ и k получаются из фиксированных строк, поэтому векторы воспроизводимы
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// Граничный вектор проверки подписи
+type verifyVector struct {
+	// Описание
+	name string
+	// Сообщение и подпись
+	message   []byte
+	signature []byte
+	// Ожидаемый результат
+	valid bool
+	// Ожидаемая ошибка строгого режима
+	err error
+}
+
+// Получение фиксированного числа 0 < v < q из строки, для ключей и k векторов
+func fixedScalar(sign *Signer, label string) *big.Int {
+	v := new(big.Int).SetBytes(streebog(512, []byte(label)))
+	v.Mod(v, new(big.Int).Sub(sign.c.Q, i1))
+	return v.Add(v, i1)
+}
+
+// Формирование граничных векторов для кривой Signer
+func strictVerifyVectors(t *testing.T, sign *Signer) ([]verifyVector, *PublicKey) {
+	q := sign.c.Q
+	d := fixedScalar(sign, "gost34102012 strict verification key")
+	x, y := sign.c.Exp(d, sign.c.X, sign.c.Y)
+	pubKey := NewPublicKeyOnCurve(sign.c, x, y)
+
+	message := []byte("gost34102012 strict verification message")
+	e := sign.hashToE(NewHasher(sign.mode).GetHashBytes(message))
+
+	var r, s *big.Int
+	for i := 0; ; i++ {
+		k := fixedScalar(sign, fmt.Sprintf("gost34102012 strict verification k %d", i))
+		var ok bool
+		if r, s, ok = sign.signWithK(e, d, k); ok {
+			break
+		}
+	}
+	valid := sign.EncodeSignature(r, s)
+
+	// Подпись с произвольными r и s, nil если числа не помещаются в mode/8 байт
+	raw := func(r, s *big.Int) []byte {
+		size := sign.mode / 8
+		if (r.BitLen()+7)/8 > size || (s.BitLen()+7)/8 > size {
+			return nil
+		}
+		return sign.EncodeSignature(r, s)
+	}
+	qMinus1 := new(big.Int).Sub(q, i1)
+
+	vectors := []verifyVector{
+		{name: "верная подпись", signature: valid, valid: true},
+		{name: "r = 0", signature: raw(i0, s), err: ErrSignatureRange},
+		{name: "s = 0", signature: raw(r, i0), err: ErrSignatureRange},
+		{name: "r = q", signature: raw(q, s), err: ErrSignatureRange},
+		{name: "s = q", signature: raw(r, q), err: ErrSignatureRange},
+		{name: "r = r + q", signature: raw(new(big.Int).Add(r, q), s), err: ErrSignatureRange},
+		{name: "s = s + q", signature: raw(r, new(big.Int).Add(s, q)), err: ErrSignatureRange},
+		{name: "r = s = q - 1, граница интервала", signature: raw(qMinus1, qMinus1)},
+		{name: "s = q - s", signature: raw(r, new(big.Int).Sub(q, s))},
+		{name: "r и s переставлены", signature: raw(s, r)},
+		{name: "формат s || r вместо r || s", signature: sign.EncodeSignatureSR(r, s)},
+		{name: "подпись другого сообщения", message: []byte("другое сообщение"), signature: valid},
+		{name: "подпись укорочена на байт", signature: valid[1:], err: ErrSignatureEncoding},
+		{name: "ведущий нулевой байт", signature: append([]byte{0}, valid...), err: ErrSignatureEncoding},
+		{name: "лишний байт в конце", signature: append(append([]byte{}, valid...), 0), err: ErrSignatureEncoding},
+		{name: "пустая подпись", signature: []byte{}, err: ErrSignatureEncoding},
+		// s = rd (mod q): C = sv·P - rv·dP = O
+		{name: "C равна точке на бесконечности", signature: raw(r, new(big.Int).Mod(new(big.Int).Mul(r, d), q)), err: ErrSignatureIdentity},
+	}
+
+	// Для кривых с p > 2q координата C.x может быть больше q,
+	// тогда подпись верна только при приведении C.x по модулю q
+	if sign.c.P.Cmp(new(big.Int).Lsh(q, 1)) > 0 {
+		found := false
+		for i := 0; i < 64 && !found; i++ {
+			k := fixedScalar(sign, fmt.Sprintf("gost34102012 strict verification large x %d", i))
+			cx, _ := sign.c.Exp(k, sign.c.X, sign.c.Y)
+			if cx.Cmp(q) < 0 {
+				continue
+			}
+			if r2, s2, ok := sign.signWithK(e, d, k); ok {
+				vectors = append(vectors, verifyVector{name: "C.x >= q", signature: sign.EncodeSignature(r2, s2), valid: true})
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("не найдено k с C.x >= q")
+		}
+	}
+
+	for i := range vectors {
+		if vectors[i].message == nil {
+			vectors[i].message = message
+		}
+	}
+	return vectors, pubKey
+}
+
+func TestStrictVerification(t *testing.T) {
+	for _, ps := range ParamSets() {
+		ps := ps
+		t.Run(ps.Name, func(t *testing.T) {
+			strict := NewSigner(ps.Curve(), ps.HashMode)
+			strict.SetStrictVerification(true)
+			lax := NewSigner(ps.Curve(), ps.HashMode)
+
+			vectors, pubKey := strictVerifyVectors(t, strict)
+			for _, v := range vectors {
+				v := v
+				t.Run(v.name, func(t *testing.T) {
+					if v.signature == nil {
+						t.Skip("числа не помещаются в подпись фиксированной длины для этой кривой")
+					}
+					ok, err := strict.VerifySign(v.message, v.signature, pubKey)
+					if ok != v.valid || !errors.Is(err, v.err) {
+						t.Fatalf("строгий режим: ожидалось %t, %v, получено %t, %v", v.valid, v.err, ok, err)
+					}
+					// В обычном режиме результат тот же, нарушение правил означает неверную подпись
+					if ok, _ := lax.VerifySign(v.message, v.signature, pubKey); ok != v.valid {
+						t.Fatalf("обычный режим: ожидалось %t, получено %t", v.valid, ok)
+					}
+				})
+			}
+		})
+	}
+}
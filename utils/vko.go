@@ -0,0 +1,51 @@
+package utils
+
+// Выработка ключа согласования VKO_GOSTR3410_2012_256 и VKO_GOSTR3410_2012_512
+// Р 50.1.113-2016, RFC 7836 п.4.3
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Выработка общего ключа по своему приватному ключу и публичному ключу второй стороны
+// K = m/q · (UKM · d mod q) · Q, KEK = H(K), где H - Стрибог с длиной хеша hashSize (256/512)
+// Точка K хешируется в виде K.x || K.y, каждая координата в little-endian длиной mode/8 байт
+func (sign *Signer) VKO(privKey *PrivateKey, pubKey *PublicKey, ukm *big.Int, hashSize int) ([]byte, error) {
+	if hashSize != 256 && hashSize != 512 {
+		return nil, fmt.Errorf("неверный размер хеша: %d, должен быть 256 или 512", hashSize)
+	}
+
+	// Проверка публичного ключа второй стороны, подробнее в utils/pubkey_validate.go
+	if err := sign.ValidatePublicKey(pubKey); err != nil {
+		return nil, err
+	}
+
+	// Если UKM == 0, то UKM = 1
+	u := new(big.Int).Mod(ukm, sign.c.Q)
+	if u.Cmp(i0) == 0 {
+		u = new(big.Int).Set(i1)
+	}
+
+	// UKM · d (mod q)
+	k := new(big.Int).Mul(u, privKey.D)
+	k.Mod(k, sign.c.Q)
+
+	// K = m/q · (k · Q)
+	// Умножение на кофактор выполняется отдельно, а не по модулю q, чтобы составляющая
+	// малого порядка в ключе второй стороны обнулялась, а точка малого порядка давала
+	// точку на бесконечности
+	x, y := sign.c.Exp(k, pubKey.X, pubKey.Y)
+	if sign.c.cofactor().Cmp(i1) != 0 {
+		x, y = sign.c.Exp(sign.c.cofactor(), x, y)
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, fmt.Errorf("общий ключ равен точке на бесконечности")
+	}
+
+	// K.x || K.y в little-endian
+	size := sign.mode / 8
+	raw := append(reverseBytes(x.FillBytes(make([]byte, size))), reverseBytes(y.FillBytes(make([]byte, size)))...)
+
+	return streebog(hashSize, raw), nil
+}
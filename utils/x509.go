@@ -0,0 +1,135 @@
+package utils
+
+// Сертификаты X.509 v3 с ключами и подписью ГОСТ Р 34.10-2012 (RFC 5280, RFC 9215)
+// Общие поля сертификата (имена, сроки действия, расширения) разбирает crypto/x509,
+// ключ ГОСТ и его набор параметров разбираются из SubjectPublicKeyInfo,
+// подпись проверяется Signer, подробнее в utils/signed_data.go
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+)
+
+// Тип PEM блока сертификата
+const pemCertificate = "CERTIFICATE"
+
+// Certificate
+type certificate struct {
+	TBSCertificate     asn1.RawValue
+	SignatureAlgorithm algorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+// Сертификат с ключом ГОСТ Р 34.10
+type Certificate struct {
+	// Поля сертификата, разобранные crypto/x509
+	// PublicKey и SignatureAlgorithm стандартной библиотеки для ГОСТ не заполняются
+	*x509.Certificate
+	// Публичный ключ субъекта
+	PublicKey *PublicKey
+	// Набор параметров ключа субъекта
+	ParamSet *ParamSet
+	// OID алгоритма подписи сертификата
+	SignatureAlgorithmOID asn1.ObjectIdentifier
+
+	// Алгоритм и значение подписи в исходном виде
+	signatureAlgorithm algorithmIdentifier
+	signature          asn1.BitString
+}
+
+// Разбор сертификата из DER
+// Ключ субъекта должен быть ключом ГОСТ и проходить ValidatePublicKey,
+// алгоритм подписи издателя при разборе не проверяется
+func ParseCertificate(der []byte) (*Certificate, error) {
+	var raw certificate
+	if rest, err := asn1.Unmarshal(der, &raw); err != nil {
+		return nil, fmt.Errorf("неверная структура сертификата: %w", err)
+	} else if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние данные после сертификата")
+	}
+	xc, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ParsePKIXPublicKey(xc.RawSubjectPublicKeyInfo)
+	if err != nil {
+		return nil, fmt.Errorf("ключ субъекта сертификата: %w", err)
+	}
+	ps, err := keyParamSet(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{
+		Certificate:           xc,
+		PublicKey:             pub,
+		ParamSet:              ps,
+		SignatureAlgorithmOID: raw.SignatureAlgorithm.Algorithm,
+		signatureAlgorithm:    raw.SignatureAlgorithm,
+		signature:             raw.SignatureValue,
+	}, nil
+}
+
+// Разбор первого сертификата из PEM с заголовком CERTIFICATE
+func ParseCertificatePEM(data []byte) (*Certificate, error) {
+	der, err := decodePEM(data, pemCertificate)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCertificate(der)
+}
+
+// Разбор всех сертификатов из PEM с заголовком CERTIFICATE
+// Блоки других типов пропускаются
+func ParseCertificatesPEM(data []byte) ([]*Certificate, error) {
+	var certs []*Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != pemCertificate {
+			continue
+		}
+		cert, err := ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("не найден PEM блок %s", pemCertificate)
+	}
+	return certs, nil
+}
+
+// Проверка наличия PEM блока CERTIFICATE
+func IsCertificatePEM(data []byte) bool {
+	_, err := decodePEM(data, pemCertificate)
+	return err == nil
+}
+
+// Запись сертификата в PEM с заголовком CERTIFICATE
+func (cert *Certificate) MarshalPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemCertificate, Bytes: cert.Raw})
+}
+
+// Проверка подписи сертификата ключом издателя parent
+// Издатель сертификата должен совпадать с субъектом parent
+// Ограничения parent (basicConstraints, keyUsage) не проверяются
+func (cert *Certificate) CheckSignatureFrom(parent *Certificate) error {
+	if !bytes.Equal(cert.RawIssuer, parent.RawSubject) {
+		return fmt.Errorf("издатель сертификата %q не совпадает с субъектом %q", cert.Issuer, parent.Subject)
+	}
+	sign, err := verifierFor(parent.PublicKey, cert.SignatureAlgorithmOID)
+	if err != nil {
+		return err
+	}
+	if err := sign.verifyData(cert.signatureAlgorithm, cert.RawTBSCertificate, cert.signature, parent.PublicKey); err != nil {
+		return fmt.Errorf("сертификат %q: %w", cert.Subject, err)
+	}
+	return nil
+}
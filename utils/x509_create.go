@@ -0,0 +1,398 @@
+package utils
+
+// Выпуск сертификатов X.509 v3 с подписью ГОСТ Р 34.10-2012
+// Сертификат подписывается Signer ключом издателя, подробнее в utils/signed_data.go
+// Расширения: basicConstraints, keyUsage, extKeyUsage, subjectKeyIdentifier, authorityKeyIdentifier,
+// cRLDistributionPoints, authorityInfoAccess
+// Идентификатор ключа субъекта - PublicKey.KeyID, подробнее в utils/keyfile.go
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+)
+
+var (
+	// Расширения сертификата RFC 5280
+	oidExtensionSubjectKeyID     = asn1.ObjectIdentifier{2, 5, 29, 14}
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidExtensionExtKeyUsage      = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidExtensionAuthorityKeyID   = asn1.ObjectIdentifier{2, 5, 29, 35}
+	oidExtensionCRLDistribution  = asn1.ObjectIdentifier{2, 5, 29, 31}
+	oidExtensionAuthorityInfo    = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 1}
+
+	// Метод доступа id-ad-ocsp расширения authorityInfoAccess
+	oidAccessOCSP = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1}
+)
+
+// OID назначений ключа extKeyUsage
+var extKeyUsageOIDs = map[x509.ExtKeyUsage]asn1.ObjectIdentifier{
+	x509.ExtKeyUsageAny:             {2, 5, 29, 37, 0},
+	x509.ExtKeyUsageServerAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	x509.ExtKeyUsageClientAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	x509.ExtKeyUsageCodeSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	x509.ExtKeyUsageEmailProtection: {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	x509.ExtKeyUsageTimeStamping:    {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	x509.ExtKeyUsageOCSPSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+}
+
+// Длина серийного номера в байтах, RFC 5280 допускает до 20
+const serialNumberSize = 16
+
+// TBSCertificate
+type tbsCertificate struct {
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm algorithmIdentifier
+	Issuer             asn1.RawValue
+	Validity           validity
+	Subject            asn1.RawValue
+	PublicKey          asn1.RawValue
+	Extensions         []pkix.Extension `asn1:"optional,explicit,tag:3"`
+}
+
+// Validity
+type validity struct {
+	NotBefore, NotAfter time.Time
+}
+
+// BasicConstraints
+type basicConstraints struct {
+	IsCA       bool `asn1:"optional"`
+	MaxPathLen int  `asn1:"optional,default:-1"`
+}
+
+// AuthorityKeyIdentifier, используется только keyIdentifier
+type authorityKeyID struct {
+	ID []byte `asn1:"optional,tag:0"`
+}
+
+// Шаблон выпускаемого сертификата
+type CertificateTemplate struct {
+	// Серийный номер, если nil - вырабатывается случайный
+	SerialNumber *big.Int
+	// Субъект
+	Subject pkix.Name
+	// Субъект в DER, если задан - используется вместо Subject
+	// Позволяет перенести имя из запроса на сертификат без изменений
+	RawSubject []byte
+	// Срок действия
+	NotBefore, NotAfter time.Time
+	// Использование ключа, если 0 - расширение не добавляется
+	KeyUsage x509.KeyUsage
+	// Назначения ключа
+	ExtKeyUsage []x509.ExtKeyUsage
+	// Сертификат удостоверяющего центра
+	IsCA bool
+	// Ограничение длины цепочки для сертификата УЦ, отрицательное значение - без ограничения
+	MaxPathLen int
+	// URL списков отзыва для расширения cRLDistributionPoints
+	CRLDistributionPoints []string
+	// URL ответчиков OCSP для расширения authorityInfoAccess
+	OCSPServer []string
+	// Дополнительные расширения, например запрошенные в запросе на сертификат
+	ExtraExtensions []pkix.Extension
+}
+
+// Выработка случайного положительного серийного номера
+// rnd - источник случайности, если nil - используется crypto/rand
+func NewSerialNumber(rnd io.Reader) (*big.Int, error) {
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	buf := make([]byte, serialNumberSize)
+	for {
+		if _, err := io.ReadFull(rnd, buf); err != nil {
+			return nil, err
+		}
+		// Старший бит сброшен, чтобы число в DER было положительным и не длиннее serialNumberSize
+		buf[0] &= 0x7f
+		serial := new(big.Int).SetBytes(buf)
+		if serial.Sign() > 0 {
+			return serial, nil
+		}
+	}
+}
+
+// Запись использования ключа в BIT STRING без завершающих нулевых бит
+func marshalKeyUsage(ku x509.KeyUsage) asn1.BitString {
+	var bits [2]byte
+	length := 0
+	for i := 0; i < 9; i++ {
+		if ku&(1<<i) != 0 {
+			bits[i/8] |= 0x80 >> (i % 8)
+			length = i + 1
+		}
+	}
+	return asn1.BitString{Bytes: bits[:(length+7)/8], BitLength: length}
+}
+
+// Расширение keyUsage, всегда критичное
+func keyUsageExtension(ku x509.KeyUsage) (pkix.Extension, error) {
+	value, err := asn1.Marshal(marshalKeyUsage(ku))
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: value}, nil
+}
+
+// Расширение extKeyUsage
+func extKeyUsageExtension(ekus []x509.ExtKeyUsage) (pkix.Extension, error) {
+	oids := make([]asn1.ObjectIdentifier, 0, len(ekus))
+	for _, eku := range ekus {
+		oid, ok := extKeyUsageOIDs[eku]
+		if !ok {
+			return pkix.Extension{}, fmt.Errorf("неподдерживаемое назначение ключа: %d", eku)
+		}
+		oids = append(oids, oid)
+	}
+	value, err := asn1.Marshal(oids)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: oidExtensionExtKeyUsage, Value: value}, nil
+}
+
+// Перенос расширений, запрошенных в запросе на сертификат, в дополнительные расширения шаблона
+// Расширения, формируемые по шаблону (basicConstraints, keyUsage, идентификаторы ключей
+// и extKeyUsage, если задан в шаблоне), определяет УЦ, запрошенные значения не учитываются
+func (tmpl *CertificateTemplate) AddRequestedExtensions(csr *CertificateRequest) {
+	managed := []asn1.ObjectIdentifier{oidExtensionBasicConstraints, oidExtensionKeyUsage, oidExtensionSubjectKeyID, oidExtensionAuthorityKeyID}
+	if len(tmpl.CRLDistributionPoints) != 0 {
+		managed = append(managed, oidExtensionCRLDistribution)
+	}
+	if len(tmpl.OCSPServer) != 0 {
+		managed = append(managed, oidExtensionAuthorityInfo)
+	}
+	if len(tmpl.ExtKeyUsage) != 0 {
+		managed = append(managed, oidExtensionExtKeyUsage)
+	}
+	for _, e := range tmpl.ExtraExtensions {
+		managed = append(managed, e.Id)
+	}
+	for _, ext := range csr.Extensions {
+		if !containsOID(managed, ext.Id) {
+			tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+		}
+	}
+}
+
+// Проверка наличия OID в списке
+func containsOID(oids []asn1.ObjectIdentifier, oid asn1.ObjectIdentifier) bool {
+	for _, o := range oids {
+		if o.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessDescription
+type accessDescription struct {
+	Method   asn1.ObjectIdentifier
+	Location asn1.RawValue
+}
+
+// Формирование расширений сертификата по шаблону
+func (tmpl *CertificateTemplate) extensions(subjectKeyID, authorityKeyIdentifier []byte) ([]pkix.Extension, error) {
+	var exts []pkix.Extension
+
+	// basicConstraints критично для сертификата УЦ (RFC 5280 п.4.2.1.9)
+	bc := basicConstraints{IsCA: tmpl.IsCA, MaxPathLen: -1}
+	if tmpl.IsCA && tmpl.MaxPathLen >= 0 {
+		bc.MaxPathLen = tmpl.MaxPathLen
+	}
+	value, err := asn1.Marshal(bc)
+	if err != nil {
+		return nil, err
+	}
+	exts = append(exts, pkix.Extension{Id: oidExtensionBasicConstraints, Critical: tmpl.IsCA, Value: value})
+
+	if tmpl.KeyUsage != 0 {
+		ext, err := keyUsageExtension(tmpl.KeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, ext)
+	}
+
+	if len(tmpl.ExtKeyUsage) != 0 {
+		ext, err := extKeyUsageExtension(tmpl.ExtKeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, ext)
+	}
+
+	if value, err = asn1.Marshal(subjectKeyID); err != nil {
+		return nil, err
+	}
+	exts = append(exts, pkix.Extension{Id: oidExtensionSubjectKeyID, Value: value})
+
+	if value, err = asn1.Marshal(authorityKeyID{ID: authorityKeyIdentifier}); err != nil {
+		return nil, err
+	}
+	exts = append(exts, pkix.Extension{Id: oidExtensionAuthorityKeyID, Value: value})
+
+	if len(tmpl.CRLDistributionPoints) != 0 {
+		ext, err := distributionPointsExtension(oidExtensionCRLDistribution, tmpl.CRLDistributionPoints)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, ext)
+	}
+
+	if len(tmpl.OCSPServer) != 0 {
+		var access []accessDescription
+		for _, url := range tmpl.OCSPServer {
+			// uniformResourceIdentifier [6] IA5String
+			access = append(access, accessDescription{Method: oidAccessOCSP, Location: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte(url)}})
+		}
+		if value, err = asn1.Marshal(access); err != nil {
+			return nil, err
+		}
+		exts = append(exts, pkix.Extension{Id: oidExtensionAuthorityInfo, Value: value})
+	}
+
+	// Дополнительные расширения не должны дублировать формируемые по шаблону
+	for _, ext := range tmpl.ExtraExtensions {
+		for _, e := range exts {
+			if ext.Id.Equal(e.Id) {
+				return nil, fmt.Errorf("расширение %s задается полями шаблона", ext.Id)
+			}
+		}
+		exts = append(exts, ext)
+	}
+	return exts, nil
+}
+
+// Проверка того, что сертификат parent может выпускать сертификаты по шаблону tmpl
+func (tmpl *CertificateTemplate) checkIssuer(parent *Certificate) error {
+	if !parent.BasicConstraintsValid || !parent.IsCA {
+		return fmt.Errorf("сертификат издателя %q не является сертификатом УЦ", parent.Subject)
+	}
+	if parent.KeyUsage != 0 && parent.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return fmt.Errorf("ключ издателя %q не предназначен для подписи сертификатов", parent.Subject)
+	}
+	if tmpl.IsCA && parent.MaxPathLen == 0 && parent.MaxPathLenZero {
+		return fmt.Errorf("издатель %q не может выпускать сертификаты УЦ: ограничение длины цепочки 0", parent.Subject)
+	}
+	if tmpl.NotBefore.Before(parent.NotBefore) || tmpl.NotAfter.After(parent.NotAfter) {
+		return fmt.Errorf("срок действия сертификата выходит за срок действия сертификата издателя %q", parent.Subject)
+	}
+	return nil
+}
+
+// Выпуск сертификата для ключа pub по шаблону tmpl
+// parent - сертификат издателя, privKey - его приватный ключ
+// Если parent равен nil, выпускается самоподписанный сертификат и privKey должен соответствовать pub
+// Возвращает сертификат в DER, подпись которого проверена ключом издателя
+func (sign *Signer) CreateCertificate(tmpl *CertificateTemplate, pub *PublicKey, parent *Certificate, privKey *PrivateKey) ([]byte, error) {
+	if privKey.Curve == nil {
+		return nil, fmt.Errorf("приватный ключ издателя не привязан к кривой")
+	}
+	if sign.mode != privKey.Curve.Size() {
+		return nil, fmt.Errorf("режим %d не соответствует ключу издателя %d бит", sign.mode, privKey.Curve.Size())
+	}
+	if !tmpl.NotAfter.After(tmpl.NotBefore) {
+		return nil, fmt.Errorf("окончание срока действия сертификата должно быть позже начала")
+	}
+
+	spki, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	subjectKeyID, err := pub.KeyID()
+	if err != nil {
+		return nil, err
+	}
+
+	subject := tmpl.RawSubject
+	if subject == nil {
+		if subject, err = asn1.Marshal(tmpl.Subject.ToRDNSequence()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Издатель и его ключ
+	issuer, issuerKey, issuerKeyID := subject, pub, subjectKeyID
+	if parent != nil {
+		if err := tmpl.checkIssuer(parent); err != nil {
+			return nil, err
+		}
+		issuer, issuerKey, issuerKeyID = parent.RawSubject, parent.PublicKey, parent.SubjectKeyId
+		if len(issuerKeyID) == 0 {
+			if issuerKeyID, err = parent.PublicKey.KeyID(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !privKey.Public().(*PublicKey).Equal(issuerKey) {
+		return nil, ErrKeyPairMismatch
+	}
+
+	serial := tmpl.SerialNumber
+	if serial == nil {
+		if serial, err = NewSerialNumber(sign.random()); err != nil {
+			return nil, err
+		}
+	}
+	if serial.Sign() <= 0 {
+		return nil, fmt.Errorf("серийный номер сертификата должен быть положительным")
+	}
+
+	exts, err := tmpl.extensions(subjectKeyID, issuerKeyID)
+	if err != nil {
+		return nil, err
+	}
+	algo, err := signatureAlgorithm(sign.mode)
+	if err != nil {
+		return nil, err
+	}
+
+	tbs, err := asn1.Marshal(tbsCertificate{
+		Version:            2,
+		SerialNumber:       serial,
+		SignatureAlgorithm: algorithmIdentifier{Algorithm: algo},
+		Issuer:             asn1.RawValue{FullBytes: issuer},
+		Validity:           validity{tmpl.NotBefore.UTC().Truncate(time.Second), tmpl.NotAfter.UTC().Truncate(time.Second)},
+		Subject:            asn1.RawValue{FullBytes: subject},
+		PublicKey:          asn1.RawValue{FullBytes: spki},
+		Extensions:         exts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sigAlgo, signature, err := sign.signData(tbs, privKey)
+	if err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(certificate{
+		TBSCertificate:     asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: sigAlgo,
+		SignatureValue:     signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка выпущенного сертификата разбором и проверкой подписи
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	issuerCert := cert
+	if parent != nil {
+		issuerCert = parent
+	}
+	if err := cert.CheckSignatureFrom(issuerCert); err != nil {
+		return nil, err
+	}
+	return der, nil
+}
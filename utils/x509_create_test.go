@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// Запрос на сертификат PKCS#10, подписанный ключом субъекта
+func testCertificateRequest(t *testing.T, sign *Signer, subject string, priv *PrivateKey) *CertificateRequest {
+	t.Helper()
+	name, err := ParseDistinguishedName(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := sign.CreateCertificateRequest(&CertificateRequestTemplate{Subject: name}, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+// Самоподписанный сертификат УЦ
+func testRootCertificate(t *testing.T, sign *Signer, tmpl *CertificateTemplate) (*Certificate, *PrivateKey) {
+	t.Helper()
+	pub, priv, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := sign.CreateCertificate(tmpl, pub, nil, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, priv
+}
+
+func TestCreateCertificate(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sign := NewSigner(NewCurve512ParamSetA(), 512)
+	name, err := ParseDistinguishedName("CN=Тестовый УЦ,O=Организация,C=RU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, rootKey := testRootCertificate(t, sign, &CertificateTemplate{
+		SerialNumber: big.NewInt(1),
+		Subject:      name,
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:         true,
+		MaxPathLen:   -1,
+	})
+	if err := root.CheckSignatureFrom(root); err != nil {
+		t.Fatal(err)
+	}
+	if !root.IsCA || root.MaxPathLen != -1 || root.Subject.CommonName != "Тестовый УЦ" || root.SerialNumber.Int64() != 1 ||
+		!bytes.Equal(root.RawIssuer, root.RawSubject) || !bytes.Equal(root.AuthorityKeyId, root.SubjectKeyId) {
+		t.Fatalf("корневой сертификат: %s, IsCA %t, MaxPathLen %d", root.Subject, root.IsCA, root.MaxPathLen)
+	}
+	if !root.NotBefore.Equal(notBefore) || root.KeyUsage != x509.KeyUsageCertSign|x509.KeyUsageCRLSign {
+		t.Fatalf("корневой сертификат: срок действия %s, KeyUsage %b", root.NotBefore, root.KeyUsage)
+	}
+	if keyID, _ := root.PublicKey.KeyID(); !bytes.Equal(root.SubjectKeyId, keyID) {
+		t.Fatal("идентификатор ключа субъекта не совпадает с Key-Id")
+	}
+
+	// Сертификат ключа 256 бит по запросу, подписанный УЦ 512 бит
+	ps, err := ParamSetByName("id-GostR3410-2001-CryptoPro-B-ParamSet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafSign := NewSigner(ps.Curve(), ps.HashMode)
+	leafPub, leafPriv, err := leafSign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr := testCertificateRequest(t, leafSign, "CN=Пользователь,C=RU", leafPriv)
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatal(err)
+	}
+	der, err := sign.CreateCertificate(&CertificateTemplate{
+		RawSubject:  csr.RawSubject,
+		NotBefore:   notBefore,
+		NotAfter:    notBefore.AddDate(1, 0, 0),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, csr.PublicKey, root, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.CheckSignatureFrom(root); err != nil {
+		t.Fatal(err)
+	}
+	if leaf.IsCA || !bytes.Equal(leaf.RawSubject, csr.RawSubject) || !leaf.PublicKey.Equal(leafPub) ||
+		!bytes.Equal(leaf.AuthorityKeyId, root.SubjectKeyId) || !leaf.SignatureAlgorithmOID.Equal(OIDSignatureGost2012_512) {
+		t.Fatalf("сертификат пользователя: %s, издатель %s", leaf.Subject, leaf.Issuer)
+	}
+	if len(leaf.ExtKeyUsage) != 1 || leaf.ExtKeyUsage[0] != x509.ExtKeyUsageClientAuth || leaf.SerialNumber.Sign() <= 0 {
+		t.Fatalf("сертификат пользователя: ExtKeyUsage %v, серийный номер %s", leaf.ExtKeyUsage, leaf.SerialNumber)
+	}
+}
+
+func TestCreateCertificateRejects(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sign := NewSigner(NewCurve256ParamSetA(), 256)
+	root, rootKey := testRootCertificate(t, sign, &CertificateTemplate{
+		Subject:    pkix.Name{CommonName: "УЦ"},
+		NotBefore:  notBefore,
+		NotAfter:   notBefore.AddDate(5, 0, 0),
+		KeyUsage:   x509.KeyUsageCertSign,
+		IsCA:       true,
+		MaxPathLen: 0,
+	})
+	notCA, notCAKey := testRootCertificate(t, sign, &CertificateTemplate{
+		Subject:   pkix.Name{CommonName: "Не УЦ"},
+		NotBefore: notBefore,
+		NotAfter:  notBefore.AddDate(5, 0, 0),
+		KeyUsage:  x509.KeyUsageDigitalSignature,
+	})
+	if !root.MaxPathLenZero {
+		t.Fatal("ограничение длины цепочки 0 не записано")
+	}
+	pub, _, err := sign.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf := func() *CertificateTemplate {
+		return &CertificateTemplate{Subject: pkix.Name{CommonName: "Пользователь"}, NotBefore: notBefore, NotAfter: notBefore.AddDate(1, 0, 0)}
+	}
+	rejects := []struct {
+		name   string
+		modify func(tmpl *CertificateTemplate)
+		parent *Certificate
+		key    *PrivateKey
+	}{
+		{"издатель не УЦ", func(*CertificateTemplate) {}, notCA, notCAKey},
+		{"длина цепочки исчерпана", func(tmpl *CertificateTemplate) { tmpl.IsCA = true }, root, rootKey},
+		{"начало раньше срока действия издателя", func(tmpl *CertificateTemplate) { tmpl.NotBefore = notBefore.Add(-time.Second) }, root, rootKey},
+		{"окончание позже срока действия издателя", func(tmpl *CertificateTemplate) { tmpl.NotAfter = root.NotAfter.Add(time.Second) }, root, rootKey},
+		{"окончание раньше начала", func(tmpl *CertificateTemplate) { tmpl.NotAfter = tmpl.NotBefore }, root, rootKey},
+		{"ключ не соответствует издателю", func(*CertificateTemplate) {}, root, notCAKey},
+		{"отрицательный серийный номер", func(tmpl *CertificateTemplate) { tmpl.SerialNumber = big.NewInt(-1) }, root, rootKey},
+		{"ключ издателя без кривой", func(*CertificateTemplate) {}, root, NewPrivateKey(rootKey.D)},
+	}
+	for _, r := range rejects {
+		tmpl := leaf()
+		r.modify(tmpl)
+		if _, err := sign.CreateCertificate(tmpl, pub, r.parent, r.key); err == nil {
+			t.Fatalf("%s: сертификат выпущен", r.name)
+		}
+	}
+
+	// Ключ УЦ 256 бит не подписывает в режиме 512 бит
+	if _, err := NewSigner(NewCurve256ParamSetA(), 512).CreateCertificate(leaf(), pub, root, rootKey); err == nil {
+		t.Fatal("сертификат выпущен в режиме другого размера")
+	}
+	// Конечный сертификат выпускается издателем с ограничением длины цепочки 0
+	if _, err := sign.CreateCertificate(leaf(), pub, root, rootKey); err != nil {
+		t.Fatal(err)
+	}
+}
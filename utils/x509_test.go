@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"os"
+	"testing"
+)
+
+// Сертификаты с ключами tc26-256-A и tc26-512-A: TBSCertificate сформирован openssl asn1parse -genconf,
+// подпись вычислена отдельной реализацией арифметики по ГОСТ Р 34.10-2012 независимо от Signer
+// Корневые сертификаты самоподписанные, сертификат пользователя 256 бит подписан корневым 512 бит
+var certFixtures = []struct {
+	file     string
+	paramSet string
+	issuer   string
+	ca       bool
+}{
+	{"testdata/cert-root-512.pem", "id-tc26-gost-3410-2012-512-paramSetA", "testdata/cert-root-512.pem", true},
+	{"testdata/cert-root-256.pem", "id-tc26-gost-3410-2012-256-paramSetA", "testdata/cert-root-256.pem", true},
+	{"testdata/cert-leaf-256.pem", "id-tc26-gost-3410-2012-256-paramSetA", "testdata/cert-root-512.pem", false},
+}
+
+func readCertFixture(t *testing.T, path string) *Certificate {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ParseCertificatePEM(data)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return cert
+}
+
+func TestCertificateFixtures(t *testing.T) {
+	for _, f := range certFixtures {
+		cert := readCertFixture(t, f.file)
+		if cert.ParamSet.Name != f.paramSet {
+			t.Fatalf("%s: набор параметров %s, ожидался %s", f.file, cert.ParamSet.Name, f.paramSet)
+		}
+		if cert.IsCA != f.ca || !cert.BasicConstraintsValid {
+			t.Fatalf("%s: IsCA = %t", f.file, cert.IsCA)
+		}
+		if f.ca && cert.KeyUsage != x509.KeyUsageCertSign|x509.KeyUsageCRLSign ||
+			!f.ca && cert.KeyUsage != x509.KeyUsageDigitalSignature {
+			t.Fatalf("%s: KeyUsage = %b", f.file, cert.KeyUsage)
+		}
+
+		issuer := readCertFixture(t, f.issuer)
+		if err := cert.CheckSignatureFrom(issuer); err != nil {
+			t.Fatalf("%s: %v", f.file, err)
+		}
+		if f.ca && !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			t.Fatalf("%s: корневой сертификат не самоподписанный", f.file)
+		}
+		if !f.ca && !bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId) {
+			t.Fatalf("%s: AKI %x не совпадает с SKI издателя %x", f.file, cert.AuthorityKeyId, issuer.SubjectKeyId)
+		}
+
+		// PEM совпадает с исходным файлом
+		data, err := os.ReadFile(f.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(cert.MarshalPEM(), data) || !IsCertificatePEM(data) {
+			t.Fatalf("%s: MarshalPEM не совпадает с файлом", f.file)
+		}
+	}
+}
+
+func TestCertificateRejects(t *testing.T) {
+	root512 := readCertFixture(t, "testdata/cert-root-512.pem")
+	root256 := readCertFixture(t, "testdata/cert-root-256.pem")
+	leaf := readCertFixture(t, "testdata/cert-leaf-256.pem")
+
+	// Сертификат другого издателя
+	if err := leaf.CheckSignatureFrom(root256); err == nil {
+		t.Fatal("принят сертификат другого издателя")
+	}
+
+	// Издатель с тем же именем, но другим ключом
+	impostor := *root256
+	impostor.RawSubject = root512.RawSubject
+	if err := leaf.CheckSignatureFrom(&impostor); err == nil {
+		t.Fatal("принята подпись ключом другого издателя")
+	}
+
+	// Измененный TBSCertificate: последний байт серийного номера
+	der := append([]byte(nil), leaf.Raw...)
+	serial := bytes.Index(der, []byte{0x02, 0x01, 0x01})
+	if serial < 0 {
+		t.Fatal("серийный номер не найден")
+	}
+	der[serial+2] = 2
+	tampered, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tampered.CheckSignatureFrom(root512); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("измененный сертификат: %v", err)
+	}
+
+	if _, err := ParseCertificate(append(append([]byte(nil), leaf.Raw...), 0)); err == nil {
+		t.Fatal("принят сертификат с лишними данными")
+	}
+	if _, err := ParseCertificatePEM(root512.Raw); err == nil {
+		t.Fatal("принят сертификат без PEM блока")
+	}
+
+	// Все сертификаты из одного файла
+	chain := append(leaf.MarshalPEM(), root512.MarshalPEM()...)
+	certs, err := ParseCertificatesPEM(chain)
+	if err != nil || len(certs) != 2 || !bytes.Equal(certs[1].Raw, root512.Raw) {
+		t.Fatalf("разобрано %d сертификатов: %v", len(certs), err)
+	}
+}